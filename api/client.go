@@ -37,6 +37,8 @@ type Client struct {
 	Session     *SessionClient
 	Credentials *CredentialsClient // this replaces the registry endpoint
 	Worklog     *WorklogClient
+	Keyrings    *KeyringsClient
+	Graph       *GraphClient
 	Updates     *UpdatesClient
 
 	// Cryptography related registry endpoints that should be accessed
@@ -76,6 +78,8 @@ func NewClient(cfg *config.Config) *Client {
 	c.Session = &SessionClient{client: rt}
 	c.Credentials = &CredentialsClient{client: rt}
 	c.Worklog = &WorklogClient{client: rt}
+	c.Keyrings = &KeyringsClient{client: rt}
+	c.Graph = &GraphClient{client: rt}
 	c.Updates = &UpdatesClient{client: rt}
 
 	return c
@@ -29,6 +29,19 @@ func (c *CredentialsClient) Search(ctx context.Context, pathexp string, teamIDs
 	return c.listWorker(ctx, v, p)
 }
 
+// SearchDecrypted returns all credentials at the given pathexp, with their
+// values decrypted.
+func (c *CredentialsClient) SearchDecrypted(ctx context.Context, pathexp string, teamIDs []identity.ID, p ProgressFunc) ([]apitypes.CredentialEnvelope, error) {
+	v := &url.Values{}
+	v.Set("pathexp", pathexp)
+
+	for _, id := range teamIDs {
+		v.Add("team_id", id.String())
+	}
+
+	return c.listWorker(ctx, v, p)
+}
+
 // Get returns all credentials at the given path.
 func (c *CredentialsClient) Get(ctx context.Context, path string, p ProgressFunc) ([]apitypes.CredentialEnvelope, error) {
 	v := &url.Values{}
@@ -37,6 +50,39 @@ func (c *CredentialsClient) Get(ctx context.Context, path string, p ProgressFunc
 	return c.listWorker(ctx, v, p)
 }
 
+// History returns every version of the named credential at the given
+// pathexp, most recent first. Values are only decrypted if decrypt is true.
+func (c *CredentialsClient) History(ctx context.Context, pathexp, name string, decrypt bool, p ProgressFunc) ([]apitypes.CredentialVersion, error) {
+	v := &url.Values{}
+	v.Set("pathexp", pathexp)
+	v.Set("name", name)
+	if decrypt {
+		v.Set("decrypt", "true")
+	}
+
+	var resp []apitypes.CredentialVersion
+	err := c.client.DaemonRoundTrip(ctx, "GET", "/credentials/history", v, nil, &resp, p)
+	return resp, err
+}
+
+// Head returns the most recent version of the named credential at the given
+// pathexp, with its value decrypted, or nil if it has never been set.
+func (c *CredentialsClient) Head(ctx context.Context, pathexp, name string, p ProgressFunc) (*apitypes.CredentialVersion, error) {
+	v := &url.Values{}
+	v.Set("pathexp", pathexp)
+	v.Set("name", name)
+	v.Set("decrypt", "true")
+	v.Set("limit", "1")
+
+	var resp []apitypes.CredentialVersion
+	err := c.client.DaemonRoundTrip(ctx, "GET", "/credentials/history", v, nil, &resp, p)
+	if err != nil || len(resp) == 0 {
+		return nil, err
+	}
+
+	return &resp[0], nil
+}
+
 func (c *CredentialsClient) listWorker(ctx context.Context, v *url.Values, p ProgressFunc) ([]apitypes.CredentialEnvelope, error) {
 	var resp []apitypes.CredentialResp
 	err := c.client.DaemonRoundTrip(ctx, "GET", "/credentials", v, nil, &resp, p)
@@ -0,0 +1,32 @@
+package api
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/pathexp"
+)
+
+// GraphClient makes requests to the daemon's credential graph endpoints
+type GraphClient struct {
+	client *apiRoundTripper
+}
+
+// Verify checks the signatures and claim chains of the credential graphs,
+// keyrings, and keyring memberships matching the given PathExp, and of the
+// org's claimtree, returning any objects which fail.
+func (g *GraphClient) Verify(ctx context.Context, pe *pathexp.PathExp,
+	output ProgressFunc) (*apitypes.GraphVerifyResult, error) {
+
+	v := &url.Values{}
+	v.Set("pathexp", pe.String())
+
+	resp := apitypes.GraphVerifyResult{}
+	err := g.client.DaemonRoundTrip(ctx, "GET", "/graph/verify", v, nil, &resp, output)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
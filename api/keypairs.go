@@ -32,6 +32,12 @@ func (k *KeyPairsClient) Revoke(ctx context.Context, orgID *identity.ID, output
 	return k.worker(ctx, "revoke", orgID, output)
 }
 
+// Rotate replaces the user's keypairs in the given org with new ones, sharing
+// their keyring memberships with the new keys before revoking the old ones.
+func (k *KeyPairsClient) Rotate(ctx context.Context, orgID *identity.ID, output ProgressFunc) error {
+	return k.worker(ctx, "rotate", orgID, output)
+}
+
 func (k *KeyPairsClient) worker(ctx context.Context, action string, orgID *identity.ID, output ProgressFunc) error {
 	kpr := keyPairsRequest{OrgID: orgID}
 	return k.client.DaemonRoundTrip(ctx, "POST", "/keypairs/"+action, nil, &kpr, nil, output)
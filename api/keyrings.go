@@ -0,0 +1,27 @@
+package api
+
+import (
+	"context"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/pathexp"
+)
+
+// KeyringsClient makes requests to the daemon's keyrings endpoints
+type KeyringsClient struct {
+	client *apiRoundTripper
+}
+
+// Rekey creates new versions of the keyrings matching the given PathExp, with
+// new key material for their current members, and re-encrypts their secrets.
+// Only keyrings a removed member could decrypt are rekeyed, unless force is
+// true.
+func (k *KeyringsClient) Rekey(ctx context.Context, pe *pathexp.PathExp, force bool,
+	output ProgressFunc) ([]apitypes.RekeyedKeyring, error) {
+
+	req := apitypes.KeyringRekeyRequest{PathExp: pe, Force: force}
+
+	var resp []apitypes.RekeyedKeyring
+	err := k.client.DaemonRoundTrip(ctx, "POST", "/keyrings/rekey", nil, &req, &resp, output)
+	return resp, err
+}
@@ -40,6 +40,59 @@ func (u *UsersClient) Update(ctx context.Context, delta apitypes.ProfileUpdate)
 	return envelope.ConvertUser(&e)
 }
 
+// UpdatePassword changes the user's password, re-encrypting their master key
+// without ending their session.
+func (u *UsersClient) UpdatePassword(ctx context.Context, currentPassword, newPassword string) (envelope.UserInf, error) {
+	req := apitypes.PasswordUpdate{
+		CurrentPassword: currentPassword,
+		NewPassword:     newPassword,
+	}
+
+	e := envelope.Unsigned{}
+	err := u.client.DaemonRoundTrip(ctx, "PUT", "/self/password", nil, &req, &e, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return envelope.ConvertUser(&e)
+}
+
+// ExportRecoveryKit creates a recovery kit holding the user's master key,
+// returning it along with the recovery code which opens it. The user's current
+// password is required.
+func (u *UsersClient) ExportRecoveryKit(ctx context.Context, password string) (*apitypes.RecoveryKitExport, error) {
+	req := apitypes.RecoveryKitExportRequest{Password: password}
+
+	resp := apitypes.RecoveryKitExport{}
+	err := u.client.DaemonRoundTrip(ctx, "POST", "/self/recovery-kit/export", nil, &req, &resp, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// ImportRecoveryKit logs in with a recovery kit, and restores the user's
+// master key from it, protecting it with a new password.
+func (u *UsersClient) ImportRecoveryKit(ctx context.Context, kit *apitypes.RecoveryKit,
+	code, email, newPassword string) (envelope.UserInf, error) {
+
+	req := apitypes.RecoveryKitImport{
+		Kit:         kit,
+		Code:        code,
+		Email:       email,
+		NewPassword: newPassword,
+	}
+
+	e := envelope.Unsigned{}
+	err := u.client.DaemonRoundTrip(ctx, "POST", "/self/recovery-kit/import", nil, &req, &e, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return envelope.ConvertUser(&e)
+}
+
 // Verify verifies the users account using the given code
 func (u *UsersClient) Verify(ctx context.Context, code string) error {
 	verifyEmail := apitypes.VerifyEmail{
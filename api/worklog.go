@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"net/url"
+	"time"
 
 	"github.com/manifoldco/torus-cli/apitypes"
 	"github.com/manifoldco/torus-cli/identity"
@@ -18,11 +19,10 @@ type WorklogClient struct {
 var errUnknownWorklogType = errors.New("Unknown worklog item type")
 
 // List returns the list of all worklog items in the given org.
-func (w *WorklogClient) List(ctx context.Context, orgID *identity.ID) ([]apitypes.WorklogItem, error) {
-	v := &url.Values{}
-	if orgID != nil {
-		v.Set("org_id", orgID.String())
-	}
+func (w *WorklogClient) List(ctx context.Context, orgID *identity.ID,
+	opts *apitypes.WorklogOptions) ([]apitypes.WorklogItem, error) {
+
+	v := worklogValues(orgID, opts)
 
 	var resp []rawWorklogItem
 	err := w.client.DaemonRoundTrip(ctx, "GET", "/worklog", v, nil, &resp, nil)
@@ -43,9 +43,11 @@ func (w *WorklogClient) List(ctx context.Context, orgID *identity.ID) ([]apitype
 }
 
 // Get returns the worklog item with the given id in the given org.
-func (w *WorklogClient) Get(ctx context.Context, orgID *identity.ID, ident *apitypes.WorklogID) (*apitypes.WorklogItem, error) {
+func (w *WorklogClient) Get(ctx context.Context, orgID *identity.ID,
+	ident *apitypes.WorklogID, opts *apitypes.WorklogOptions) (*apitypes.WorklogItem, error) {
+
 	var res rawWorklogItem
-	err := w.singleItemWorker(ctx, "GET", orgID, ident, &res)
+	err := w.singleItemWorker(ctx, "GET", orgID, ident, opts, &res)
 	if err != nil {
 		return nil, err
 	}
@@ -54,18 +56,78 @@ func (w *WorklogClient) Get(ctx context.Context, orgID *identity.ID, ident *apit
 	return res.WorklogItem, err
 }
 
-// Resolve resolves the worklog item with the given id in the given org.
-func (w *WorklogClient) Resolve(ctx context.Context, orgID *identity.ID, ident *apitypes.WorklogID) error {
-	return w.singleItemWorker(ctx, "POST", orgID, ident, nil)
+// Resolve resolves the worklog items with the given ids in the given org,
+// returning the outcome of each. Progress is reported as each item completes.
+func (w *WorklogClient) Resolve(ctx context.Context, orgID *identity.ID,
+	idents []apitypes.WorklogID, opts *apitypes.WorklogOptions,
+	progress ProgressFunc) ([]apitypes.WorklogResult, error) {
+
+	req := apitypes.WorklogResolveRequest{IDs: idents}
+	v := worklogValues(orgID, opts)
+
+	var resp []apitypes.WorklogResult
+	err := w.client.DaemonRoundTrip(ctx, "POST", "/worklog", v, &req, &resp, progress)
+	return resp, err
 }
 
-func (w *WorklogClient) singleItemWorker(ctx context.Context, verb string, orgID *identity.ID, ident *apitypes.WorklogID, res interface{}) error {
+// Dismiss snoozes the worklog item with the given id in the given org until
+// the given time, or dismisses it indefinitely if until is nil.
+func (w *WorklogClient) Dismiss(ctx context.Context, orgID *identity.ID,
+	ident *apitypes.WorklogID, until *time.Time, reason string,
+	opts *apitypes.WorklogOptions) (*apitypes.WorklogItem, error) {
+
+	req := apitypes.WorklogDismissRequest{Until: until, Reason: reason}
+	v := worklogValues(orgID, opts)
+
+	var res rawWorklogItem
+	err := w.client.DaemonRoundTrip(ctx, "PUT", "/worklog/"+ident.String()+"/dismissal", v, &req, &res, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = res.setDetails()
+	return res.WorklogItem, err
+}
+
+// Restore returns a snoozed or dismissed worklog item with the given id in the
+// given org to the worklog.
+func (w *WorklogClient) Restore(ctx context.Context, orgID *identity.ID,
+	ident *apitypes.WorklogID) error {
+
+	v := worklogValues(orgID, nil)
+	return w.client.DaemonRoundTrip(ctx, "DELETE", "/worklog/"+ident.String()+"/dismissal", v, nil, nil, nil)
+}
+
+func (w *WorklogClient) singleItemWorker(ctx context.Context, verb string, orgID *identity.ID,
+	ident *apitypes.WorklogID, opts *apitypes.WorklogOptions, res interface{}) error {
+
+	v := worklogValues(orgID, opts)
+	return w.client.DaemonRoundTrip(ctx, verb, "/worklog/"+ident.String(), v, nil, res, nil)
+}
+
+func worklogValues(orgID *identity.ID, opts *apitypes.WorklogOptions) *url.Values {
 	v := &url.Values{}
 	if orgID != nil {
 		v.Set("org_id", orgID.String())
 	}
 
-	return w.client.DaemonRoundTrip(ctx, verb, "/worklog/"+ident.String(), v, nil, res, nil)
+	if opts != nil && opts.ExpiryWindow != 0 {
+		v.Set("expiry_window", opts.ExpiryWindow.String())
+	}
+
+	if opts != nil && len(opts.SecretAge) > 0 {
+		v.Set("max_secret_age", opts.SecretAge.String())
+	}
+
+	if opts != nil && opts.MachineRevocations {
+		v.Set("machine_revocations", "true")
+	}
+
+	if opts != nil && opts.All {
+		v.Set("all", "true")
+	}
+
+	return v
 }
 
 type rawWorklogItem struct {
@@ -89,6 +151,10 @@ func (w *rawWorklogItem) setDetails() error {
 		fallthrough
 	case apitypes.MachineKeyringMembersWorklogType:
 		w.WorklogItem.Details = &apitypes.KeyringMembersWorklogDetails{}
+	case apitypes.SecretExpiryWorklogType:
+		w.WorklogItem.Details = &apitypes.SecretExpiryWorklogDetails{}
+	case apitypes.SecretAgeWorklogType:
+		w.WorklogItem.Details = &apitypes.SecretAgeWorklogDetails{}
 	default:
 		return errUnknownWorklogType
 	}
@@ -255,6 +255,13 @@ type ProfileUpdate struct {
 	Password string `json:"password"`
 }
 
+// PasswordUpdate contains the current and new passwords for changing a user's
+// password
+type PasswordUpdate struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
 // InviteAccept contains data required to accept org invite
 type InviteAccept struct {
 	Org   string `json:"org"`
@@ -1,10 +1,16 @@
 package apitypes
 
 import (
+	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"reflect"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/manifoldco/torus-cli/identity"
 	"github.com/manifoldco/torus-cli/pathexp"
@@ -12,12 +18,19 @@ import (
 
 var errMistmatchedType = errors.New("Mismatched type and value in credential")
 
+// BinaryValuePrefix marks the string representation of a binary value, which
+// is base64 encoded so it can be safely used in environment variables and
+// text formats.
+const BinaryValuePrefix = "base64:"
+
 const (
 	unsetCV = iota
 	stringCV
 	intCV
 	floatCV
 	undecryptedCV // only used internally to the daemon
+	binaryCV
+	referenceCV
 )
 
 // CredentialEnvelope is an unencrypted credential object with a
@@ -42,6 +55,8 @@ type Credential interface {
 	GetPathExp() *pathexp.PathExp
 	GetProjectID() *identity.ID
 	GetValue() *CredentialValue
+	GetReference() string
+	GetUnresolved() string
 }
 
 // BaseCredential is the body of an unencrypted Credential
@@ -51,6 +66,14 @@ type BaseCredential struct {
 	PathExp   *pathexp.PathExp `json:"pathexp"`
 	ProjectID *identity.ID     `json:"project_id"`
 	Value     *CredentialValue `json:"value"`
+
+	// Reference holds the unresolved value of a reference credential, whose
+	// Value has been resolved by the daemon.
+	Reference string `json:"reference,omitempty"`
+
+	// Unresolved holds why the daemon couldn't resolve a reference
+	// credential's Value, in which case it is left unresolved.
+	Unresolved string `json:"unresolved,omitempty"`
 }
 
 // GetName returns the name
@@ -82,6 +105,18 @@ func (c *BaseCredential) GetValue() *CredentialValue {
 	return c.Value
 }
 
+// GetReference returns the unresolved value of a reference credential, or an
+// empty string for all other credentials.
+func (c *BaseCredential) GetReference() string {
+	return c.Reference
+}
+
+// GetUnresolved returns why a reference credential couldn't be resolved, or
+// an empty string if it was, or for all other credentials.
+func (c *BaseCredential) GetUnresolved() string {
+	return c.Unresolved
+}
+
 // CredentialV2 is the body of an unencrypted Credential
 type CredentialV2 struct {
 	BaseCredential
@@ -102,11 +137,106 @@ func (c *CredentialV2) GetValue() *CredentialValue {
 	return c.Value
 }
 
+// CredentialVersion is a single version from the history of a credential.
+// Value is nil for unset versions, or when the value was not decrypted.
+type CredentialVersion struct {
+	ID                *identity.ID     `json:"id"`
+	Previous          *identity.ID     `json:"previous"`
+	Name              string           `json:"name"`
+	PathExp           *pathexp.PathExp `json:"pathexp"`
+	CredentialVersion int              `json:"version"`
+	KeyringVersion    int              `json:"keyring_version"`
+
+	// KeyringCreated is when the keyring version holding the credential was
+	// created. Credentials aren't timestamped themselves, so this is only the
+	// earliest time the version could have been set.
+	KeyringCreated time.Time `json:"keyring_created_at"`
+
+	SignerID *identity.ID     `json:"signer_id"`
+	Unset    bool             `json:"unset"`
+	Value    *CredentialValue `json:"value"`
+}
+
+// SetAt returns when this version was set, and whether that time is exact.
+// Values set before their set time was recorded, or which weren't decrypted,
+// fall back to when their keyring version was created, which is approximate.
+func (v *CredentialVersion) SetAt() (time.Time, bool) {
+	if v.Value != nil && v.Value.SetAt() != nil {
+		return *v.Value.SetAt(), true
+	}
+
+	return v.KeyringCreated, false
+}
+
 // CredentialValue is the raw value of a credential.
 type CredentialValue struct {
 	cvtype int
 	value  string
 	raw    interface{}
+	meta   *CredentialMetadata
+	setAt  *time.Time
+}
+
+// CredentialMetadata describes a credential. It is encrypted along with the
+// credential's value.
+type CredentialMetadata struct {
+	Description string     `json:"description,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	Owner       string     `json:"owner,omitempty"`
+	Link        string     `json:"link,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// IsEmpty returns if no metadata has been set.
+func (m *CredentialMetadata) IsEmpty() bool {
+	return m == nil || (m.Description == "" && len(m.Tags) == 0 && m.Owner == "" &&
+		m.Link == "" && m.ExpiresAt == nil)
+}
+
+// HasTag returns if the metadata includes the given tag.
+func (m *CredentialMetadata) HasTag(tag string) bool {
+	if m == nil {
+		return false
+	}
+
+	for _, t := range m.Tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Metadata returns the metadata describing this credential, or nil if it has
+// none.
+func (c *CredentialValue) Metadata() *CredentialMetadata {
+	return c.meta
+}
+
+// WithMetadata returns a copy of this credential with the given metadata.
+func (c *CredentialValue) WithMetadata(m *CredentialMetadata) *CredentialValue {
+	cv := *c
+	cv.meta = nil
+	if !m.IsEmpty() {
+		cv.meta = m
+	}
+
+	return &cv
+}
+
+// SetAt returns when this credential's value was set, or nil if it was set
+// before set times were recorded.
+func (c *CredentialValue) SetAt() *time.Time {
+	return c.setAt
+}
+
+// WithSetAt returns a copy of this credential, recording that its value was
+// set at the given time. A nil time clears it.
+func (c *CredentialValue) WithSetAt(t *time.Time) *CredentialValue {
+	cv := *c
+	cv.setAt = t
+	return &cv
 }
 
 // IsUnset returns if this credential has been unset (deleted)
@@ -119,8 +249,21 @@ func (c *CredentialValue) IsUndecrypted() bool {
 	return c.cvtype == undecryptedCV
 }
 
+// IsBinary returns if this credential holds arbitrary binary data, such as
+// the contents of a file.
+func (c *CredentialValue) IsBinary() bool {
+	return c.cvtype == binaryCV
+}
+
+// IsReference returns if this credential's value refers to the values of
+// other credentials, to be interpolated when read.
+func (c *CredentialValue) IsReference() bool {
+	return c.cvtype == referenceCV
+}
+
 // String returns the string representation of this credential. It panics
-// if the credential was deleted.
+// if the credential was deleted. Binary credentials are returned base64
+// encoded, prefixed with BinaryValuePrefix; use Raw for their bytes.
 func (c *CredentialValue) String() string {
 	if c.cvtype == unsetCV {
 		panic("CredentialValue has been unset")
@@ -139,9 +282,12 @@ type credentialImpl struct {
 		Type  string          `json:"type"`
 		Value json.RawMessage `json:"value"`
 	} `json:"body"`
+	Meta  *CredentialMetadata `json:"meta,omitempty"`
+	SetAt *time.Time          `json:"set_at,omitempty"`
 }
 
-// Raw returns the underlying typed value for this Credential.
+// Raw returns the underlying typed value for this Credential. Binary
+// credentials are returned as a []byte.
 func (c *CredentialValue) Raw() (interface{}, error) {
 	if c.IsUnset() {
 		return nil, errors.New("Cannot return raw value of an unset Credential")
@@ -161,6 +307,10 @@ func (c *CredentialValue) MarshalJSON() ([]byte, error) {
 		impl.Body.Type = "number"
 	case floatCV:
 		impl.Body.Type = "number"
+	case binaryCV:
+		impl.Body.Type = "binary"
+	case referenceCV:
+		impl.Body.Type = "reference"
 	case unsetCV:
 		impl.Body.Type = "undefined"
 	case undecryptedCV:
@@ -173,7 +323,15 @@ func (c *CredentialValue) MarshalJSON() ([]byte, error) {
 			return nil, err
 		}
 
+		// Whole floats are written with a fraction, so they aren't read
+		// back as integers.
+		if c.cvtype == floatCV && !bytes.ContainsAny(v, ".eE") {
+			v = append(v, ".0"...)
+		}
+
 		impl.Body.Value = v
+		impl.Meta = c.meta
+		impl.SetAt = c.setAt
 	} else {
 		impl.Body.Value = []byte(`""`)
 	}
@@ -206,13 +364,20 @@ func (c *CredentialValue) UnmarshalJSON(b []byte) error {
 		return err
 	}
 
+	c.meta = impl.Meta
+	c.setAt = impl.SetAt
+
 	switch impl.Body.Type {
 	case "undefined":
 		c.cvtype = unsetCV
 	case "undecrypted":
 		c.cvtype = undecryptedCV
-	case "string":
+	case "string", "reference":
 		c.cvtype = stringCV
+		if impl.Body.Type == "reference" {
+			c.cvtype = referenceCV
+		}
+
 		var v string
 		err := json.Unmarshal(impl.Body.Value, &v)
 		if err != nil {
@@ -238,6 +403,21 @@ func (c *CredentialValue) UnmarshalJSON(b []byte) error {
 		}
 
 		c.value = v.String()
+	case "binary":
+		var v string
+		err := json.Unmarshal(impl.Body.Value, &v)
+		if err != nil {
+			return errMistmatchedType
+		}
+
+		b, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return errMistmatchedType
+		}
+
+		c.cvtype = binaryCV
+		c.raw = b
+		c.value = BinaryValuePrefix + v
 	default:
 		return errors.New("Decoding type " + impl.Body.Type + " is not supported")
 	}
@@ -276,6 +456,27 @@ func NewFloatCredentialValue(f float64) *CredentialValue {
 	}
 }
 
+// NewBinaryCredentialValue creates a CredentialValue holding binary data.
+// The data is stored base64 encoded.
+func NewBinaryCredentialValue(b []byte) *CredentialValue {
+	return &CredentialValue{
+		cvtype: binaryCV,
+		value:  BinaryValuePrefix + base64.StdEncoding.EncodeToString(b),
+		raw:    b,
+	}
+}
+
+// NewReferenceCredentialValue creates a CredentialValue whose value is
+// interpolated from the credentials it references when read. References take
+// the form ${NAME} or ${/org/project/env/service/identity/instance/NAME}.
+func NewReferenceCredentialValue(s string) *CredentialValue {
+	return &CredentialValue{
+		cvtype: referenceCV,
+		value:  s,
+		raw:    s,
+	}
+}
+
 // NewUndecryptedCredentialValue creates a CredentialValue with an undecrypted
 // value
 func NewUndecryptedCredentialValue() *CredentialValue {
@@ -283,3 +484,100 @@ func NewUndecryptedCredentialValue() *CredentialValue {
 		cvtype: undecryptedCV,
 	}
 }
+
+var credentialReferencePattern = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+// CredentialReference is a reference to another credential from within the
+// value of a reference CredentialValue.
+//
+// A reference without a PathExp refers to the credential of the same name
+// which applies to the path being read.
+type CredentialReference struct {
+	PathExp *pathexp.PathExp
+	Name    string
+}
+
+// String returns the reference as it is written in a value.
+func (r *CredentialReference) String() string {
+	if r.PathExp == nil {
+		return "${" + r.Name + "}"
+	}
+
+	return "${" + r.PathExp.String() + "/" + r.Name + "}"
+}
+
+// ParseCredentialReference parses a single reference, either NAME or a full
+// path to a credential.
+func ParseCredentialReference(raw string) (*CredentialReference, error) {
+	ref := &CredentialReference{Name: strings.ToLower(raw)}
+
+	if idx := strings.LastIndex(raw, "/"); idx != -1 {
+		pe, err := pathexp.Parse(raw[:idx])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid reference ${%s}: %s", raw, err)
+		}
+
+		ref.PathExp = pe
+		ref.Name = strings.ToLower(raw[idx+1:])
+	}
+
+	if !pathexp.ValidSecret(ref.Name) || strings.Contains(ref.Name, "*") {
+		return nil, fmt.Errorf("Invalid reference ${%s}: invalid name", raw)
+	}
+
+	return ref, nil
+}
+
+// References returns the credentials referred to by a reference
+// CredentialValue.
+func (c *CredentialValue) References() ([]*CredentialReference, error) {
+	if c.cvtype != referenceCV {
+		return nil, errors.New("CredentialValue is not a reference")
+	}
+
+	var refs []*CredentialReference
+	for _, m := range credentialReferencePattern.FindAllStringSubmatch(c.value, -1) {
+		ref, err := ParseCredentialReference(m[1])
+		if err != nil {
+			return nil, err
+		}
+
+		refs = append(refs, ref)
+	}
+
+	if len(refs) == 0 {
+		return nil, errors.New("A reference value must contain at least one ${NAME} or ${/path/NAME} reference")
+	}
+
+	return refs, nil
+}
+
+// Interpolate returns the value of a reference CredentialValue, with each
+// reference replaced by the value returned from lookup.
+func (c *CredentialValue) Interpolate(lookup func(*CredentialReference) (string, error)) (string, error) {
+	if _, err := c.References(); err != nil {
+		return "", err
+	}
+
+	var err error
+	out := credentialReferencePattern.ReplaceAllStringFunc(c.value, func(m string) string {
+		if err != nil {
+			return ""
+		}
+
+		var ref *CredentialReference
+		ref, err = ParseCredentialReference(m[2 : len(m)-1])
+		if err != nil {
+			return ""
+		}
+
+		var v string
+		v, err = lookup(ref)
+		return v
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return out, nil
+}
@@ -1,9 +1,12 @@
 package apitypes
 
 import (
+	"bytes"
 	"encoding/json"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 func interfaceToCredentialValue(t *testing.T, i interface{}) (*CredentialValue, error) {
@@ -84,6 +87,29 @@ func TestCredentialValueUnmarshalJSON(t *testing.T) {
 
 	})
 
+	tc("binary", "AAEC/w==", func(t *testing.T, c *CredentialValue) {
+		if !c.IsBinary() {
+			t.Error("value was not considered binary")
+		}
+
+		expected := "base64:AAEC/w=="
+		if c.String() != expected {
+			t.Errorf("wrong value! had: '%q' wanted: '%q'", c.String(), expected)
+		}
+	})
+
+	t.Run("invalid binary", func(t *testing.T) {
+		v := map[string]interface{}{
+			"version": 1,
+			"body":    map[string]interface{}{"type": "binary", "value": "not base64!"},
+		}
+
+		_, err := interfaceToCredentialValue(t, v)
+		if err != errMistmatchedType {
+			t.Errorf("expected mismatched type error, got: %v", err)
+		}
+	})
+
 	tc("undecrypted", "", func(t *testing.T, c *CredentialValue) {
 		if c.IsUnset() {
 			t.Error("value is unset")
@@ -111,3 +137,143 @@ func TestCredentialValueUnmarshalJSON(t *testing.T) {
 		}
 	})
 }
+
+func TestBinaryCredentialValueRoundTrip(t *testing.T) {
+	in := []byte{0, 1, 2, 0xff, 0xfe, '\n', 0}
+	cv := NewBinaryCredentialValue(in)
+	b, err := json.Marshal(cv)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if strings.ContainsAny(cv.String(), "\x00\n") {
+		t.Errorf("string value contains raw bytes: %q", cv.String())
+	}
+
+	c := CredentialValue{}
+	err = json.Unmarshal(b, &c)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	raw, err := c.Raw()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !bytes.Equal(raw.([]byte), in) {
+		t.Errorf("wrong value! had: '%v' wanted: '%v'", raw, in)
+	}
+}
+
+func TestFloatCredentialValueRoundTrip(t *testing.T) {
+	for _, f := range []float64{1, -3, 1.5, 1e21} {
+		b, err := json.Marshal(NewFloatCredentialValue(f))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		c := CredentialValue{}
+		err = json.Unmarshal(b, &c)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		raw, err := c.Raw()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if raw != f {
+			t.Errorf("expected float64 %v, got %T %v", f, raw, raw)
+		}
+	}
+}
+
+func TestCredentialValueInterpolate(t *testing.T) {
+	c := NewReferenceCredentialValue("${DB_USER}@${/o/p/e/*/*/*/db_host}")
+
+	var refs []string
+	out, err := c.Interpolate(func(ref *CredentialReference) (string, error) {
+		refs = append(refs, ref.String())
+		return ref.Name, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if out != "db_user@db_host" {
+		t.Errorf("wrong value! had: '%s' wanted: '%s'", out, "db_user@db_host")
+	}
+
+	expected := []string{"${db_user}", "${/o/p/e/*/*/*/db_host}"}
+	if len(refs) != 2 || refs[0] != expected[0] || refs[1] != expected[1] {
+		t.Errorf("wrong references! had: %v wanted: %v", refs, expected)
+	}
+
+	for _, bad := range []string{"no references", "${}", "${a*}", "${/not/a/path/name}"} {
+		_, err := NewReferenceCredentialValue(bad).References()
+		if err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestCredentialValueMetadata(t *testing.T) {
+	meta := &CredentialMetadata{Description: "Legacy key", Tags: []string{"payments"}}
+	b, err := json.Marshal(NewStringCredentialValue("secret").WithMetadata(meta))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	c := CredentialValue{}
+	err = json.Unmarshal(b, &c)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if c.String() != "secret" {
+		t.Errorf("wrong value! had: '%s' wanted: '%s'", c.String(), "secret")
+	}
+
+	if m := c.Metadata(); m == nil || m.Description != "Legacy key" || !m.HasTag("payments") {
+		t.Errorf("wrong metadata! had: %+v wanted: %+v", m, meta)
+	}
+
+	old := strconv.Quote(`{"version":1,"body":{"type":"string","value":"secret"}}`)
+	c = CredentialValue{}
+	err = json.Unmarshal([]byte(old), &c)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if c.Metadata() != nil {
+		t.Errorf("expected no metadata, had: %+v", c.Metadata())
+	}
+
+	if NewStringCredentialValue("x").WithMetadata(&CredentialMetadata{}).Metadata() != nil {
+		t.Error("expected empty metadata to be dropped")
+	}
+}
+
+func TestCredentialValueSetAt(t *testing.T) {
+	at := time.Date(2018, 3, 1, 12, 0, 0, 0, time.UTC)
+	b, err := json.Marshal(NewStringCredentialValue("secret").WithSetAt(&at))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	c := CredentialValue{}
+	err = json.Unmarshal(b, &c)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if c.SetAt() == nil || !c.SetAt().Equal(at) {
+		t.Errorf("wrong set time! had: %v wanted: %v", c.SetAt(), at)
+	}
+
+	if c.WithSetAt(nil).SetAt() != nil {
+		t.Error("expected set time to be cleared")
+	}
+}
@@ -0,0 +1,59 @@
+package apitypes
+
+import "github.com/manifoldco/torus-cli/identity"
+
+// GraphProblem is the reason an object failed verification.
+type GraphProblem string
+
+// The problems found when verifying the objects of a credential graph.
+const (
+	// InvalidSignatureProblem is an object whose signature doesn't match its
+	// contents and signing key.
+	InvalidSignatureProblem GraphProblem = "invalid-signature"
+
+	// InvalidIDProblem is an object whose ID wasn't derived from its contents
+	// and signature.
+	InvalidIDProblem GraphProblem = "invalid-id"
+
+	// UnknownSignerProblem is an object signed by a key that isn't in the
+	// org's claimtree.
+	UnknownSignerProblem GraphProblem = "unknown-signer"
+
+	// RevokedSignerProblem is an object signed by a key after it was revoked.
+	RevokedSignerProblem GraphProblem = "revoked-signer"
+
+	// InvalidChainProblem is an object that doesn't link back to the org
+	// through valid keys and claims, or whose previous links are
+	// inconsistent.
+	InvalidChainProblem GraphProblem = "invalid-chain"
+)
+
+// GraphVerifyResult is the result of verifying the signatures and claim chains
+// of the objects in an org's credential graph.
+type GraphVerifyResult struct {
+	Verified int          `json:"verified"`
+	Issues   []GraphIssue `json:"issues"`
+}
+
+// Failed returns whether any object failed verification. Warnings are not
+// failures.
+func (r *GraphVerifyResult) Failed() bool {
+	for _, issue := range r.Issues {
+		if !issue.Warning {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GraphIssue is an object that failed verification, or which couldn't be
+// fully verified if Warning is true.
+type GraphIssue struct {
+	ID      *identity.ID `json:"id"`
+	Type    string       `json:"type"`
+	Path    string       `json:"path,omitempty"`
+	Problem GraphProblem `json:"problem"`
+	Detail  string       `json:"detail"`
+	Warning bool         `json:"warning,omitempty"`
+}
@@ -0,0 +1,18 @@
+package apitypes
+
+import "github.com/manifoldco/torus-cli/pathexp"
+
+// KeyringRekeyRequest is the request to rekey the keyrings matching a path
+// expression.
+type KeyringRekeyRequest struct {
+	PathExp *pathexp.PathExp `json:"pathexp"`
+	Force   bool             `json:"force"`
+}
+
+// RekeyedKeyring is a new version of a keyring, holding its active
+// credentials under new key material shared only with its current members.
+type RekeyedKeyring struct {
+	PathExp        *pathexp.PathExp `json:"pathexp"`
+	KeyringVersion int              `json:"keyring_version"`
+	Credentials    int              `json:"credentials"`
+}
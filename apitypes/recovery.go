@@ -0,0 +1,49 @@
+package apitypes
+
+import (
+	"time"
+
+	"github.com/manifoldco/go-base64"
+
+	"github.com/manifoldco/torus-cli/identity"
+)
+
+// RecoveryKitVersion is the version of the recovery kit format written by
+// this version of Torus.
+const RecoveryKitVersion = 1
+
+// RecoveryKit holds a user's master key, and the login keypair derived from
+// their password, encrypted with a recovery code. It lets a user who has
+// forgotten their password log in and regain access to the private keys sealed
+// with their master key.
+type RecoveryKit struct {
+	Version  int           `json:"version"`
+	UserID   *identity.ID  `json:"user_id"`
+	Username string        `json:"username"`
+	Created  time.Time     `json:"created_at"`
+	Alg      string        `json:"alg"`
+	Nonce    *base64.Value `json:"nonce"`
+	Value    *base64.Value `json:"value,omitempty"`
+}
+
+// RecoveryKitExportRequest contains the user's current password, which must be
+// given to export a recovery kit.
+type RecoveryKitExportRequest struct {
+	Password string `json:"password"`
+}
+
+// RecoveryKitExport contains a newly exported recovery kit, and the recovery
+// code which opens it. The code is never stored by Torus.
+type RecoveryKitExport struct {
+	Kit  *RecoveryKit `json:"kit"`
+	Code string       `json:"code"`
+}
+
+// RecoveryKitImport contains the data required to log in with a recovery kit,
+// and restore the user's master key from it with a new password.
+type RecoveryKitImport struct {
+	Kit         *RecoveryKit `json:"kit"`
+	Code        string       `json:"code"`
+	Email       string       `json:"email"`
+	NewPassword string       `json:"new_password"`
+}
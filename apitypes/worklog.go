@@ -1,8 +1,12 @@
 package apitypes
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/dchest/blake2b"
 
@@ -22,10 +26,197 @@ const (
 	InviteApproveWorklogType
 	UserKeyringMembersWorklogType
 	MachineKeyringMembersWorklogType
+	SecretExpiryWorklogType
+	SecretAgeWorklogType
 
 	AnyWorklogType WorklogType = 0xff
 )
 
+// WorklogOptions configures how worklog items are found.
+type WorklogOptions struct {
+	// ExpiryWindow is how far ahead to look for expiring secrets. Secrets are
+	// not checked for expiry when it is zero.
+	ExpiryWindow time.Duration
+
+	// SecretAge sets the maximum age of secrets. Secrets are not checked for
+	// their age when it is empty.
+	SecretAge SecretAgePolicy
+
+	// MachineRevocations includes the revocation of machines' access as a
+	// reason to rotate secrets.
+	MachineRevocations bool
+
+	// All includes snoozed and dismissed items.
+	All bool
+}
+
+// WorklogDismissal records a worklog item the user has chosen not to act on,
+// until a date (snoozed) or indefinitely (dismissed).
+type WorklogDismissal struct {
+	Until   *time.Time `json:"until,omitempty"`
+	Reason  string     `json:"reason,omitempty"`
+	Created time.Time  `json:"created_at"`
+
+	// Fingerprint identifies the cause of the item when it was dismissed.
+	// The item resurfaces if its cause changes.
+	Fingerprint string `json:"fingerprint"`
+}
+
+// Active returns whether the dismissal still hides an item with the given
+// fingerprint at the given time.
+func (d *WorklogDismissal) Active(fingerprint string, now time.Time) bool {
+	if d.Fingerprint != fingerprint {
+		return false
+	}
+
+	return d.Until == nil || now.Before(*d.Until)
+}
+
+// WorklogResolveRequest is the request to resolve a batch of worklog items.
+type WorklogResolveRequest struct {
+	IDs []WorklogID `json:"ids"`
+}
+
+// WorklogResultState is the outcome of resolving a worklog item.
+type WorklogResultState string
+
+// The possible outcomes of resolving a worklog item.
+const (
+	WorklogResultSuccess WorklogResultState = "success"
+	WorklogResultFailure WorklogResultState = "failure"
+	WorklogResultManual  WorklogResultState = "manual"
+)
+
+// WorklogResult is the outcome of resolving a single worklog item in a batch.
+type WorklogResult struct {
+	ID      *WorklogID         `json:"id"`
+	State   WorklogResultState `json:"state"`
+	Message string             `json:"message,omitempty"`
+}
+
+// WorklogDismissRequest is the request to snooze or dismiss a worklog item.
+// The item is snoozed if Until is set, otherwise it is dismissed.
+type WorklogDismissRequest struct {
+	Until  *time.Time `json:"until,omitempty"`
+	Reason string     `json:"reason,omitempty"`
+}
+
+// SecretAgeRule sets the maximum age of the secrets within a path.
+type SecretAgeRule struct {
+	// Path holds the org, project, environment, and service segments of the
+	// path, each of which is a name or "*". Missing segments match anything.
+	Path   []string
+	MaxAge time.Duration
+}
+
+// Matches returns whether the rule applies to secrets at the given pathexp.
+func (r *SecretAgeRule) Matches(pe *pathexp.PathExp) bool {
+	subject := []string{pe.Org.String(), pe.Project.String(), pe.Envs.String(), pe.Services.String()}
+	for i, seg := range r.Path {
+		if seg != "*" && seg != subject[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r *SecretAgeRule) specificity() int {
+	n := 0
+	for _, seg := range r.Path {
+		if seg != "*" {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *SecretAgeRule) String() string {
+	days := strconv.Itoa(int(r.MaxAge / (24 * time.Hour)))
+	if len(r.Path) == 0 {
+		return days
+	}
+	return "/" + strings.Join(r.Path, "/") + "=" + days
+}
+
+// SecretAgePolicy is a set of rules for the maximum age of secrets.
+type SecretAgePolicy []SecretAgeRule
+
+// ParseSecretAgePolicy parses a comma separated list of rules. Each rule is a
+// number of days, applying to all secrets, or a path and number of days, such
+// as /myorg/*/production=90.
+func ParseSecretAgePolicy(raw string) (SecretAgePolicy, error) {
+	var policy SecretAgePolicy
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		rule := SecretAgeRule{}
+		days := part
+		if idx := strings.LastIndex(part, "="); idx != -1 {
+			path := part[:idx]
+			days = part[idx+1:]
+
+			if !strings.HasPrefix(path, "/") {
+				return nil, fmt.Errorf("Invalid path %q: paths must start with /", path)
+			}
+			rule.Path = strings.Split(strings.TrimSuffix(path[1:], "/"), "/")
+			if len(rule.Path) > 4 {
+				return nil, fmt.Errorf("Invalid path %q: paths can have at most org, project, environment, and service segments", path)
+			}
+			for _, seg := range rule.Path {
+				if seg != "*" && !pathexp.ValidSlug(seg) {
+					return nil, fmt.Errorf("Invalid path %q: segments must be a name or *", path)
+				}
+			}
+		}
+
+		n, err := strconv.Atoi(strings.TrimSpace(days))
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("Invalid maximum age %q: must be a positive whole number of days", days)
+		}
+		rule.MaxAge = time.Duration(n) * 24 * time.Hour
+
+		policy = append(policy, rule)
+	}
+
+	return policy, nil
+}
+
+// MaxAge returns the maximum age for secrets at the given pathexp. The most
+// specific matching rule is used, with the shortest age winning ties. false is
+// returned if no rule matches.
+func (p SecretAgePolicy) MaxAge(pe *pathexp.PathExp) (time.Duration, bool) {
+	var best *SecretAgeRule
+	for i, r := range p {
+		if !r.Matches(pe) {
+			continue
+		}
+
+		switch {
+		case best == nil, r.specificity() > best.specificity():
+			best = &p[i]
+		case r.specificity() == best.specificity() && r.MaxAge < best.MaxAge:
+			best = &p[i]
+		}
+	}
+
+	if best == nil {
+		return 0, false
+	}
+	return best.MaxAge, true
+}
+
+func (p SecretAgePolicy) String() string {
+	rules := make([]string, len(p))
+	for i, r := range p {
+		rules[i] = r.String()
+	}
+	return strings.Join(rules, ",")
+}
+
 // ErrIncorrectWorklogIDLen is returned when a base32 encoded worklog id is the
 // wrong length.
 var ErrIncorrectWorklogIDLen = errors.New("Incorrect worklog ID length")
@@ -69,6 +260,10 @@ type WorklogItem struct {
 	ID *WorklogID `json:"id"`
 
 	Details WorklogDetails `json:"details"`
+
+	// Dismissal is set for items which have been snoozed or dismissed, when
+	// they are requested.
+	Dismissal *WorklogDismissal `json:"dismissal,omitempty"`
 }
 
 // Subject returns the human readable subject of this WorklogItem.
@@ -163,10 +358,11 @@ type SecretRotateWorklogDetails struct {
 	Reasons []SecretRotateWorklogReason `json:"reasons"`
 }
 
-// SecretRotateWorklogReason holds the username and claim revocation type
-// for a secret rotation reason.
+// SecretRotateWorklogReason holds the username or machine name, and claim
+// revocation type for a secret rotation reason.
 type SecretRotateWorklogReason struct {
 	Username string                                `json:"username"`
+	Machine  string                                `json:"machine,omitempty"`
 	Type     primitive.KeyringMemberRevocationType `json:"type"`
 }
 
@@ -177,7 +373,108 @@ func (s *SecretRotateWorklogDetails) Subject() string {
 
 // Summary returns the human readable summary of this WorklogItem.
 func (s *SecretRotateWorklogDetails) Summary() string {
-	return "A user's access was revoked. This secret's value should be changed."
+	var users, machines bool
+	for _, r := range s.Reasons {
+		if r.Machine != "" {
+			machines = true
+		} else {
+			users = true
+		}
+	}
+
+	switch {
+	case users && machines:
+		return "User and machine access was revoked. This secret's value should be changed."
+	case machines:
+		return "A machine's access was revoked. This secret's value should be changed."
+	default:
+		return "A user's access was revoked. This secret's value should be changed."
+	}
+}
+
+// SecretExpiryWorklogDetails holds WorklogItem details for the
+// SecretExpiryWorklogType.
+type SecretExpiryWorklogDetails struct {
+	PathExp   *pathexp.PathExp `json:"pathexp"`
+	Name      string           `json:"name"`
+	ExpiresAt time.Time        `json:"expires_at"`
+}
+
+// Subject returns the human readable subject of this WorklogItem.
+func (s *SecretExpiryWorklogDetails) Subject() string {
+	return s.PathExp.String() + "/" + s.Name
+}
+
+// Summary returns the human readable summary of this WorklogItem.
+func (s *SecretExpiryWorklogDetails) Summary() string {
+	days := s.DaysRemaining(time.Now())
+	switch {
+	case days < 0:
+		return fmt.Sprintf("This secret expired %d day%s ago.", -days, plural(-days))
+	case days == 0:
+		return "This secret expires today."
+	default:
+		return fmt.Sprintf("This secret expires in %d day%s.", days, plural(days))
+	}
+}
+
+// DaysRemaining returns the number of whole days from now until the secret
+// expires. It is negative once the secret has expired.
+func (s *SecretExpiryWorklogDetails) DaysRemaining(now time.Time) int {
+	d := s.ExpiresAt.Sub(now)
+	days := int(d / (24 * time.Hour))
+	if d < 0 && d%(24*time.Hour) != 0 {
+		days--
+	}
+
+	return days
+}
+
+// SecretAgeWorklogDetails holds WorklogItem details for the
+// SecretAgeWorklogType.
+type SecretAgeWorklogDetails struct {
+	PathExp *pathexp.PathExp `json:"pathexp"`
+	Name    string           `json:"name"`
+	SetAt   time.Time        `json:"set_at"`
+	MaxAge  time.Duration    `json:"max_age"`
+
+	// Approximate is true when the secret was set before set times were
+	// recorded. SetAt is then the earliest time it could have been set.
+	Approximate bool `json:"approximate"`
+
+	// Link is the secret's documentation link, which may describe how to
+	// rotate it.
+	Link string `json:"link,omitempty"`
+}
+
+// Subject returns the human readable subject of this WorklogItem.
+func (s *SecretAgeWorklogDetails) Subject() string {
+	return s.PathExp.String() + "/" + s.Name
+}
+
+// Summary returns the human readable summary of this WorklogItem.
+func (s *SecretAgeWorklogDetails) Summary() string {
+	age := s.AgeDays(time.Now())
+	maxAge := int(s.MaxAge / (24 * time.Hour))
+	qualifier := ""
+	if s.Approximate {
+		qualifier = "up to "
+	}
+
+	return fmt.Sprintf("This secret was set %s%d day%s ago, over the maximum age of %d day%s.",
+		qualifier, age, plural(age), maxAge, plural(maxAge))
+}
+
+// AgeDays returns the number of whole days since the secret was set.
+func (s *SecretAgeWorklogDetails) AgeDays(now time.Time) int {
+	return int(now.Sub(s.SetAt) / (24 * time.Hour))
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
 }
 
 // Type returns this item's type
@@ -198,11 +495,88 @@ func (t WorklogType) String() string {
 		fallthrough
 	case MachineKeyringMembersWorklogType:
 		return "secret"
+	case SecretExpiryWorklogType:
+		return "secret"
+	case SecretAgeWorklogType:
+		return "secret"
 	default:
 		return "n/a"
 	}
 }
 
+// worklogTypeNames holds the machine readable names of each worklog type.
+var worklogTypeNames = []struct {
+	t    WorklogType
+	name string
+}{
+	{MissingKeypairsWorklogType, "missing-keypairs"},
+	{InviteApproveWorklogType, "invite-approve"},
+	{UserKeyringMembersWorklogType, "user-keyring-members"},
+	{MachineKeyringMembersWorklogType, "machine-keyring-members"},
+	{SecretRotateWorklogType, "secret-rotate"},
+	{SecretExpiryWorklogType, "secret-expiry"},
+	{SecretAgeWorklogType, "secret-age"},
+}
+
+// Name returns the unique machine readable name of this worklog item type.
+func (t WorklogType) Name() string {
+	for _, n := range worklogTypeNames {
+		if n.t == t {
+			return n.name
+		}
+	}
+	return "unknown"
+}
+
+// ParseWorklogTypes parses a comma separated list of worklog type names, as
+// returned by Name, into a WorklogType matching any of them. "any" matches
+// all types.
+func ParseWorklogTypes(raw string) (WorklogType, error) {
+	var t WorklogType
+NameLoop:
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "any" {
+			t |= AnyWorklogType
+			continue
+		}
+
+		for _, n := range worklogTypeNames {
+			if n.name == name {
+				t |= n.t
+				continue NameLoop
+			}
+		}
+
+		names := make([]string, len(worklogTypeNames))
+		for i, n := range worklogTypeNames {
+			names[i] = n.name
+		}
+		return 0, fmt.Errorf("Unknown worklog type %q: must be one of any, %s",
+			name, strings.Join(names, ", "))
+	}
+
+	return t, nil
+}
+
+// Fingerprint returns a content-based identifier for the details of this
+// WorklogItem. Unlike its ID, it changes when the cause of the item changes.
+func (w *WorklogItem) Fingerprint() string {
+	b, err := json.Marshal(w.Details)
+	if err != nil { // details are always plain data
+		panic(err)
+	}
+
+	h, err := blake2b.New(&blake2b.Config{Size: 16})
+	if err != nil { // this only happens with a bad config
+		panic(err)
+	}
+
+	h.Write([]byte{byte(w.Type())})
+	h.Write(b)
+	return base32.EncodeToString(h.Sum(nil))
+}
+
 // CreateID creates and populates a WorklogID for the WorklogItem based on the
 // given type and its subject.
 func (w *WorklogItem) CreateID(worklogType WorklogType) {
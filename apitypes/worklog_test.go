@@ -0,0 +1,197 @@
+package apitypes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/manifoldco/torus-cli/pathexp"
+)
+
+func TestSecretExpiryWorklogDetailsDaysRemaining(t *testing.T) {
+	now := time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tcs := []struct {
+		expires time.Time
+		days    int
+	}{
+		{now.Add(72 * time.Hour), 3},
+		{now.Add(36 * time.Hour), 1},
+		{now.Add(time.Hour), 0},
+		{now, 0},
+		{now.Add(-time.Hour), -1},
+		{now.Add(-48 * time.Hour), -2},
+	}
+
+	for _, tc := range tcs {
+		d := &SecretExpiryWorklogDetails{ExpiresAt: tc.expires}
+		if days := d.DaysRemaining(now); days != tc.days {
+			t.Errorf("expected %d days until %s, got %d", tc.days, tc.expires, days)
+		}
+	}
+}
+
+func TestParseSecretAgePolicy(t *testing.T) {
+	policy, err := ParseSecretAgePolicy("365, /myorg/*/production=90,/myorg/api=180")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := policy.String(); s != "365,/myorg/*/production=90,/myorg/api=180" {
+		t.Errorf("unexpected policy string: %s", s)
+	}
+
+	tcs := []struct {
+		path   string
+		maxAge int
+		ok     bool
+	}{
+		{"/myorg/api/production/web/*/*", 90, true},
+		{"/myorg/api/staging/web/*/*", 180, true},
+		{"/myorg/web/staging/web/*/*", 365, true},
+		{"/other/web/*/web/*/*", 365, true},
+	}
+
+	for _, tc := range tcs {
+		pe, err := pathexp.Parse(tc.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		maxAge, ok := policy.MaxAge(pe)
+		if ok != tc.ok || maxAge != time.Duration(tc.maxAge)*24*time.Hour {
+			t.Errorf("expected max age of %d days for %s, got %s", tc.maxAge, tc.path, maxAge)
+		}
+	}
+
+	t.Run("no default", func(t *testing.T) {
+		policy, err := ParseSecretAgePolicy("/myorg=30")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pe, _ := pathexp.Parse("/other/web/*/web/*/*")
+		if _, ok := policy.MaxAge(pe); ok {
+			t.Error("expected no max age for an unmatched path")
+		}
+	})
+
+	t.Run("ties", func(t *testing.T) {
+		policy, err := ParseSecretAgePolicy("/myorg/*/production=90,/myorg/api=30")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pe, _ := pathexp.Parse("/myorg/api/production/web/*/*")
+		if maxAge, _ := policy.MaxAge(pe); maxAge != 30*24*time.Hour {
+			t.Errorf("expected the shortest age to win a tie, got %s", maxAge)
+		}
+	})
+
+	for _, raw := range []string{"0", "ninety", "myorg=30", "/my org=30", "/a/b/c/d/e=30"} {
+		if _, err := ParseSecretAgePolicy(raw); err == nil {
+			t.Errorf("expected an error parsing %q", raw)
+		}
+	}
+}
+
+func TestSecretRotateWorklogDetailsSummary(t *testing.T) {
+	user := SecretRotateWorklogReason{Username: "jeff"}
+	machine := SecretRotateWorklogReason{Machine: "ci-runner"}
+
+	tcs := []struct {
+		reasons []SecretRotateWorklogReason
+		summary string
+	}{
+		{[]SecretRotateWorklogReason{user}, "A user's access was revoked. This secret's value should be changed."},
+		{[]SecretRotateWorklogReason{machine}, "A machine's access was revoked. This secret's value should be changed."},
+		{[]SecretRotateWorklogReason{user, machine}, "User and machine access was revoked. This secret's value should be changed."},
+	}
+
+	for _, tc := range tcs {
+		d := &SecretRotateWorklogDetails{Reasons: tc.reasons}
+		if s := d.Summary(); s != tc.summary {
+			t.Errorf("expected summary %q, got %q", tc.summary, s)
+		}
+	}
+}
+
+func TestWorklogDismissalActive(t *testing.T) {
+	pe, err := pathexp.Parse("/o/p/e/s/*/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	item := &WorklogItem{Details: &SecretExpiryWorklogDetails{
+		PathExp:   pe,
+		Name:      "token",
+		ExpiresAt: time.Date(2017, 6, 1, 0, 0, 0, 0, time.UTC),
+	}}
+	item.CreateID(SecretExpiryWorklogType)
+	fingerprint := item.Fingerprint()
+
+	now := time.Date(2017, 5, 1, 0, 0, 0, 0, time.UTC)
+	later := now.Add(24 * time.Hour)
+
+	dismissed := &WorklogDismissal{Fingerprint: fingerprint}
+	if !dismissed.Active(fingerprint, now) {
+		t.Error("expected dismissal to be active")
+	}
+
+	snoozed := &WorklogDismissal{Fingerprint: fingerprint, Until: &later}
+	if !snoozed.Active(fingerprint, now) {
+		t.Error("expected snooze to be active before its end")
+	}
+	if snoozed.Active(fingerprint, later) {
+		t.Error("expected snooze to end")
+	}
+
+	// A new expiry date keeps the id, but changes the cause.
+	changed := &WorklogItem{Details: &SecretExpiryWorklogDetails{
+		PathExp:   pe,
+		Name:      "token",
+		ExpiresAt: time.Date(2017, 7, 1, 0, 0, 0, 0, time.UTC),
+	}}
+	changed.CreateID(SecretExpiryWorklogType)
+	if *changed.ID != *item.ID {
+		t.Error("expected ids to match")
+	}
+	if dismissed.Active(changed.Fingerprint(), now) {
+		t.Error("expected dismissal to end when the cause changes")
+	}
+}
+
+func TestParseWorklogTypes(t *testing.T) {
+	tcs := []struct {
+		raw string
+		t   WorklogType
+	}{
+		{"secret-rotate", SecretRotateWorklogType},
+		{"secret-rotate, secret-expiry", SecretRotateWorklogType | SecretExpiryWorklogType},
+		{"any", AnyWorklogType},
+		{"missing-keypairs,any", AnyWorklogType},
+	}
+
+	for _, tc := range tcs {
+		typ, err := ParseWorklogTypes(tc.raw)
+		if err != nil {
+			t.Errorf("unexpected error parsing %q: %s", tc.raw, err)
+			continue
+		}
+		if typ != tc.t {
+			t.Errorf("expected %q to be %08b, got %08b", tc.raw, tc.t, typ)
+		}
+	}
+
+	for _, raw := range []string{"", "secret", "secret-rotate,"} {
+		if _, err := ParseWorklogTypes(raw); err == nil {
+			t.Errorf("expected error parsing %q", raw)
+		}
+	}
+
+	for _, n := range worklogTypeNames {
+		typ, err := ParseWorklogTypes(n.t.Name())
+		if err != nil || typ != n.t {
+			t.Errorf("expected %s to round trip", n.name)
+		}
+	}
+}
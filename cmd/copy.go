@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/hints"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/prompts"
+	"github.com/manifoldco/torus-cli/ui"
+)
+
+func init() {
+	cp := cli.Command{
+		Name:      "copy",
+		Aliases:   []string{"promote"},
+		Usage:     "Copy secrets from one path to another",
+		ArgsUsage: "<source path> <destination path> [<name|glob>...]",
+		Category:  "SECRETS",
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  "overwrite",
+				Usage: "Overwrite secrets which are already set at the destination",
+			},
+			cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Display the secrets which would be copied, without copying them",
+			},
+			stdAutoAcceptFlag,
+		},
+		Action: chain(ensureDaemon, ensureSession, copyCmd),
+	}
+
+	Cmds = append(Cmds, cp)
+}
+
+// copyPlan is a secret to be copied, and whether it overwrites an existing
+// secret at the destination.
+type copyPlan struct {
+	name      string
+	value     *apitypes.CredentialValue
+	overwrite bool
+}
+
+func copyCmd(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) < 2 {
+		return errs.NewUsageExitError("A source and destination path are required", ctx)
+	}
+
+	src, err := parseSinglePath(args[0])
+	if err != nil {
+		return errs.NewUsageExitError(err.Error(), ctx)
+	}
+
+	dest, err := parsePathExp(args[1])
+	if err != nil {
+		return errs.NewUsageExitError(err.Error(), ctx)
+	}
+
+	patterns := args[2:]
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return errs.NewUsageExitError(fmt.Sprintf("Invalid name or glob: %s", p), ctx)
+		}
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	session, err := client.Session.Who(c)
+	if err != nil {
+		return err
+	}
+
+	s, p := spinner("Decrypting credentials")
+	s.Start()
+	secrets, err := fetchSecretsAt(c, client, src, deriveIdentity(session), p)
+	var existing []apitypes.CredentialEnvelope
+	if err == nil {
+		existing, err = client.Credentials.Search(c, dest.String(), nil, nil)
+		if err != nil {
+			err = errs.NewErrorExitError("Could not retrieve destination secrets.", err)
+		}
+	}
+	s.Stop()
+	if err != nil {
+		return err
+	}
+
+	plan := planCopy(secrets, existing, dest, patterns)
+	if len(plan) == 0 {
+		return errs.NewExitError("No secrets to copy")
+	}
+
+	conflicts := 0
+	for _, cp := range plan {
+		if cp.overwrite {
+			conflicts++
+		}
+	}
+
+	srcDisplay := displayPathExp(src)
+	destDisplay := displayPathExp(dest)
+
+	fmt.Printf("Copying %d secret%s from %s to %s:\n\n", len(plan), plural(len(plan)), srcDisplay, destDisplay)
+	for _, cp := range plan {
+		if cp.overwrite {
+			fmt.Printf("%s %s %s\n", ui.ColorString(ui.Yellow, "~"), ui.BoldString(cp.name), ui.FaintString("(overwrite)"))
+		} else {
+			fmt.Printf("%s %s\n", ui.ColorString(ui.Green, "+"), ui.BoldString(cp.name))
+		}
+	}
+	fmt.Println()
+
+	if conflicts > 0 && !ctx.Bool("overwrite") {
+		return errs.NewExitError(fmt.Sprintf(
+			"%d secret%s already set at %s; use --overwrite to replace them",
+			conflicts, plural(conflicts), destDisplay))
+	}
+
+	if ctx.Bool("dry-run") {
+		fmt.Println("Dry run; no secrets were copied.")
+		return nil
+	}
+
+	if !ctx.Bool("yes") {
+		preamble := fmt.Sprintf("You are about to copy %d secret%s to %s.", len(plan), plural(len(plan)), destDisplay)
+		success, err := prompts.Confirm(nil, &preamble, true, false)
+		if err != nil {
+			return errs.NewErrorExitError("Failed to retrieve confirmation", err)
+		}
+		if !success {
+			return errs.ErrAbort
+		}
+	}
+
+	makers := valueMakers{}
+	for _, cp := range plan {
+		makers[cp.name] = func(value *apitypes.CredentialValue) valueMaker {
+			return func() *apitypes.CredentialValue {
+				return value
+			}
+		}(cp.value)
+	}
+
+	s, p = spinner("Attempting to copy credentials")
+	s.Start()
+	creds, err := setCredentials(ctx, dest, makers, p)
+	s.Stop()
+	if err != nil {
+		return errs.NewErrorExitError("Could not copy credentials.", err)
+	}
+
+	for _, cred := range creds {
+		name := (*cred.Body).GetName()
+		pe := (*cred.Body).GetPathExp()
+		fmt.Printf("Credential %s has been set at %s/%s\n", name, displayPathExp(pe), name)
+	}
+
+	hints.Display(hints.View, hints.Run)
+	return nil
+}
+
+// planCopy selects the secrets matching any of the given names or globs (or
+// all secrets, if none are given), marking those already set at dest.
+func planCopy(secrets, existing []apitypes.CredentialEnvelope, dest *pathexp.PathExp,
+	patterns []string) []copyPlan {
+
+	set := make(map[string]bool)
+	for _, e := range existing {
+		if (*e.Body).GetPathExp().Equal(dest) && (*e.Body).GetValue() != nil {
+			set[(*e.Body).GetName()] = true
+		}
+	}
+
+	var plan []copyPlan
+	for _, secret := range secrets {
+		name := (*secret.Body).GetName()
+		if !matchesAny(name, patterns) {
+			continue
+		}
+
+		// References are copied unresolved, so they're resolved relative to
+		// the destination. Metadata is copied along with the value.
+		value := (*secret.Body).GetValue()
+		if ref := (*secret.Body).GetReference(); ref != "" {
+			value = apitypes.NewReferenceCredentialValue(ref).WithMetadata(value.Metadata())
+		}
+
+		plan = append(plan, copyPlan{
+			name:      name,
+			value:     value,
+			overwrite: set[name],
+		})
+	}
+
+	return plan
+}
+
+// matchesAny returns whether name matches any of the given names or globs.
+// Every name matches an empty list of patterns.
+func matchesAny(name string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
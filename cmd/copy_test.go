@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"testing"
+
+	gm "github.com/onsi/gomega"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/pathexp"
+)
+
+func TestPlanCopy(t *testing.T) {
+	gm.RegisterTestingT(t)
+
+	dest, err := pathexp.Parse("/o/p/e/s/*/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := pathexp.Parse("/o/p/e/*/*/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secrets := exportCredentialsHelper(t, false)
+
+	var existing []apitypes.CredentialEnvelope
+	for _, c := range secrets {
+		body := *(*c.Body).(*apitypes.CredentialV2)
+		switch body.Name {
+		case "greeting":
+		case "on":
+			body.Value = nil
+		case "port":
+			body.PathExp = other
+		default:
+			continue
+		}
+
+		var cBody apitypes.Credential = &body
+		existing = append(existing, apitypes.CredentialEnvelope{Body: &cBody})
+	}
+
+	names := func(plan []copyPlan) map[string]bool {
+		m := make(map[string]bool)
+		for _, cp := range plan {
+			m[cp.name] = cp.overwrite
+		}
+		return m
+	}
+
+	t.Run("all secrets", func(t *testing.T) {
+		plan := planCopy(secrets, existing, dest, nil)
+		gm.Expect(names(plan)).To(gm.Equal(map[string]bool{
+			"api_key":  false,
+			"greeting": true,
+			"keystore": false,
+			"on":       false,
+			"port":     false,
+			"special":  false,
+		}))
+		gm.Expect(plan[0].value.String()).To(gm.Equal("abc123"))
+	})
+
+	t.Run("names and globs", func(t *testing.T) {
+		plan := planCopy(secrets, existing, dest, []string{"api_key", "g*", "p?rt"})
+		gm.Expect(names(plan)).To(gm.Equal(map[string]bool{
+			"api_key":  false,
+			"greeting": true,
+			"port":     false,
+		}))
+	})
+
+	t.Run("references", func(t *testing.T) {
+		body := *(*secrets[0].Body).(*apitypes.CredentialV2)
+		body.Reference = "${greeting}"
+		meta := &apitypes.CredentialMetadata{Description: "Greets", Owner: "ops"}
+		body.Value = body.Value.WithMetadata(meta)
+		var cBody apitypes.Credential = &body
+
+		plan := planCopy([]apitypes.CredentialEnvelope{{Body: &cBody}}, nil, dest, nil)
+		gm.Expect(plan).To(gm.HaveLen(1))
+		gm.Expect(plan[0].value.IsReference()).To(gm.BeTrue())
+		gm.Expect(plan[0].value.String()).To(gm.Equal("${greeting}"))
+		gm.Expect(plan[0].value.Metadata()).To(gm.Equal(meta))
+	})
+
+	t.Run("no matches", func(t *testing.T) {
+		plan := planCopy(secrets, existing, dest, []string{"missing"})
+		gm.Expect(plan).To(gm.BeEmpty())
+	})
+}
@@ -0,0 +1,430 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/juju/ansiterm"
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/ui"
+)
+
+// metadataFlags are used to describe a secret, when setting or describing it.
+var metadataFlags = []cli.Flag{
+	newPlaceholder("description", "TEXT", "Describe what the secret is for", "", "", false),
+	cli.StringSliceFlag{
+		Name:  "tag",
+		Usage: "Tag the secret, can be specified multiple times",
+	},
+	newPlaceholder("owner", "TEAM", "The team responsible for the secret", "", "", false),
+	newPlaceholder("link", "URL", "A link to documentation for the secret", "", "", false),
+	newPlaceholder("expires-at", "DATE", "When the secret expires, as YYYY-MM-DD or RFC 3339", "", "", false),
+}
+
+// expiryDateFormat is the format used to enter and display expiry dates.
+const expiryDateFormat = "2006-01-02"
+
+func init() {
+	describe := cli.Command{
+		Name:      "describe",
+		Usage:     "View or edit the description, tags, owner, link, and expiry of a secret",
+		ArgsUsage: "<name|path>",
+		Category:  "SECRETS",
+		Flags: append(append(setUnsetFlags, metadataFlags...),
+			cli.StringSliceFlag{
+				Name:  "untag",
+				Usage: "Remove a tag from the secret, can be specified multiple times",
+			},
+		),
+		Action: chain(
+			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+			setSliceDefaults, describeCmd,
+		),
+	}
+
+	Cmds = append(Cmds, describe)
+}
+
+func describeCmd(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 1 {
+		msg := "Name or path is required."
+		if len(args) > 1 {
+			msg = "Too many arguments provided."
+		}
+		return errs.NewUsageExitError(msg, ctx)
+	}
+
+	edit := metadataEditFromFlags(ctx)
+	if err := edit.Validate(); err != nil {
+		return errs.NewUsageExitError(err.Error(), ctx)
+	}
+
+	pe, cname, err := determinePath(ctx, args[0])
+	if err != nil {
+		return errs.NewErrorExitError("Could not describe credential", err)
+	}
+
+	name := strings.ToLower(*cname)
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	s, _ := spinner(fmt.Sprintf("Retrieving %s", name))
+	s.Start()
+	head, err := client.Credentials.Head(c, pe.String(), name, nil)
+	s.Stop()
+	if err != nil {
+		return errs.NewErrorExitError("Could not retrieve credential.", err)
+	}
+
+	if head == nil || head.Unset {
+		return errs.NewExitError(fmt.Sprintf("Credential %s not found at %s", name, displayPathExp(pe)))
+	}
+	if head.Value == nil {
+		return errs.NewExitError(fmt.Sprintf("Credential %s could not be decrypted", name))
+	}
+
+	if edit.IsEmpty() {
+		fmt.Printf("\n%s/%s\n\n", displayPathExp(pe), name)
+		writeMetadata(os.Stdout, head.Value.Metadata())
+		return nil
+	}
+
+	if edit.owner != nil && *edit.owner != "" {
+		err = checkOwner(c, client, pe.Org.String(), *edit.owner)
+		if err != nil {
+			return err
+		}
+	}
+
+	value := head.Value.WithMetadata(edit.Apply(head.Value.Metadata()))
+	makers := valueMakers{}
+	makers[name] = func() *apitypes.CredentialValue {
+		return value
+	}
+
+	s, p := spinner(fmt.Sprintf("Attempting to describe credential %s", name))
+	s.Start()
+	_, err = setCredentials(ctx, pe, makers, p)
+	s.Stop()
+	if err != nil {
+		return errs.NewErrorExitError("Could not describe credential.", err)
+	}
+
+	fmt.Printf("\nMetadata of %s has been updated at %s/%s\n", name, displayPathExp(pe), name)
+	return nil
+}
+
+// metadataEdit holds the changes to make to a secret's metadata. Nil fields
+// are left as they are.
+type metadataEdit struct {
+	description *string
+	owner       *string
+	link        *string
+	expiresAt   *string
+	tags        []string
+	untags      []string
+}
+
+func metadataEditFromFlags(ctx *cli.Context) metadataEdit {
+	var e metadataEdit
+	for _, f := range []struct {
+		name  string
+		field **string
+	}{
+		{"description", &e.description},
+		{"owner", &e.owner},
+		{"link", &e.link},
+		{"expires-at", &e.expiresAt},
+	} {
+		if ctx.IsSet(f.name) {
+			v := strings.TrimSpace(ctx.String(f.name))
+			*f.field = &v
+		}
+	}
+
+	e.tags = ctx.StringSlice("tag")
+	e.untags = ctx.StringSlice("untag")
+	return e
+}
+
+// IsEmpty returns if no changes are to be made.
+func (e metadataEdit) IsEmpty() bool {
+	return e.description == nil && e.owner == nil && e.link == nil &&
+		e.expiresAt == nil && len(e.tags) == 0 && len(e.untags) == 0
+}
+
+// Validate returns an error if the tags, link, or expiry date are invalid.
+func (e metadataEdit) Validate() error {
+	for _, t := range append(e.tags, e.untags...) {
+		if t == "" || strings.IndexFunc(t, func(r rune) bool { return r == ' ' || r == ',' }) != -1 {
+			return fmt.Errorf("Invalid tag %q: tags cannot be empty, or contain spaces or commas", t)
+		}
+	}
+
+	if e.link != nil && *e.link != "" {
+		u, err := url.Parse(*e.link)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("Invalid link %q: links must be http or https URLs", *e.link)
+		}
+	}
+
+	if e.expiresAt != nil && *e.expiresAt != "" {
+		if _, err := parseExpiry(*e.expiresAt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Apply returns a copy of m with the changes made. Tags are kept sorted.
+func (e metadataEdit) Apply(m *apitypes.CredentialMetadata) *apitypes.CredentialMetadata {
+	out := &apitypes.CredentialMetadata{}
+	if m != nil {
+		*out = *m
+	}
+
+	if e.description != nil {
+		out.Description = *e.description
+	}
+	if e.owner != nil {
+		out.Owner = *e.owner
+	}
+	if e.link != nil {
+		out.Link = *e.link
+	}
+	if e.expiresAt != nil {
+		out.ExpiresAt = nil
+		if t, err := parseExpiry(*e.expiresAt); err == nil {
+			out.ExpiresAt = &t
+		}
+	}
+
+	tags := make(map[string]bool)
+	for _, t := range out.Tags {
+		tags[t] = true
+	}
+	for _, t := range e.tags {
+		tags[t] = true
+	}
+	for _, t := range e.untags {
+		delete(tags, t)
+	}
+
+	out.Tags = nil
+	for t := range tags {
+		out.Tags = append(out.Tags, t)
+	}
+	sort.Strings(out.Tags)
+
+	return out
+}
+
+// parseExpiry parses an expiry date given as YYYY-MM-DD, which expires at the
+// start of that day in UTC, or as an RFC 3339 timestamp.
+func parseExpiry(raw string) (time.Time, error) {
+	t, err := time.Parse(expiryDateFormat, raw)
+	if err != nil {
+		t, err = time.Parse(time.RFC3339, raw)
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid expiry date %q: dates must be YYYY-MM-DD or RFC 3339", raw)
+	}
+
+	return t.UTC(), nil
+}
+
+// checkOwner returns an error if there is no team of the given name in the
+// org.
+func checkOwner(c context.Context, client *api.Client, orgName, team string) error {
+	org, err := client.Orgs.GetByName(c, orgName)
+	if org == nil || err != nil {
+		return errs.NewExitError("Org not found")
+	}
+
+	teams, err := client.Teams.GetByName(c, org.ID, team)
+	if err != nil {
+		return errs.NewErrorExitError("Could not retrieve teams.", err)
+	}
+	if len(teams) == 0 {
+		return errs.NewExitError("No such team " + team)
+	}
+
+	return nil
+}
+
+// describeValueMaker wraps the maker for the named credential, so its value is
+// described by the credential's current metadata, with edit applied.
+//
+// This keeps metadata intact as values are changed.
+func describeValueMaker(c context.Context, client *api.Client, pe *pathexp.PathExp,
+	name string, makers valueMakers, edit metadataEdit) error {
+
+	if _, ok := makers[name]; !ok {
+		return errors.New("No value for " + name)
+	}
+
+	meta, found, err := currentMetadata(c, client, pe, name)
+	if err != nil {
+		return err
+	}
+
+	// Secrets set at the individual paths covered by pe are more specific,
+	// and aren't replaced, but their metadata isn't carried over either.
+	if !found && multiSegment(pe) {
+		ui.Warn("Metadata is only carried over from %s set at exactly %s, not from secrets set at the paths it covers.",
+			name, displayPathExp(pe))
+	}
+
+	applyMetadata(makers, map[string]*apitypes.CredentialMetadata{name: edit.Apply(meta)})
+	return nil
+}
+
+// carryMetadata wraps the maker for each credential, so its value keeps the
+// metadata of the credential it replaces at pe.
+func carryMetadata(c context.Context, client *api.Client, pe *pathexp.PathExp, makers valueMakers) error {
+	metas := make(map[string]*apitypes.CredentialMetadata, len(makers))
+	missing := false
+	for name := range makers {
+		meta, found, err := currentMetadata(c, client, pe, name)
+		if err != nil {
+			return err
+		}
+
+		metas[name] = meta
+		missing = missing || !found
+	}
+
+	if missing && multiSegment(pe) {
+		ui.Warn("Metadata is only carried over from secrets set at exactly %s, not from secrets set at the paths it covers.",
+			displayPathExp(pe))
+	}
+
+	applyMetadata(makers, metas)
+	return nil
+}
+
+// currentMetadata returns the metadata of the named credential's current
+// value at pe, and whether the credential has ever been set there.
+func currentMetadata(c context.Context, client *api.Client, pe *pathexp.PathExp,
+	name string) (*apitypes.CredentialMetadata, bool, error) {
+
+	head, err := client.Credentials.Head(c, pe.String(), name, nil)
+	if err != nil || head == nil {
+		return nil, false, err
+	}
+
+	if head.Value == nil {
+		return nil, true, nil
+	}
+
+	return head.Value.Metadata(), true, nil
+}
+
+// applyMetadata wraps the makers for the named credentials, so their values
+// are described by the given metadata.
+func applyMetadata(makers valueMakers, metas map[string]*apitypes.CredentialMetadata) {
+	for name, meta := range metas {
+		maker, ok := makers[name]
+		if !ok || meta.IsEmpty() {
+			continue
+		}
+
+		makers[name] = func(maker valueMaker, meta *apitypes.CredentialMetadata) valueMaker {
+			return func() *apitypes.CredentialValue {
+				return maker().WithMetadata(meta)
+			}
+		}(maker, meta)
+	}
+}
+
+// multiSegment returns whether any of the path's segments, other than a
+// full glob, match more than one value.
+func multiSegment(pe *pathexp.PathExp) bool {
+	for _, s := range []string{pe.Envs.String(), pe.Services.String(),
+		pe.Identities.String(), pe.Instances.String()} {
+
+		if s != "*" && strings.ContainsAny(s, "[!*") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// formatMetadata returns a single line summary of a secret's metadata.
+func formatMetadata(m *apitypes.CredentialMetadata) string {
+	if m.IsEmpty() {
+		return ""
+	}
+
+	var parts []string
+	if m.Description != "" {
+		parts = append(parts, m.Description)
+	}
+	if len(m.Tags) > 0 {
+		parts = append(parts, "tags: "+strings.Join(m.Tags, ", "))
+	}
+	if m.Owner != "" {
+		parts = append(parts, "owner: "+m.Owner)
+	}
+	if m.Link != "" {
+		parts = append(parts, m.Link)
+	}
+	if m.ExpiresAt != nil {
+		parts = append(parts, "expires "+m.ExpiresAt.Format(expiryDateFormat))
+	}
+
+	return strings.Join(parts, " | ")
+}
+
+// writeMetadata writes a table of a secret's metadata.
+func writeMetadata(out io.Writer, m *apitypes.CredentialMetadata) {
+	if m == nil {
+		m = &apitypes.CredentialMetadata{}
+	}
+
+	none := ui.FaintString("-")
+	or := func(s string) string {
+		if s == "" {
+			return none
+		}
+		return s
+	}
+
+	w := ansiterm.NewTabWriter(out, 2, 0, 3, ' ', 0)
+	fmt.Fprintf(w, "%s\t%s\n", ui.BoldString("Description"), or(m.Description))
+	fmt.Fprintf(w, "%s\t%s\n", ui.BoldString("Tags"), or(strings.Join(m.Tags, ", ")))
+	fmt.Fprintf(w, "%s\t%s\n", ui.BoldString("Owner"), or(m.Owner))
+	fmt.Fprintf(w, "%s\t%s\n", ui.BoldString("Link"), or(m.Link))
+
+	var expires string
+	if m.ExpiresAt != nil {
+		expires = m.ExpiresAt.Format(time.RFC3339)
+		if m.ExpiresAt.Before(time.Now()) {
+			expires += " " + ui.ColorString(ui.Red, "(expired)")
+		}
+	}
+	fmt.Fprintf(w, "%s\t%s\n", ui.BoldString("Expires"), or(expires))
+	w.Flush()
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	gm "github.com/onsi/gomega"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/pathexp"
+)
+
+func TestMetadataEdit(t *testing.T) {
+	gm.RegisterTestingT(t)
+
+	str := func(s string) *string { return &s }
+
+	t.Run("apply", func(t *testing.T) {
+		m := &apitypes.CredentialMetadata{
+			Description: "Old description",
+			Tags:        []string{"legacy", "payments"},
+			Owner:       "billing",
+		}
+
+		edit := metadataEdit{
+			description: str("Stripe API key"),
+			link:        str("https://wiki.example.com/stripe"),
+			tags:        []string{"stripe", "payments"},
+			untags:      []string{"legacy"},
+		}
+
+		out := edit.Apply(m)
+		gm.Expect(out).To(gm.Equal(&apitypes.CredentialMetadata{
+			Description: "Stripe API key",
+			Tags:        []string{"payments", "stripe"},
+			Owner:       "billing",
+			Link:        "https://wiki.example.com/stripe",
+		}))
+
+		// The original is left untouched
+		gm.Expect(m.Description).To(gm.Equal("Old description"))
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		m := &apitypes.CredentialMetadata{Description: "desc", Tags: []string{"a"}}
+		out := metadataEdit{description: str(""), untags: []string{"a"}}.Apply(m)
+		gm.Expect(out.IsEmpty()).To(gm.BeTrue())
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		gm.Expect(metadataEdit{}.IsEmpty()).To(gm.BeTrue())
+		gm.Expect(metadataEdit{}.Apply(nil).IsEmpty()).To(gm.BeTrue())
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		gm.Expect(metadataEdit{tags: []string{"ok"}, link: str("http://x.io")}.Validate()).To(gm.Succeed())
+		gm.Expect(metadataEdit{link: str("")}.Validate()).To(gm.Succeed())
+		gm.Expect(metadataEdit{tags: []string{"two words"}}.Validate()).ToNot(gm.Succeed())
+		gm.Expect(metadataEdit{untags: []string{""}}.Validate()).ToNot(gm.Succeed())
+		gm.Expect(metadataEdit{link: str("ftp://x.io")}.Validate()).ToNot(gm.Succeed())
+		gm.Expect(metadataEdit{link: str("not a url")}.Validate()).ToNot(gm.Succeed())
+		gm.Expect(metadataEdit{expiresAt: str("2018-03-01")}.Validate()).To(gm.Succeed())
+		gm.Expect(metadataEdit{expiresAt: str("")}.Validate()).To(gm.Succeed())
+		gm.Expect(metadataEdit{expiresAt: str("03/01/2018")}.Validate()).ToNot(gm.Succeed())
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		out := metadataEdit{expiresAt: str("2018-03-01T10:00:00-05:00")}.Apply(nil)
+		gm.Expect(*out.ExpiresAt).To(gm.Equal(time.Date(2018, 3, 1, 15, 0, 0, 0, time.UTC)))
+
+		out = metadataEdit{expiresAt: str("")}.Apply(out)
+		gm.Expect(out.IsEmpty()).To(gm.BeTrue())
+	})
+}
+
+func TestFormatMetadata(t *testing.T) {
+	gm.RegisterTestingT(t)
+
+	gm.Expect(formatMetadata(nil)).To(gm.Equal(""))
+	gm.Expect(formatMetadata(&apitypes.CredentialMetadata{
+		Description: "Legacy key",
+		Tags:        []string{"a", "b"},
+		Owner:       "ops",
+		Link:        "https://x.io",
+	})).To(gm.Equal("Legacy key | tags: a, b | owner: ops | https://x.io"))
+
+	at := time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC)
+	gm.Expect(formatMetadata(&apitypes.CredentialMetadata{
+		Owner:     "ops",
+		ExpiresAt: &at,
+	})).To(gm.Equal("owner: ops | expires 2018-03-01"))
+}
+
+func TestMultiSegment(t *testing.T) {
+	gm.RegisterTestingT(t)
+
+	for raw, expected := range map[string]bool{
+		"/o/p/prod/api/*/*":       false,
+		"/o/p/*/*/*/*":            false,
+		"/o/p/[dev|prod]/api/*/*": true,
+		"/o/p/!prod/api/*/*":      true,
+		"/o/p/prod/api-*/*/*":     true,
+		"/o/p/prod/api/*/[1|2]":   true,
+	} {
+		pe, err := pathexp.Parse(raw)
+		gm.Expect(err).To(gm.BeNil())
+		gm.Expect(multiSegment(pe)).To(gm.Equal(expected), raw)
+	}
+}
+
+func TestApplyMetadata(t *testing.T) {
+	gm.RegisterTestingT(t)
+
+	value := func(v string) valueMaker {
+		return func() *apitypes.CredentialValue {
+			return apitypes.NewStringCredentialValue(v)
+		}
+	}
+
+	makers := valueMakers{"api_key": value("abc123"), "port": value("3000")}
+	meta := &apitypes.CredentialMetadata{Description: "Stripe API key", Owner: "billing"}
+
+	applyMetadata(makers, map[string]*apitypes.CredentialMetadata{
+		"api_key": meta,
+		"port":    {},
+		"missing": meta,
+	})
+
+	gm.Expect(makers).To(gm.HaveLen(2))
+	gm.Expect(makers["api_key"]().String()).To(gm.Equal("abc123"))
+	gm.Expect(makers["api_key"]().Metadata()).To(gm.Equal(meta))
+	gm.Expect(makers["port"]().Metadata()).To(gm.BeNil())
+}
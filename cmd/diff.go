@@ -0,0 +1,329 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/ui"
+)
+
+func init() {
+	diff := cli.Command{
+		Name:      "diff",
+		Usage:     "Compare the secrets of two environments, services, or paths",
+		ArgsUsage: "[<path> <path>]",
+		Category:  "SECRETS",
+		Flags: []cli.Flag{
+			orgFlag("Use this organization.", false),
+			projectFlag("Use this project.", false),
+			envSliceFlag("Compare this environment. Specify twice to compare two environments.", false),
+			serviceSliceFlag("Compare this service. Specify twice to compare two services.", "default", false),
+			cli.BoolFlag{
+				Name:  "show-values",
+				Usage: "Show the values of secrets which differ, instead of masking them",
+			},
+			formatFlag("text", "Format used to display the differences (text, json)"),
+		},
+		Action: chain(
+			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+			setSliceDefaults, diffCmd,
+		),
+	}
+
+	Cmds = append(Cmds, diff)
+}
+
+// secretDiff describes how the secrets at two paths differ.
+type secretDiff struct {
+	From      string          `json:"from"`
+	To        string          `json:"to"`
+	OnlyFrom  []diffSecret    `json:"only_in_from"`
+	OnlyTo    []diffSecret    `json:"only_in_to"`
+	Changed   []changedSecret `json:"changed"`
+	Unchanged int             `json:"unchanged"`
+}
+
+// diffSecret is a secret only found on one side of a diff. The value is
+// only included when values are shown.
+type diffSecret struct {
+	Name  string `json:"name"`
+	Value string `json:"value,omitempty"`
+}
+
+// changedSecret is a secret found on both sides of a diff, with differing
+// values. The values are only included when values are shown.
+type changedSecret struct {
+	Name string `json:"name"`
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+}
+
+// Differs returns whether the two sides differ.
+func (d *secretDiff) Differs() bool {
+	return len(d.OnlyFrom) > 0 || len(d.OnlyTo) > 0 || len(d.Changed) > 0
+}
+
+func diffCmd(ctx *cli.Context) error {
+	format := ctx.String("format")
+	if format != "text" && format != "json" {
+		return errs.NewUsageExitError(fmt.Sprintf("Invalid format provided: %s", format), ctx)
+	}
+
+	args := ctx.Args()
+
+	var from, to *pathexp.PathExp
+	var err error
+	switch len(args) {
+	case 0:
+		from, to, err = diffPathsFromFlags(ctx)
+	case 2:
+		from, err = parseSinglePath(args[0])
+		if err == nil {
+			to, err = parseSinglePath(args[1])
+		}
+	default:
+		return errs.NewUsageExitError("Either two paths, or two environments or services, must be supplied", ctx)
+	}
+	if err != nil {
+		return errs.NewUsageExitError(err.Error(), ctx)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	session, err := client.Session.Who(c)
+	if err != nil {
+		return err
+	}
+	identity := deriveIdentity(session)
+
+	s, p := spinner("Decrypting credentials")
+	s.Start()
+	fromSecrets, err := fetchSecretsAt(c, client, from, identity, p)
+	var toSecrets []apitypes.CredentialEnvelope
+	if err == nil {
+		toSecrets, err = fetchSecretsAt(c, client, to, identity, p)
+	}
+	s.Stop()
+	if err != nil {
+		return err
+	}
+
+	d := computeSecretDiff(fromSecrets, toSecrets, ctx.Bool("show-values"))
+	d.From = displayPathExp(from)
+	d.To = displayPathExp(to)
+
+	return writeSecretDiff(os.Stdout, d, format)
+}
+
+// diffPathsFromFlags builds the paths to compare from the environment and
+// service flags. One of them must be given twice; the other may be given
+// once, to apply to both sides.
+func diffPathsFromFlags(ctx *cli.Context) (*pathexp.PathExp, *pathexp.PathExp, error) {
+	err := chain(setUserEnv, checkRequiredFlags)(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	org := ctx.String("org")
+	project := ctx.String("project")
+	if org == "" || project == "" {
+		return nil, nil, errs.NewExitError("An org and project must be supplied")
+	}
+
+	envs := ctx.StringSlice("environment")
+	services := ctx.StringSlice("service")
+
+	if len(envs) > 2 || len(services) > 2 || (len(envs) != 2 && len(services) != 2) {
+		return nil, nil, errs.NewExitError("Either --environment or --service must be supplied exactly twice")
+	}
+	if len(envs) == 0 {
+		return nil, nil, errs.NewExitError("An environment must be supplied")
+	}
+
+	pick := func(values []string, i int) string {
+		if len(values) == 1 {
+			return values[0]
+		}
+		return values[i]
+	}
+
+	paths := make([]*pathexp.PathExp, 2)
+	for i := range paths {
+		paths[i], err = pathexp.New(org, project, []string{pick(envs, i)},
+			[]string{pick(services, i)}, []string{"*"}, []string{"*"})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return paths[0], paths[1], nil
+}
+
+// parseSinglePath parses a path which must refer to a single org,
+// project, environment and service.
+func parseSinglePath(raw string) (*pathexp.PathExp, error) {
+	pe, err := parsePathExp(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if !singleSegment(pe.Envs.String(), pe.Envs.Components()) ||
+		!singleSegment(pe.Services.String(), pe.Services.Components()) {
+		return nil, fmt.Errorf("Path %s must refer to a single environment and service", raw)
+	}
+
+	return pe, nil
+}
+
+// singleSegment returns whether a segment's string and components describe
+// a single literal value.
+func singleSegment(s string, components []string) bool {
+	return len(components) == 1 && components[0] == s && !strings.ContainsAny(s, "*!")
+}
+
+// fetchSecretsAt retrieves the compacted set of secrets visible at the
+// given path, as `torus view` would for it.
+func fetchSecretsAt(ctx context.Context, client *api.Client, pe *pathexp.PathExp,
+	identity string, p api.ProgressFunc) ([]apitypes.CredentialEnvelope, error) {
+
+	path, err := deriveExplicitPathExp(pe.Org.String(), pe.Project.String(),
+		pe.Envs.String(), pe.Services.String(), identity)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &secretsFetcher{client: client, path: path}
+	return f.fetch(ctx, p)
+}
+
+// computeSecretDiff compares two sorted sets of secrets.
+func computeSecretDiff(from, to []apitypes.CredentialEnvelope, showValues bool) *secretDiff {
+	d := &secretDiff{
+		OnlyFrom: []diffSecret{},
+		OnlyTo:   []diffSecret{},
+		Changed:  []changedSecret{},
+	}
+
+	values := func(secrets []apitypes.CredentialEnvelope) map[string]string {
+		m := make(map[string]string, len(secrets))
+		for _, s := range secrets {
+			m[(*s.Body).GetName()] = (*s.Body).GetValue().String()
+		}
+		return m
+	}
+
+	fromValues := values(from)
+	toValues := values(to)
+
+	for _, s := range from {
+		name := (*s.Body).GetName()
+		fv := fromValues[name]
+		tv, ok := toValues[name]
+
+		switch {
+		case !ok:
+			e := diffSecret{Name: name}
+			if showValues {
+				e.Value = fv
+			}
+			d.OnlyFrom = append(d.OnlyFrom, e)
+		case fv != tv:
+			e := changedSecret{Name: name}
+			if showValues {
+				e.From = fv
+				e.To = tv
+			}
+			d.Changed = append(d.Changed, e)
+		default:
+			d.Unchanged++
+		}
+	}
+
+	for _, s := range to {
+		name := (*s.Body).GetName()
+		if _, ok := fromValues[name]; ok {
+			continue
+		}
+
+		e := diffSecret{Name: name}
+		if showValues {
+			e.Value = toValues[name]
+		}
+		d.OnlyTo = append(d.OnlyTo, e)
+	}
+
+	return d
+}
+
+// writeSecretDiff writes the diff in the given format. If the sides differ,
+// an error with a non-zero exit code is returned.
+func writeSecretDiff(w io.Writer, d *secretDiff, format string) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		err := enc.Encode(d)
+		if err != nil {
+			return errs.NewErrorExitError("Could not marshal to json", err)
+		}
+	} else {
+		writeSecretDiffText(w, d)
+	}
+
+	if d.Differs() {
+		return cli.NewExitError("", 1)
+	}
+
+	return nil
+}
+
+func writeSecretDiffText(w io.Writer, d *secretDiff) {
+	fmt.Fprintf(w, "Comparing %s to %s\n\n", d.From, d.To)
+
+	if !d.Differs() {
+		fmt.Fprintf(w, "No differences found between %d secret%s.\n", d.Unchanged, plural(d.Unchanged))
+		return
+	}
+
+	for _, s := range d.OnlyFrom {
+		fmt.Fprintf(w, "%s %s%s\n", ui.ColorString(ui.Red, "-"), ui.BoldString(s.Name), diffValue(s.Value))
+	}
+	for _, s := range d.OnlyTo {
+		fmt.Fprintf(w, "%s %s%s\n", ui.ColorString(ui.Green, "+"), ui.BoldString(s.Name), diffValue(s.Value))
+	}
+	for _, s := range d.Changed {
+		fmt.Fprintf(w, "%s %s\n", ui.ColorString(ui.Yellow, "~"), ui.BoldString(s.Name))
+		if s.From != "" || s.To != "" {
+			fmt.Fprintf(w, "    %s %q\n", ui.ColorString(ui.Red, "-"), s.From)
+			fmt.Fprintf(w, "    %s %q\n", ui.ColorString(ui.Green, "+"), s.To)
+		}
+	}
+
+	fmt.Fprintf(w, "\n%d only in %s, %d only in %s, %d changed, %d identical.\n",
+		len(d.OnlyFrom), d.From, len(d.OnlyTo), d.To, len(d.Changed), d.Unchanged)
+}
+
+func diffValue(v string) string {
+	if v == "" {
+		return ""
+	}
+
+	return fmt.Sprintf(" = %q", v)
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	gm "github.com/onsi/gomega"
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+)
+
+func diffCredentialsHelper(t *testing.T, values map[string]string) []apitypes.CredentialEnvelope {
+	cset := credentialSet{}
+	for _, c := range exportCredentialsHelper(t, false) {
+		cset.Add(c)
+	}
+
+	var creds []apitypes.CredentialEnvelope
+	for _, c := range cset.ToSlice() {
+		v, ok := values[(*c.Body).GetName()]
+		if !ok {
+			continue
+		}
+
+		body := (*c.Body).(*apitypes.CredentialV2)
+		copied := *body
+		copied.Value = apitypes.NewStringCredentialValue(v)
+
+		var cBody apitypes.Credential = &copied
+		creds = append(creds, apitypes.CredentialEnvelope{Body: &cBody})
+	}
+
+	return creds
+}
+
+func TestComputeSecretDiff(t *testing.T) {
+	gm.RegisterTestingT(t)
+
+	from := diffCredentialsHelper(t, map[string]string{
+		"api_key": "a", "greeting": "hello", "on": "same",
+	})
+	to := diffCredentialsHelper(t, map[string]string{
+		"greeting": "goodbye", "on": "same", "port": "80",
+	})
+
+	d := computeSecretDiff(from, to, false)
+	gm.Expect(d.Differs()).To(gm.BeTrue())
+	gm.Expect(d.OnlyFrom).To(gm.Equal([]diffSecret{{Name: "api_key"}}))
+	gm.Expect(d.OnlyTo).To(gm.Equal([]diffSecret{{Name: "port"}}))
+	gm.Expect(d.Changed).To(gm.Equal([]changedSecret{{Name: "greeting"}}))
+	gm.Expect(d.Unchanged).To(gm.Equal(1))
+
+	d = computeSecretDiff(from, to, true)
+	gm.Expect(d.OnlyFrom).To(gm.Equal([]diffSecret{{Name: "api_key", Value: "a"}}))
+	gm.Expect(d.OnlyTo).To(gm.Equal([]diffSecret{{Name: "port", Value: "80"}}))
+	gm.Expect(d.Changed).To(gm.Equal([]changedSecret{{Name: "greeting", From: "hello", To: "goodbye"}}))
+
+	d = computeSecretDiff(from, from, false)
+	gm.Expect(d.Differs()).To(gm.BeFalse())
+	gm.Expect(d.Unchanged).To(gm.Equal(3))
+}
+
+func TestWriteSecretDiff(t *testing.T) {
+	gm.RegisterTestingT(t)
+
+	d := &secretDiff{
+		From:     "/o/p/staging/default",
+		To:       "/o/p/production/default",
+		OnlyFrom: []diffSecret{{Name: "debug"}},
+		OnlyTo:   []diffSecret{},
+		Changed:  []changedSecret{},
+	}
+
+	buf := &bytes.Buffer{}
+	err := writeSecretDiff(buf, d, "json")
+	gm.Expect(err).ToNot(gm.BeNil())
+	gm.Expect(err.(cli.ExitCoder).ExitCode()).To(gm.Equal(1))
+	gm.Expect(buf.String()).To(gm.Equal(`{
+  "from": "/o/p/staging/default",
+  "to": "/o/p/production/default",
+  "only_in_from": [
+    {
+      "name": "debug"
+    }
+  ],
+  "only_in_to": [],
+  "changed": [],
+  "unchanged": 0
+}
+`))
+
+	d.OnlyFrom = []diffSecret{}
+	err = writeSecretDiff(&bytes.Buffer{}, d, "text")
+	gm.Expect(err).To(gm.BeNil())
+}
+
+func TestParseSinglePath(t *testing.T) {
+	gm.RegisterTestingT(t)
+
+	pe, err := parseSinglePath("/o/p/staging/api")
+	gm.Expect(err).To(gm.BeNil())
+	gm.Expect(pe.Envs.String()).To(gm.Equal("staging"))
+	gm.Expect(pe.Services.String()).To(gm.Equal("api"))
+
+	for _, path := range []string{"/o/p/*/api", "/o/p/staging/[api|www]", "/o/p/prod*/api", "/o/p/!prod/api", "/o/p/staging/*-api", "o/p/e/s"} {
+		_, err = parseSinglePath(path)
+		gm.Expect(err).ToNot(gm.BeNil(), path)
+	}
+}
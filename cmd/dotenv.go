@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+)
+
+// dotenvKey matches the names allowed in a dotenv file.
+var dotenvKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// dotenvParser parses the contents of a dotenv file.
+type dotenvParser struct {
+	src  string
+	pos  int
+	line int
+}
+
+// parseDotenv reads secret pairs from a dotenv file. It supports:
+//
+//   - blank lines and lines beginning with #, which are ignored
+//   - an optional `export` prefix before each name
+//   - unquoted values, which end at the end of the line or at a # preceded by
+//     whitespace, and have surrounding whitespace removed
+//   - single quoted values, which are taken literally
+//   - double quoted values, which support \n, \r, \t, \", \\ and \$ escapes
+//
+// Quoted values may span multiple lines. Empty values are allowed.
+func parseDotenv(r io.Reader) ([]secretPair, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading input file. %s", err)
+	}
+
+	p := &dotenvParser{src: strings.Replace(string(b), "\r\n", "\n", -1), line: 1}
+
+	var pairs []secretPair
+	for {
+		p.skipBlank()
+		if p.pos >= len(p.src) {
+			return pairs, nil
+		}
+
+		pair, err := p.parsePair()
+		if err != nil {
+			return nil, err
+		}
+
+		pairs = append(pairs, pair)
+	}
+}
+
+// skipBlank skips over whitespace, empty lines and comment lines.
+func (p *dotenvParser) skipBlank() {
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case '\n':
+			p.line++
+			p.pos++
+		case ' ', '\t':
+			p.pos++
+		case '#':
+			p.skipLine()
+		default:
+			return
+		}
+	}
+}
+
+// skipLine moves past the end of the current line.
+func (p *dotenvParser) skipLine() {
+	for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+		p.pos++
+	}
+}
+
+func (p *dotenvParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("Error parsing secret on line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *dotenvParser) parsePair() (secretPair, error) {
+	end := strings.IndexAny(p.src[p.pos:], "=\n")
+	if end == -1 || p.src[p.pos+end] != '=' {
+		return secretPair{}, p.errorf("expected NAME=value")
+	}
+
+	key := strings.TrimSpace(p.src[p.pos : p.pos+end])
+	if strings.HasPrefix(key, "export ") || strings.HasPrefix(key, "export\t") {
+		key = strings.TrimSpace(key[len("export"):])
+	}
+
+	if !dotenvKey.MatchString(key) {
+		return secretPair{}, p.errorf("invalid name %q", key)
+	}
+
+	p.pos += end + 1
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+
+	var value string
+	var err error
+	if p.pos < len(p.src) && (p.src[p.pos] == '"' || p.src[p.pos] == '\'') {
+		value, err = p.parseQuoted(key)
+		if err != nil {
+			return secretPair{}, err
+		}
+
+		err = p.parseTrailer(key)
+	} else {
+		value = p.parseUnquoted()
+	}
+
+	return secretPair{key: key, value: value}, err
+}
+
+// parseUnquoted reads a value up to the end of the line, or an inline
+// comment.
+func (p *dotenvParser) parseUnquoted() string {
+	start := p.pos
+	p.skipLine()
+	value := p.src[start:p.pos]
+
+	for i := 1; i < len(value); i++ {
+		if value[i] == '#' && (value[i-1] == ' ' || value[i-1] == '\t') {
+			value = value[:i]
+			break
+		}
+	}
+
+	return strings.TrimSpace(value)
+}
+
+// parseQuoted reads a single or double quoted value, which may span
+// multiple lines.
+func (p *dotenvParser) parseQuoted(key string) (string, error) {
+	quote := p.src[p.pos]
+	start := p.line
+	p.pos++
+
+	var value []byte
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		p.pos++
+
+		switch {
+		case c == quote:
+			return string(value), nil
+		case c == '\n':
+			p.line++
+			value = append(value, c)
+		case c == '\\' && quote == '"' && p.pos < len(p.src):
+			e := p.src[p.pos]
+			p.pos++
+
+			switch e {
+			case 'n':
+				value = append(value, '\n')
+			case 'r':
+				value = append(value, '\r')
+			case 't':
+				value = append(value, '\t')
+			case '"', '\\', '$':
+				value = append(value, e)
+			default:
+				value = append(value, c, e)
+				if e == '\n' {
+					p.line++
+				}
+			}
+		default:
+			value = append(value, c)
+		}
+	}
+
+	p.line = start
+	return "", p.errorf("unterminated quoted value for %s", key)
+}
+
+// parseTrailer ensures only whitespace or a comment follows a quoted value.
+func (p *dotenvParser) parseTrailer(key string) error {
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case ' ', '\t':
+			p.pos++
+		case '#':
+			p.skipLine()
+			return nil
+		case '\n':
+			return nil
+		default:
+			return p.errorf("unexpected characters after the value for %s", key)
+		}
+	}
+
+	return nil
+}
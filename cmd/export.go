@@ -1,30 +1,19 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"strings"
-	"text/tabwriter"
 
 	"github.com/urfave/cli"
 
-	"github.com/manifoldco/torus-cli/apitypes"
 	"github.com/manifoldco/torus-cli/errs"
 )
 
-var formatValues = []string{"env", "bash", "powershell", "fish", "cmd", "json", "tfvars"}
+var formatValues = exportFormatNames()
 var formatDescription = "Format of exported secrets (" + strings.Join(formatValues, ", ") + ")"
 
-type mod int
-
-const (
-	_ mod = iota
-	quotes
-	uppercase
-)
-
 func init() {
 	export := cli.Command{
 		Name:      "export",
@@ -37,6 +26,9 @@ func init() {
 			stdEnvFlag,
 			serviceFlag("Use this service.", "default", true),
 			formatFlag(formatValues[0], formatDescription),
+			nameFlag("Name of the exported Kubernetes secret (k8s-secret format only)."),
+			newPlaceholder("namespace", "NAMESPACE",
+				"Namespace of the exported Kubernetes secret (k8s-secret format only).", "", "TORUS_NAMESPACE", false),
 		},
 		Action: chain(
 			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
@@ -58,16 +50,25 @@ func exportCmd(ctx *cli.Context) error {
 		filepath = args[0]
 	}
 
+	format := ctx.String("format")
+	encoder := lookupExportFormat(format)
+	if encoder == nil {
+		return errs.NewUsageExitError(fmt.Sprintf("Invalid format provided: %s", format), ctx)
+	}
+
+	opts := &exportOptions{
+		name:      ctx.String("name"),
+		namespace: ctx.String("namespace"),
+	}
+	if format == "k8s-secret" && opts.name == "" {
+		return errs.NewUsageExitError("--name is required for the k8s-secret format", ctx)
+	}
+
 	secrets, _, err := getSecrets(ctx)
 	if err != nil {
 		return err
 	}
 
-	format := ctx.String("format")
-	if !validFormat(format) {
-		return errs.NewUsageExitError(fmt.Sprintf("Invalid format provided: %s", format), ctx)
-	}
-
 	var w io.Writer = os.Stdout
 	if filepath != "" {
 		fd, err := os.OpenFile(filepath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
@@ -79,84 +80,10 @@ func exportCmd(ctx *cli.Context) error {
 		w = fd
 	}
 
-	switch format {
-	case "env":
-		err = writeFormat(w, secrets, "%s=%s\n", uppercase|quotes)
-	case "bash":
-		err = writeFormat(w, secrets, "export %s=%s\n", uppercase|quotes)
-	case "powershell":
-		err = writeFormat(w, secrets, "$Env:%s = \"%s\"\n", uppercase)
-	case "cmd":
-		err = writeFormat(w, secrets, "set %s=%s\n", quotes)
-	case "fish":
-		err = writeFormat(w, secrets, "set -x %s %s;\n", quotes)
-	case "tfvars":
-		err = writeFormat(w, secrets, "%s = %s\n", quotes)
-	case "json":
-		err = writeJSONFormat(w, secrets)
-	default:
-		return errs.NewUsageExitError(fmt.Sprintf("Could not find format for %s", format), ctx)
-	}
-
+	err = encoder(w, secrets, opts)
 	if err != nil {
 		return errs.NewErrorExitError(fmt.Sprintf("Could not write format for %s", format), err)
 	}
 
 	return nil
 }
-
-func writeFormat(w io.Writer, secrets []apitypes.CredentialEnvelope, format string, modifier mod) error {
-	tw := tabwriter.NewWriter(w, 2, 0, 2, ' ', 0)
-
-	for _, secret := range secrets {
-		name := (*secret.Body).GetName()
-		value := (*secret.Body).GetValue().String()
-
-		if modifier&quotes == quotes {
-			value = fmt.Sprintf("%q", value)
-		}
-
-		if (modifier & uppercase) == uppercase {
-			name = strings.ToUpper(name)
-		}
-
-		fmt.Fprintf(tw, format, name, value)
-	}
-
-	return tw.Flush()
-}
-
-func writeJSONFormat(w io.Writer, secrets []apitypes.CredentialEnvelope) error {
-	keyMap := make(map[string]interface{})
-
-	for _, secret := range secrets {
-		value := (*secret.Body).GetValue()
-		name := (*secret.Body).GetName()
-		v, err := value.Raw()
-		if err != nil {
-			return err
-		}
-
-		keyMap[name] = v
-	}
-
-	enc := json.NewEncoder(w)
-	enc.SetIndent("", "  ")
-
-	err := enc.Encode(keyMap)
-	if err != nil {
-		return errs.NewErrorExitError("Could not marshal to json", err)
-	}
-
-	return nil
-}
-
-func validFormat(format string) bool {
-	for _, v := range formatValues {
-		if v == format {
-			return true
-		}
-	}
-
-	return false
-}
@@ -0,0 +1,442 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/errs"
+)
+
+// exportOptions holds the options for encoders which need more than the
+// secrets themselves.
+type exportOptions struct {
+	name      string
+	namespace string
+}
+
+// exportEncoder writes secrets to w in a specific format.
+type exportEncoder func(w io.Writer, secrets []apitypes.CredentialEnvelope, opts *exportOptions) error
+
+// exportFormat is a named encoder, usable via `torus export --format`.
+type exportFormat struct {
+	name    string
+	encoder exportEncoder
+}
+
+// exportFormats holds every format secrets can be exported in. The first
+// format is the default.
+var exportFormats = []exportFormat{
+	{"env", printfEncoder("%s=%s\n", uppercase|quotes)},
+	{"bash", printfEncoder("export %s=%s\n", uppercase|quotes)},
+	{"powershell", printfEncoder("$Env:%s = \"%s\"\n", uppercase)},
+	{"fish", printfEncoder("set -x %s %s;\n", quotes)},
+	{"cmd", printfEncoder("set %s=%s\n", quotes)},
+	{"json", func(w io.Writer, secrets []apitypes.CredentialEnvelope, _ *exportOptions) error {
+		return writeJSONFormat(w, secrets)
+	}},
+	{"tfvars", printfEncoder("%s = %s\n", quotes)},
+	{"yaml", writeYAMLFormat},
+	{"k8s-secret", writeK8sSecretFormat},
+	{"docker", writeDockerFormat},
+	{"properties", writePropertiesFormat},
+	{"toml", writeTOMLFormat},
+}
+
+func exportFormatNames() []string {
+	names := make([]string, len(exportFormats))
+	for i, f := range exportFormats {
+		names[i] = f.name
+	}
+
+	return names
+}
+
+// lookupExportFormat returns the encoder for the named format, or nil if no
+// such format exists.
+func lookupExportFormat(name string) exportEncoder {
+	for _, f := range exportFormats {
+		if f.name == name {
+			return f.encoder
+		}
+	}
+
+	return nil
+}
+
+type mod int
+
+const (
+	_ mod = iota
+	quotes
+	uppercase
+)
+
+func printfEncoder(format string, modifier mod) exportEncoder {
+	return func(w io.Writer, secrets []apitypes.CredentialEnvelope, _ *exportOptions) error {
+		return writeFormat(w, secrets, format, modifier)
+	}
+}
+
+func writeFormat(w io.Writer, secrets []apitypes.CredentialEnvelope, format string, modifier mod) error {
+	tw := tabwriter.NewWriter(w, 2, 0, 2, ' ', 0)
+
+	for _, secret := range secrets {
+		name := (*secret.Body).GetName()
+		value := (*secret.Body).GetValue().String()
+
+		if modifier&quotes == quotes {
+			value = fmt.Sprintf("%q", value)
+		}
+
+		if (modifier & uppercase) == uppercase {
+			name = strings.ToUpper(name)
+		}
+
+		fmt.Fprintf(tw, format, name, value)
+	}
+
+	return tw.Flush()
+}
+
+// binaryExport is how binary values are written in the json and yaml formats,
+// marked with their type so `torus import` can tell them apart from strings.
+type binaryExport struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+func newBinaryExport(b []byte) *binaryExport {
+	return &binaryExport{Type: "binary", Value: base64.StdEncoding.EncodeToString(b)}
+}
+
+func writeJSONFormat(w io.Writer, secrets []apitypes.CredentialEnvelope) error {
+	keyMap := make(map[string]interface{})
+
+	for _, secret := range secrets {
+		value := (*secret.Body).GetValue()
+		name := (*secret.Body).GetName()
+		v, err := value.Raw()
+		if err != nil {
+			return err
+		}
+
+		switch raw := v.(type) {
+		case []byte:
+			v = newBinaryExport(raw)
+		case float64:
+			v = json.Number(floatText(raw))
+		}
+
+		keyMap[name] = v
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	err := enc.Encode(keyMap)
+	if err != nil {
+		return errs.NewErrorExitError("Could not marshal to json", err)
+	}
+
+	return nil
+}
+
+// floatText returns the float as a number which decodes back to a float,
+// rather than an integer, in the json, yaml, and toml formats.
+func floatText(f float64) string {
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eEIN") {
+		s += ".0"
+	}
+
+	return s
+}
+
+// yamlBareKey matches keys which can be written in YAML without quotes.
+var yamlBareKey = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]*$`)
+
+// yamlReserved holds the plain scalars YAML parsers interpret as something
+// other than a string, and so must be quoted when used as a key.
+var yamlReserved = map[string]bool{
+	"y": true, "yes": true, "n": true, "no": true, "true": true, "false": true,
+	"on": true, "off": true, "null": true,
+}
+
+func yamlKey(name string) string {
+	if yamlBareKey.MatchString(name) && !yamlReserved[strings.ToLower(name)] {
+		return name
+	}
+
+	return yamlQuote(name)
+}
+
+// yamlQuote returns s as a YAML double quoted scalar. YAML's \x escapes are
+// code points rather than bytes, so s must be valid UTF-8.
+func yamlQuote(s string) string {
+	buf := &bytes.Buffer{}
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			switch {
+			case r < 0x20 || r == 0x7f:
+				fmt.Fprintf(buf, `\x%02X`, r)
+			case !unicode.IsPrint(r) && r <= 0xffff:
+				fmt.Fprintf(buf, `\u%04X`, r)
+			case !unicode.IsPrint(r):
+				fmt.Fprintf(buf, `\U%08X`, r)
+			default:
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+
+	return buf.String()
+}
+
+// yamlValue returns the value as a YAML scalar. Strings are double quoted,
+// and escaped such that multi-line and special character values survive
+// intact. Strings which aren't valid UTF-8 can't be represented as YAML
+// strings, so their bytes are written as base64 tagged !!binary. Binary values
+// are written as a typed mapping, as in the json format.
+func yamlValue(value *apitypes.CredentialValue) (string, error) {
+	raw, err := value.Raw()
+	if err != nil {
+		return "", err
+	}
+
+	switch b := raw.(type) {
+	case []byte:
+		e := newBinaryExport(b)
+		return fmt.Sprintf("{type: %s, value: %s}", e.Type, yamlQuote(e.Value)), nil
+	case float64:
+		return floatText(b), nil
+	case int64:
+		return value.String(), nil
+	default:
+		s := value.String()
+		if !utf8.ValidString(s) {
+			return "!!binary " + base64.StdEncoding.EncodeToString([]byte(s)), nil
+		}
+
+		return yamlQuote(s), nil
+	}
+}
+
+func writeYAMLFormat(w io.Writer, secrets []apitypes.CredentialEnvelope, _ *exportOptions) error {
+	buf := &bytes.Buffer{}
+	for _, secret := range secrets {
+		v, err := yamlValue((*secret.Body).GetValue())
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(buf, "%s: %s\n", yamlKey((*secret.Body).GetName()), v)
+	}
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// writeK8sSecretFormat writes a Kubernetes v1/Secret manifest. Names are
+// kept as they are, as they may be mounted as files.
+func writeK8sSecretFormat(w io.Writer, secrets []apitypes.CredentialEnvelope, opts *exportOptions) error {
+	buf := &bytes.Buffer{}
+	fmt.Fprintln(buf, "apiVersion: v1")
+	fmt.Fprintln(buf, "kind: Secret")
+	fmt.Fprintln(buf, "metadata:")
+	fmt.Fprintf(buf, "  name: %s\n", yamlQuote(opts.name))
+	if opts.namespace != "" {
+		fmt.Fprintf(buf, "  namespace: %s\n", yamlQuote(opts.namespace))
+	}
+	fmt.Fprintln(buf, "type: Opaque")
+
+	if len(secrets) == 0 {
+		fmt.Fprintln(buf, "data: {}")
+	} else {
+		fmt.Fprintln(buf, "data:")
+	}
+
+	for _, secret := range secrets {
+		name := (*secret.Body).GetName()
+		value := (*secret.Body).GetValue()
+
+		data := []byte(value.String())
+		if value.IsBinary() {
+			raw, err := value.Raw()
+			if err != nil {
+				return err
+			}
+			data = raw.([]byte)
+		}
+
+		fmt.Fprintf(buf, "  %s: %s\n", yamlKey(name), base64.StdEncoding.EncodeToString(data))
+	}
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// writeDockerFormat writes an env-file for `docker run --env-file`. Docker
+// reads values verbatim, without quoting or escaping, so values containing
+// newlines cannot be represented.
+func writeDockerFormat(w io.Writer, secrets []apitypes.CredentialEnvelope, _ *exportOptions) error {
+	buf := &bytes.Buffer{}
+	for _, secret := range secrets {
+		name := strings.ToUpper((*secret.Body).GetName())
+		value := (*secret.Body).GetValue().String()
+
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("value of %s contains a newline, which docker env-files do not support", name)
+		}
+
+		fmt.Fprintf(buf, "%s=%s\n", name, value)
+	}
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// propertiesEscape escapes s for use in a Java properties file, as
+// described by java.util.Properties#store. Keys additionally have spaces and
+// separator characters escaped.
+func propertiesEscape(s string, key bool) string {
+	buf := &bytes.Buffer{}
+	for i, r := range s {
+		switch {
+		case r == '\\':
+			buf.WriteString(`\\`)
+		case r == '\n':
+			buf.WriteString(`\n`)
+		case r == '\r':
+			buf.WriteString(`\r`)
+		case r == '\t':
+			buf.WriteString(`\t`)
+		case r == '\f':
+			buf.WriteString(`\f`)
+		case r == ' ' && (key || i == 0):
+			buf.WriteString(`\ `)
+		case strings.ContainsRune("=:#!", r):
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			// Properties files are ISO-8859-1; anything else is written
+			// as UTF-16 escapes.
+			for _, u := range utf16Units(r) {
+				fmt.Fprintf(buf, `\u%04X`, u)
+			}
+		default:
+			buf.WriteRune(r)
+		}
+	}
+
+	return buf.String()
+}
+
+func utf16Units(r rune) []rune {
+	if r < 0x10000 {
+		return []rune{r}
+	}
+
+	r -= 0x10000
+	return []rune{0xD800 + (r>>10)&0x3FF, 0xDC00 + r&0x3FF}
+}
+
+func writePropertiesFormat(w io.Writer, secrets []apitypes.CredentialEnvelope, _ *exportOptions) error {
+	buf := &bytes.Buffer{}
+	for _, secret := range secrets {
+		name := (*secret.Body).GetName()
+		value := (*secret.Body).GetValue().String()
+
+		fmt.Fprintf(buf, "%s=%s\n", propertiesEscape(name, true), propertiesEscape(value, false))
+	}
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// tomlQuote returns s as a TOML basic string.
+func tomlQuote(s string) string {
+	buf := &bytes.Buffer{}
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\r':
+			buf.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(buf, `\u%04X`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+
+	return buf.String()
+}
+
+// tomlBareKey matches keys which can be written in TOML without quotes.
+var tomlBareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func writeTOMLFormat(w io.Writer, secrets []apitypes.CredentialEnvelope, _ *exportOptions) error {
+	buf := &bytes.Buffer{}
+	for _, secret := range secrets {
+		name := (*secret.Body).GetName()
+		value := (*secret.Body).GetValue()
+
+		raw, err := value.Raw()
+		if err != nil {
+			return err
+		}
+
+		if !tomlBareKey.MatchString(name) {
+			name = tomlQuote(name)
+		}
+
+		switch f := raw.(type) {
+		case float64:
+			fmt.Fprintf(buf, "%s = %s\n", name, floatText(f))
+		case int64:
+			fmt.Fprintf(buf, "%s = %s\n", name, value.String())
+		default:
+			fmt.Fprintf(buf, "%s = %s\n", name, tomlQuote(value.String()))
+		}
+	}
+
+	_, err := buf.WriteTo(w)
+	return err
+}
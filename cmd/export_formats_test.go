@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/pathexp"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files in testdata")
+
+func exportCredentialsHelper(t *testing.T, multiline bool) []apitypes.CredentialEnvelope {
+	exp, err := pathexp.Parse("/o/p/e/s/*/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := []struct {
+		name  string
+		typ   string
+		value interface{}
+	}{
+		{"api_key", "string", "abc123"},
+		{"greeting", "string", "héllo wörld 👋"},
+		// NUL, invalid UTF-8, and a newline
+		{"keystore", "binary", "AP/+KGtleSkK"},
+		{"multiline", "string", "line one\nline two\r\n\ttabbed"},
+		{"on", "string", "reserved yaml key"},
+		{"port", "number", 3000},
+		{"special", "string", ` leading space, "quotes", 'single', \backslash = : # !`},
+	}
+
+	var creds []apitypes.CredentialEnvelope
+	for _, v := range values {
+		if v.name == "multiline" && !multiline {
+			continue
+		}
+
+		cval, err := interfaceToCredentialValue(t, map[string]interface{}{
+			"version": 2,
+			"body": map[string]interface{}{
+				"type":  v.typ,
+				"value": v.value,
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var cBody apitypes.Credential = &apitypes.CredentialV2{
+			State: "set",
+			BaseCredential: apitypes.BaseCredential{
+				Name:    v.name,
+				PathExp: exp,
+				Value:   cval,
+			},
+		}
+		creds = append(creds, apitypes.CredentialEnvelope{Body: &cBody})
+	}
+
+	return creds
+}
+
+func TestExportFormatsGolden(t *testing.T) {
+	opts := &exportOptions{name: "my-secret", namespace: "production"}
+
+	for _, f := range exportFormats {
+		t.Run(f.name, func(t *testing.T) {
+			creds := exportCredentialsHelper(t, f.name != "docker")
+
+			buf := &bytes.Buffer{}
+			err := f.encoder(buf, creds, opts)
+			if err != nil {
+				t.Fatalf("encoding failed: %s", err)
+			}
+
+			golden := filepath.Join("testdata", "export", f.name+".golden")
+			if *updateGolden {
+				err = ioutil.WriteFile(golden, buf.Bytes(), 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			expected, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(expected, buf.Bytes()) {
+				t.Errorf("output did not match %s; expected\n%s\ngot\n%s", golden, expected, buf.Bytes())
+			}
+		})
+	}
+}
+
+func TestDockerFormatMultiline(t *testing.T) {
+	creds := exportCredentialsHelper(t, true)
+
+	err := writeDockerFormat(&bytes.Buffer{}, creds, &exportOptions{})
+	if err == nil {
+		t.Error("expected an error for a multi-line value")
+	}
+}
+
+func TestJSONFormatBinary(t *testing.T) {
+	exp, err := pathexp.Parse("/o/p/e/s/*/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cBody apitypes.Credential = &apitypes.CredentialV2{
+		State: "set",
+		BaseCredential: apitypes.BaseCredential{
+			Name:    "cert",
+			PathExp: exp,
+			Value:   apitypes.NewBinaryCredentialValue([]byte("\x00\xc3\x28-----BEGIN\n")),
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	err = writeJSONFormat(buf, []apitypes.CredentialEnvelope{{Body: &cBody}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "{\n  \"cert\": {\n    \"type\": \"binary\",\n    \"value\": \"AMMoLS0tLS1CRUdJTgo=\"\n  }\n}\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestYAMLValue(t *testing.T) {
+	for value, expected := range map[string]string{
+		"plain":                `"plain"`,
+		"tab\tbell\a\x7f":      `"tab\tbell\x07\x7F"`,
+		"nbsp\u00a0zwsp\u200b": `"nbsp\u00A0zwsp\u200B"`,
+		"\xff\xfe":             "!!binary //4=",
+	} {
+		v, err := yamlValue(apitypes.NewStringCredentialValue(value))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if v != expected {
+			t.Errorf("expected %s for %q, got %s", expected, value, v)
+		}
+	}
+
+	v, err := yamlValue(apitypes.NewBinaryCredentialValue([]byte{0, 1, 2}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{type: binary, value: "AAEC"}`
+	if v != expected {
+		t.Errorf("expected %s for a binary value, got %s", expected, v)
+	}
+}
+
+func TestLookupExportFormat(t *testing.T) {
+	for _, name := range formatValues {
+		if lookupExportFormat(name) == nil {
+			t.Errorf("no encoder found for %s", name)
+		}
+	}
+
+	if lookupExportFormat("nope") != nil {
+		t.Error("expected no encoder for an unknown format")
+	}
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/satori/go.uuid"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+)
+
+// generateFlag is the flag used to generate a secret's value, rather than
+// supplying it.
+var generateFlag = newPlaceholder("generate, g", "GENERATOR",
+	"Generate the value using GENERATOR (password[:length[:charset]], hex[:bytes], base64[:bytes], uuid, ed25519-keypair).",
+	"", "", false)
+
+const defaultGeneratorLength = 32
+
+// publicKeySuffix is appended to a secret's name to store the public half of
+// a generated keypair.
+const publicKeySuffix = "_public"
+
+// passwordCharsets are the characters available to the password generator.
+var passwordCharsets = map[string]string{
+	"alnum":   "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789",
+	"alpha":   "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	"numeric": "0123456789",
+	"symbols": "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789!#$%&()*+,-./:;<=>?@[]^_{|}~",
+}
+
+// secretGenerator creates secret values client-side, using crypto/rand.
+type secretGenerator struct {
+	kind    string
+	length  int
+	charset string
+}
+
+// parseGenerator parses a generator spec, such as password:32:alnum, hex:64,
+// base64:32, uuid or ed25519-keypair.
+func parseGenerator(spec string) (*secretGenerator, error) {
+	parts := strings.Split(spec, ":")
+	g := &secretGenerator{kind: parts[0], length: defaultGeneratorLength}
+	args := parts[1:]
+
+	maxArgs := 0
+	switch g.kind {
+	case "password":
+		maxArgs = 2
+		g.charset = "alnum"
+	case "hex", "base64":
+		maxArgs = 1
+	case "uuid", "ed25519-keypair":
+	default:
+		return nil, fmt.Errorf("Unknown generator: %s", g.kind)
+	}
+
+	if len(args) > maxArgs {
+		return nil, fmt.Errorf("Too many options for the %s generator: %s", g.kind, spec)
+	}
+
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n <= 0 || n > 4096 {
+			return nil, fmt.Errorf("Invalid length for the %s generator: %s", g.kind, args[0])
+		}
+		g.length = n
+	}
+
+	if len(args) > 1 {
+		if _, ok := passwordCharsets[args[1]]; !ok {
+			return nil, fmt.Errorf("Unknown password charset: %s", args[1])
+		}
+		g.charset = args[1]
+	}
+
+	return g, nil
+}
+
+// Generate creates the values for a secret with the given name. Most
+// generators create a single value; keypair generators also create a second
+// value holding the public key, named with publicKeySuffix.
+func (g *secretGenerator) Generate(name string) (map[string]string, error) {
+	switch g.kind {
+	case "password":
+		v, err := randomString(passwordCharsets[g.charset], g.length)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{name: v}, nil
+	case "hex", "base64":
+		b := make([]byte, g.length)
+		_, err := rand.Read(b)
+		if err != nil {
+			return nil, err
+		}
+
+		if g.kind == "hex" {
+			return map[string]string{name: hex.EncodeToString(b)}, nil
+		}
+		return map[string]string{name: base64.StdEncoding.EncodeToString(b)}, nil
+	case "uuid":
+		return map[string]string{name: uuid.NewV4().String()}, nil
+	case "ed25519-keypair":
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+
+		return map[string]string{
+			name:                   base64.StdEncoding.EncodeToString(priv),
+			name + publicKeySuffix: base64.StdEncoding.EncodeToString(pub),
+		}, nil
+	}
+
+	return nil, fmt.Errorf("Unknown generator: %s", g.kind)
+}
+
+// randomString returns a string of n characters chosen uniformly from charset.
+func randomString(charset string, n int) (string, error) {
+	max := big.NewInt(int64(len(charset)))
+	b := make([]byte, n)
+	for i := range b {
+		idx, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = charset[idx.Int64()]
+	}
+
+	return string(b), nil
+}
+
+// ValueMakers generates the values for the secret with the given name,
+// returning valueMakers for use with setCredentials.
+func (g *secretGenerator) ValueMakers(name string) (valueMakers, error) {
+	values, err := g.Generate(name)
+	if err != nil {
+		return nil, err
+	}
+
+	makers := valueMakers{}
+	for n, v := range values {
+		makers[n] = func(v string) valueMaker {
+			return func() *apitypes.CredentialValue {
+				return apitypes.NewStringCredentialValue(v)
+			}
+		}(v)
+	}
+
+	return makers, nil
+}
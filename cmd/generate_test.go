@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"regexp"
+	"testing"
+
+	gm "github.com/onsi/gomega"
+	"golang.org/x/crypto/ed25519"
+)
+
+func TestParseGenerator(t *testing.T) {
+	gm.RegisterTestingT(t)
+
+	tcs := []struct {
+		spec string
+		want *secretGenerator
+	}{
+		{"password", &secretGenerator{kind: "password", length: 32, charset: "alnum"}},
+		{"password:16", &secretGenerator{kind: "password", length: 16, charset: "alnum"}},
+		{"password:64:symbols", &secretGenerator{kind: "password", length: 64, charset: "symbols"}},
+		{"hex:64", &secretGenerator{kind: "hex", length: 64}},
+		{"base64", &secretGenerator{kind: "base64", length: 32}},
+		{"uuid", &secretGenerator{kind: "uuid", length: 32}},
+		{"ed25519-keypair", &secretGenerator{kind: "ed25519-keypair", length: 32}},
+	}
+
+	for _, tc := range tcs {
+		g, err := parseGenerator(tc.spec)
+		gm.Expect(err).To(gm.BeNil(), tc.spec)
+		gm.Expect(g).To(gm.Equal(tc.want), tc.spec)
+	}
+
+	for _, spec := range []string{
+		"", "nope", "password:0", "password:abc", "password:16:emoji",
+		"password:16:alnum:extra", "hex:16:alnum", "uuid:4", "base64:100000",
+	} {
+		_, err := parseGenerator(spec)
+		gm.Expect(err).ToNot(gm.BeNil(), spec)
+	}
+}
+
+func TestSecretGeneratorGenerate(t *testing.T) {
+	gm.RegisterTestingT(t)
+
+	generate := func(spec string) map[string]string {
+		g, err := parseGenerator(spec)
+		gm.Expect(err).To(gm.BeNil())
+
+		values, err := g.Generate("key")
+		gm.Expect(err).To(gm.BeNil())
+		return values
+	}
+
+	t.Run("password", func(t *testing.T) {
+		v := generate("password:40:numeric")["key"]
+		gm.Expect(v).To(gm.MatchRegexp(`^[0-9]{40}$`))
+
+		v = generate("password")["key"]
+		gm.Expect(v).To(gm.MatchRegexp(`^[a-zA-Z0-9]{32}$`))
+		gm.Expect(generate("password")["key"]).ToNot(gm.Equal(v))
+	})
+
+	t.Run("hex", func(t *testing.T) {
+		b, err := hex.DecodeString(generate("hex:64")["key"])
+		gm.Expect(err).To(gm.BeNil())
+		gm.Expect(b).To(gm.HaveLen(64))
+	})
+
+	t.Run("base64", func(t *testing.T) {
+		b, err := base64.StdEncoding.DecodeString(generate("base64:24")["key"])
+		gm.Expect(err).To(gm.BeNil())
+		gm.Expect(b).To(gm.HaveLen(24))
+	})
+
+	t.Run("uuid", func(t *testing.T) {
+		re := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+		gm.Expect(re.MatchString(generate("uuid")["key"])).To(gm.BeTrue())
+	})
+
+	t.Run("ed25519-keypair", func(t *testing.T) {
+		values := generate("ed25519-keypair")
+		gm.Expect(values).To(gm.HaveLen(2))
+
+		priv, err := base64.StdEncoding.DecodeString(values["key"])
+		gm.Expect(err).To(gm.BeNil())
+		pub, err := base64.StdEncoding.DecodeString(values["key_public"])
+		gm.Expect(err).To(gm.BeNil())
+
+		gm.Expect(ed25519.PrivateKey(priv).Public()).To(gm.Equal(ed25519.PublicKey(pub)))
+	})
+}
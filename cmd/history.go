@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juju/ansiterm"
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+	"github.com/manifoldco/torus-cli/ui"
+)
+
+func init() {
+	history := cli.Command{
+		Name:      "history",
+		Usage:     "List every version of a secret for a service and environment",
+		ArgsUsage: "<name|path>",
+		Category:  "SECRETS",
+		Flags: append(setUnsetFlags, cli.BoolFlag{
+			Name:  "show-values",
+			Usage: "Decrypt and show the value of each version, where still accessible",
+		}),
+		Action: chain(
+			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+			setSliceDefaults, historyCmd,
+		),
+	}
+
+	Cmds = append(Cmds, history)
+}
+
+func historyCmd(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 1 {
+		msg := "Name or path is required."
+		if len(args) > 1 {
+			msg = "Too many arguments provided."
+		}
+		return errs.NewUsageExitError(msg, ctx)
+	}
+
+	pe, cname, err := determinePath(ctx, args[0])
+	if err != nil {
+		return errs.NewErrorExitError("Could not retrieve history", err)
+	}
+
+	name := strings.ToLower(*cname)
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	showValues := ctx.Bool("show-values")
+	s, p := spinner(fmt.Sprintf("Retrieving history of %s", name))
+	s.Start()
+	versions, err := client.Credentials.History(c, pe.String(), name, showValues, p)
+	s.Stop()
+	if err != nil {
+		return errs.NewErrorExitError("Could not retrieve history.", err)
+	}
+
+	if len(versions) == 0 {
+		return errs.NewExitError(fmt.Sprintf("Credential %s not found at %s", name, displayPathExp(pe)))
+	}
+
+	signers, err := historySigners(c, client, versions)
+	if err != nil {
+		return errs.NewErrorExitError("Could not retrieve history.", err)
+	}
+
+	fmt.Printf("\nHistory of %s/%s\n\n", displayPathExp(pe), name)
+	writeHistory(os.Stdout, versions, signers, showValues)
+	fmt.Printf("\n%s has (%s) version%s\n", name,
+		ui.FaintString(strconv.Itoa(len(versions))), plural(len(versions)))
+
+	return nil
+}
+
+// historySigners looks up the usernames of the users who signed each version.
+func historySigners(c context.Context, client *api.Client,
+	versions []apitypes.CredentialVersion) (map[identity.ID]string, error) {
+
+	typ := (&primitive.User{}).Type()
+	seen := make(map[identity.ID]bool)
+	var userIDs []identity.ID
+	for _, v := range versions {
+		if v.SignerID == nil || v.SignerID.Type() != typ || seen[*v.SignerID] {
+			continue
+		}
+
+		seen[*v.SignerID] = true
+		userIDs = append(userIDs, *v.SignerID)
+	}
+
+	signers := make(map[identity.ID]string)
+	if len(userIDs) == 0 {
+		return signers, nil
+	}
+
+	profiles, err := client.Profiles.ListByID(c, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, profile := range profiles {
+		signers[*profile.ID] = profile.Body.Username
+	}
+
+	return signers, nil
+}
+
+// writeHistory writes a table of the given versions. Signers are displayed by
+// username where known, falling back to their ID. Set times which are only
+// known to be no earlier than their keyring's creation are marked with a ~.
+func writeHistory(out io.Writer, versions []apitypes.CredentialVersion,
+	signers map[identity.ID]string, showValues bool) {
+
+	w := ansiterm.NewTabWriter(out, 2, 0, 3, ' ', 0)
+
+	headers := []string{"Version", "Keyring", "ID", "Set", "Signed by", "State"}
+	if showValues {
+		headers = append(headers, "Value")
+	}
+	for i, h := range headers {
+		headers[i] = ui.BoldString(h)
+	}
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+
+	approximate := false
+	for _, v := range versions {
+		signer := "-"
+		if v.SignerID != nil {
+			signer = v.SignerID.String()
+			if username, ok := signers[*v.SignerID]; ok {
+				signer = username
+			}
+		}
+
+		state := ui.ColorString(ui.Green, "set")
+		if v.Unset {
+			state = ui.FaintString("unset")
+		}
+
+		setAt, exact := v.SetAt()
+		set := setAt.Format(time.RFC3339)
+		if !exact {
+			set = "~" + set
+			approximate = true
+		}
+
+		row := []string{
+			strconv.Itoa(v.CredentialVersion),
+			strconv.Itoa(v.KeyringVersion),
+			v.ID.String(),
+			set,
+			signer,
+			state,
+		}
+
+		if showValues {
+			value := ui.FaintString("-")
+			if v.Value != nil {
+				value = v.Value.String()
+			} else if !v.Unset {
+				value = ui.FaintString("(no access)")
+			}
+			row = append(row, value)
+		}
+
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+
+	w.Flush()
+
+	if approximate {
+		fmt.Fprintf(out, "\n%s\n", ui.FaintString(
+			"~ Set at or after this time, when the keyring version holding it was created."))
+	}
+}
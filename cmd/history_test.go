@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	gm "github.com/onsi/gomega"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/prefs"
+	"github.com/manifoldco/torus-cli/ui"
+)
+
+func TestWriteHistory(t *testing.T) {
+	gm.RegisterTestingT(t)
+	ui.Init(&prefs.Preferences{})
+
+	signer, err := identity.DecodeFromString("0400000000000000000000000000a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id1, err := identity.DecodeFromString("04100000000000000000000000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id2, err := identity.DecodeFromString("04100000000000000000000000010")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created := time.Date(2018, 3, 19, 12, 0, 0, 0, time.UTC)
+	setAt := time.Date(2018, 3, 20, 8, 30, 0, 0, time.UTC)
+	versions := []apitypes.CredentialVersion{
+		{ID: &id2, CredentialVersion: 3, KeyringVersion: 2, KeyringCreated: created, SignerID: &signer, Unset: true},
+		{ID: &id1, CredentialVersion: 2, KeyringVersion: 1, KeyringCreated: created, SignerID: &signer,
+			Value: apitypes.NewStringCredentialValue("old").WithSetAt(&setAt)},
+		{ID: &id1, CredentialVersion: 1, KeyringVersion: 1, KeyringCreated: created},
+	}
+	signers := map[identity.ID]string{signer: "jeff"}
+
+	buf := &bytes.Buffer{}
+	writeHistory(buf, versions, signers, false)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	gm.Expect(lines).To(gm.HaveLen(6))
+	gm.Expect(lines[0]).NotTo(gm.ContainSubstring("Value"))
+	gm.Expect(strings.Fields(lines[1])).To(gm.Equal([]string{
+		"3", "2", id2.String(), "~2018-03-19T12:00:00Z", "jeff", "unset",
+	}))
+	gm.Expect(lines[2]).NotTo(gm.ContainSubstring("old"))
+	gm.Expect(strings.Fields(lines[2])[3]).To(gm.Equal("2018-03-20T08:30:00Z"))
+	gm.Expect(strings.Fields(lines[3])[4]).To(gm.Equal("-"))
+	gm.Expect(lines[5]).To(gm.HavePrefix("~ Set at or after"))
+
+	buf.Reset()
+	writeHistory(buf, versions, signers, true)
+
+	lines = strings.Split(strings.TrimSpace(buf.String()), "\n")
+	gm.Expect(lines[0]).To(gm.ContainSubstring("Value"))
+	gm.Expect(strings.Fields(lines[1])).To(gm.HaveLen(7))
+	gm.Expect(lines[2]).To(gm.HaveSuffix("old"))
+	gm.Expect(lines[3]).To(gm.HaveSuffix("(no access)"))
+
+	buf.Reset()
+	writeHistory(buf, versions[1:2], signers, true)
+	gm.Expect(buf.String()).NotTo(gm.ContainSubstring("~"))
+}
@@ -1,16 +1,26 @@
 package cmd
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/google/shlex"
 	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
 
+	"github.com/manifoldco/torus-cli/api"
 	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
 	"github.com/manifoldco/torus-cli/errs"
 	"github.com/manifoldco/torus-cli/hints"
 )
@@ -20,13 +30,36 @@ type secretPair struct {
 	value string
 }
 
+// importedSecret is a secret read from an import file, along with its typed
+// value.
+type importedSecret struct {
+	key   string
+	value *apitypes.CredentialValue
+}
+
+// importDecoder reads secrets from an import file in a specific format.
+type importDecoder func(io.Reader) ([]importedSecret, error)
+
+var importFormatValues = []string{"env", "dotenv", "json", "yaml"}
+var importFormatDescription = "Format of imported secrets (" + strings.Join(importFormatValues, ", ") + ")"
+
+var importDecoders = map[string]importDecoder{
+	"env":    pairDecoder(scanSecrets),
+	"dotenv": pairDecoder(parseDotenv),
+	"json":   decodeJSONSecrets,
+	"yaml":   decodeYAMLSecrets,
+}
+
 func init() {
+	flags := append([]cli.Flag{}, setUnsetFlags...)
+	flags = append(flags, formatFlag(importFormatValues[0], importFormatDescription))
+
 	c := cli.Command{
 		Name:      "import",
-		Usage:     "Import multiple secrets from an env file",
+		Usage:     "Import multiple secrets from an env, dotenv, json or yaml file",
 		ArgsUsage: "[path to file] or use stdin redirection (e.g. `torus import < secrets.env`)",
 		Category:  "SECRETS",
-		Flags:     setUnsetFlags,
+		Flags:     flags,
 		Action: chain(
 			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
 			setSliceDefaults, importCmd,
@@ -37,8 +70,14 @@ func init() {
 }
 
 func importCmd(ctx *cli.Context) error {
+	format := ctx.String("format")
+	decoder, ok := importDecoders[format]
+	if !ok {
+		return errs.NewUsageExitError(fmt.Sprintf("Invalid format provided: %s", format), ctx)
+	}
+
 	args := ctx.Args()
-	secrets, err := importSecretFile(args)
+	secrets, err := importSecrets(args, decoder)
 
 	if err != nil {
 		return errs.NewUsageExitError(err.Error(), ctx)
@@ -51,13 +90,31 @@ func importCmd(ctx *cli.Context) error {
 
 	makers := valueMakers{}
 	for _, secret := range secrets {
-		makers[secret.key] = func(value string) valueMaker {
+		makers[secret.key] = func(value *apitypes.CredentialValue) valueMaker {
 			return func() *apitypes.CredentialValue {
-				return apitypes.NewStringCredentialValue(value)
+				return value
 			}
 		}(secret.value)
 	}
 
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	// Imported values replace existing ones, but keep their metadata, as
+	// with `torus set`.
+	s, _ := spinner("Fetching existing credentials")
+	s.Start()
+	err = carryMetadata(c, client, path, makers)
+	s.Stop()
+	if err != nil {
+		return errs.NewErrorExitError("Could not retrieve existing credentials.", err)
+	}
+
 	s, p := spinner("Attempting to set credentials")
 	s.Start()
 	creds, err := setCredentials(ctx, path, makers, p)
@@ -77,10 +134,19 @@ func importCmd(ctx *cli.Context) error {
 	return nil
 }
 
-// importSecretFile returns a list of secret pairs either reading a file
-// provided or from the standard input. It returns an error if there is a
-// problem parsing secrets or stdin fails to read.
-func importSecretFile(args []string) ([]secretPair, error) {
+// importSecrets returns the secrets read by decoder from either the file
+// provided or the standard input.
+func importSecrets(args []string, decoder importDecoder) ([]importedSecret, error) {
+	r, err := openImportFile(args)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return decoder(r)
+}
+
+func openImportFile(args []string) (io.ReadCloser, error) {
 	switch len(args) {
 	case 0:
 		return readStdin()
@@ -91,7 +157,7 @@ func importSecretFile(args []string) ([]secretPair, error) {
 	}
 }
 
-func readFile(filename string) ([]secretPair, error) {
+func readFile(filename string) (io.ReadCloser, error) {
 	flags := os.O_RDONLY
 	f, err := os.OpenFile(filename, flags, 0644)
 	if os.IsNotExist(err) {
@@ -101,12 +167,10 @@ func readFile(filename string) ([]secretPair, error) {
 		return nil, fmt.Errorf("Error reading %s file %s", filename, err)
 	}
 
-	defer f.Close()
-
-	return scanSecrets(f)
+	return f, nil
 }
 
-func readStdin() ([]secretPair, error) {
+func readStdin() (io.ReadCloser, error) {
 	stat, err := os.Stdin.Stat()
 	if err != nil {
 		return nil, fmt.Errorf("Could not from stdin. %s", err)
@@ -116,7 +180,162 @@ func readStdin() ([]secretPair, error) {
 		return nil, errors.New("Could not read from piped input")
 	}
 
-	return scanSecrets(os.Stdin)
+	return ioutil.NopCloser(os.Stdin), nil
+}
+
+// pairDecoder adapts a parser of untyped secret pairs into an importDecoder,
+// treating every value as a string.
+func pairDecoder(parse func(io.Reader) ([]secretPair, error)) importDecoder {
+	return func(r io.Reader) ([]importedSecret, error) {
+		pairs, err := parse(r)
+		if err != nil {
+			return nil, err
+		}
+
+		secrets := make([]importedSecret, len(pairs))
+		for i, pair := range pairs {
+			secrets[i] = importedSecret{
+				key:   pair.key,
+				value: apitypes.NewStringCredentialValue(pair.value),
+			}
+		}
+
+		return secrets, nil
+	}
+}
+
+// decodeJSONSecrets reads secrets from a JSON object mapping names to string,
+// number, or binary values, as written by `torus export --format json`.
+func decodeJSONSecrets(r io.Reader) ([]importedSecret, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	var values map[string]interface{}
+	err := dec.Decode(&values)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing JSON. %s", err)
+	}
+
+	return importValues(values)
+}
+
+// decodeYAMLSecrets reads secrets from a YAML mapping of names to string,
+// number, or binary values.
+func decodeYAMLSecrets(r io.Reader) ([]importedSecret, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading input file. %s", err)
+	}
+
+	var values map[string]interface{}
+	err = yaml.Unmarshal(b, &values)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing YAML. %s", err)
+	}
+
+	// Integers too large for an int are decoded as uint64s, or as float64s
+	// which lose their precision, so they're imported from their text.
+	var text map[string]string
+	if yaml.Unmarshal(b, &text) == nil {
+		for k, v := range values {
+			switch v.(type) {
+			case int64, uint64, float64:
+				if integerText.MatchString(text[k]) {
+					values[k] = json.Number(text[k])
+				}
+			}
+		}
+	}
+
+	return importValues(values)
+}
+
+// integerText matches decimal integers.
+var integerText = regexp.MustCompile(`^[-+]?[0-9]+$`)
+
+// importValues converts decoded JSON or YAML values into typed secrets,
+// sorted by name. Booleans, and integers too large to store as numbers, are
+// imported as strings.
+func importValues(values map[string]interface{}) ([]importedSecret, error) {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	secrets := make([]importedSecret, 0, len(keys))
+	for _, k := range keys {
+		if k == "" {
+			return nil, errors.New("A secret must have a name")
+		}
+
+		var value *apitypes.CredentialValue
+		switch v := values[k].(type) {
+		case string:
+			value = apitypes.NewStringCredentialValue(v)
+		case bool:
+			value = apitypes.NewStringCredentialValue(strconv.FormatBool(v))
+		case int:
+			value = apitypes.NewIntCredentialValue(v)
+		case float64:
+			value = apitypes.NewFloatCredentialValue(v)
+		case json.Number:
+			// Numbers with a fraction or exponent are floats, even when
+			// they're whole, as export writes floats such as 1 as 1.0.
+			if !integerText.MatchString(v.String()) {
+				f, err := v.Float64()
+				if err != nil {
+					return nil, fmt.Errorf("Error parsing secret %q: invalid number %s", k, v)
+				}
+				value = apitypes.NewFloatCredentialValue(f)
+			} else if i, err := strconv.Atoi(v.String()); err == nil {
+				value = apitypes.NewIntCredentialValue(i)
+			} else {
+				value = apitypes.NewStringCredentialValue(v.String())
+			}
+		case map[string]interface{}:
+			b, err := binaryImport(v)
+			if err != nil {
+				return nil, fmt.Errorf("Error parsing secret %q: %s", k, err)
+			}
+			value = apitypes.NewBinaryCredentialValue(b)
+		case map[interface{}]interface{}:
+			obj := make(map[string]interface{}, len(v))
+			for name, field := range v {
+				if s, ok := name.(string); ok {
+					obj[s] = field
+				}
+			}
+
+			b, err := binaryImport(obj)
+			if err != nil {
+				return nil, fmt.Errorf("Error parsing secret %q: %s", k, err)
+			}
+			value = apitypes.NewBinaryCredentialValue(b)
+		default:
+			return nil, fmt.Errorf("Error parsing secret %q: value must be a string, number or binary value", k)
+		}
+
+		secrets = append(secrets, importedSecret{key: k, value: value})
+	}
+
+	return secrets, nil
+}
+
+// binaryImport returns the bytes of a binary value, written as an object with
+// a type of binary and a base64 encoded value, as done by `torus export`.
+func binaryImport(obj map[string]interface{}) ([]byte, error) {
+	s, ok := obj["value"].(string)
+	if len(obj) != 2 || obj["type"] != "binary" || !ok {
+		return nil, errors.New(`objects must have a "type" of binary and a base64 "value"`)
+	}
+
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 value. %s", err)
+	}
+
+	return b, nil
 }
 
 // scanSecrets reads secret pairs using an UNIX shell-like syntax parser. Empty
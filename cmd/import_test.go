@@ -1,55 +1,69 @@
 package cmd
 
 import (
+	"bytes"
+	"fmt"
 	"io/ioutil"
+	"os"
 	"reflect"
 	"strings"
 	"testing"
-)
 
-func TestImportSecretFile(t *testing.T) {
-	t.Run("when argument file exists", func(t *testing.T) {
-		tmpfile, err := ioutil.TempFile("", "secrets.txt")
-		if err != nil {
-			t.Fatal(err)
-		}
-		file := tmpfile.Name()
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/pathexp"
+)
 
-		content := `# this is an env file
+func TestImportSecrets(t *testing.T) {
+	files := map[string]string{
+		"dotenv": `# this is an env file
 FOO=bar
 BAR=value # with comment
 BAZ="multi word"
-`
+`,
+		"json": `{"FOO": "bar", "BAR": "value", "BAZ": "multi word"}`,
+		"yaml": "FOO: bar\nBAR: value # with comment\nBAZ: multi word\n",
+	}
 
-		err = ioutil.WriteFile(file, []byte(content), 0644)
-		if err != nil {
-			t.Fatal(err)
-		}
+	for format, content := range files {
+		t.Run("when argument file exists as "+format, func(t *testing.T) {
+			tmpfile, err := ioutil.TempFile("", "secrets.txt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			file := tmpfile.Name()
+			tmpfile.Close()
+			defer os.Remove(file)
 
-		expect := []secretPair{
-			{"FOO", "bar"},
-			{"BAR", "value"},
-			{"BAZ", "multi word"},
-		}
-		got, err := importSecretFile([]string{file})
+			err = ioutil.WriteFile(file, []byte(content), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
 
-		if err != nil {
-			t.Errorf("importSecretFile(%v) expected no errors, got %q", file, err)
-		}
+			expect := map[string]string{"FOO": "bar", "BAR": "value", "BAZ": "multi word"}
+			secrets, err := importSecrets([]string{file}, importDecoders[format])
+			if err != nil {
+				t.Fatalf("importSecrets(%v) expected no errors, got %q", file, err)
+			}
 
-		if !reflect.DeepEqual(expect, got) {
-			t.Errorf("importSecretFile(%v) expected %q, got %q", file, expect, got)
-		}
-	})
+			got := make(map[string]string)
+			for _, s := range secrets {
+				got[s.key] = s.value.String()
+			}
+
+			if !reflect.DeepEqual(expect, got) {
+				t.Errorf("importSecrets(%v) expected %q, got %q", file, expect, got)
+			}
+		})
+	}
 
 	t.Run("when argument file doesn't exist", func(t *testing.T) {
 		file := "torus-secret-missing-file.txt"
 
 		expected := "torus-secret-missing-file.txt does not exist"
-		_, got := importSecretFile([]string{file})
+		_, got := importSecrets([]string{file}, importDecoders["dotenv"])
 
-		if got.Error() != expected {
-			t.Errorf("importSecretFile(%v) expected error %q, got %q", file, expected, got)
+		if got == nil || got.Error() != expected {
+			t.Errorf("importSecrets(%v) expected error %q, got %v", file, expected, got)
 		}
 	})
 
@@ -57,10 +71,10 @@ BAZ="multi word"
 		files := []string{"a.env", "b.env"}
 
 		expected := "Too many arguments were provided"
-		_, got := importSecretFile(files)
+		_, got := importSecrets(files, importDecoders["dotenv"])
 
-		if got.Error() != expected {
-			t.Errorf("importSecretFile(%v) expected error %q, got %q", files, expected, got)
+		if got == nil || got.Error() != expected {
+			t.Errorf("importSecrets(%v) expected error %q, got %v", files, expected, got)
 		}
 	})
 }
@@ -138,3 +152,296 @@ func TestScanSecrets(t *testing.T) {
 		}
 	}
 }
+
+func TestParseDotenv(t *testing.T) {
+	type tc struct {
+		name    string
+		content string
+		pairs   []secretPair
+		err     string
+	}
+
+	testCases := []tc{
+		{
+			name: "simple values and comments",
+			content: `# comment line
+FOO=bar
+export BAR=baz # inline comment
+  BAZ = spaced out  
+URL=http://example.com/#anchor
+EMPTY=
+`,
+			pairs: []secretPair{
+				{"FOO", "bar"},
+				{"BAR", "baz"},
+				{"BAZ", "spaced out"},
+				{"URL", "http://example.com/#anchor"},
+				{"EMPTY", ""},
+			},
+		},
+		{
+			name: "quoted values",
+			content: `DOUBLE="two words" # comment
+SINGLE='literal \n $HOME'
+ESCAPED="line\nnext\t\"quoted\" \\ \$"
+HASH="not # a comment"
+`,
+			pairs: []secretPair{
+				{"DOUBLE", "two words"},
+				{"SINGLE", `literal \n $HOME`},
+				{"ESCAPED", "line\nnext\t\"quoted\" \\ $"},
+				{"HASH", "not # a comment"},
+			},
+		},
+		{
+			name:    "multi-line values",
+			content: "CERT=\"-----BEGIN-----\nabc\n-----END-----\"\r\nKEY='one\ntwo'\nAFTER=yes",
+			pairs: []secretPair{
+				{"CERT", "-----BEGIN-----\nabc\n-----END-----"},
+				{"KEY", "one\ntwo"},
+				{"AFTER", "yes"},
+			},
+		},
+		{
+			name:    "missing equals",
+			content: "FOO=bar\nfoo\n",
+			err:     "Error parsing secret on line 2: expected NAME=value",
+		},
+		{
+			name:    "invalid name",
+			content: "FOO BAR=baz",
+			err:     `Error parsing secret on line 1: invalid name "FOO BAR"`,
+		},
+		{
+			name:    "unterminated quote",
+			content: "A=1\nFOO=\"bar\nbaz",
+			err:     "Error parsing secret on line 2: unterminated quoted value for FOO",
+		},
+		{
+			name:    "trailing characters",
+			content: `FOO="bar" baz`,
+			err:     "Error parsing secret on line 1: unexpected characters after the value for FOO",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pairs, err := parseDotenv(strings.NewReader(tc.content))
+
+			if tc.err == "" && err != nil {
+				t.Fatalf("parseDotenv() expected no errors, got %q", err)
+			}
+
+			if tc.err != "" && (err == nil || err.Error() != tc.err) {
+				t.Errorf("parseDotenv() expected error %q, got %v", tc.err, err)
+			}
+
+			if !reflect.DeepEqual(tc.pairs, pairs) {
+				t.Errorf("parseDotenv() expected %q, got %q", tc.pairs, pairs)
+			}
+		})
+	}
+}
+
+func TestImportRoundTrip(t *testing.T) {
+	creds := exportCredentialsHelper(t, true)
+
+	formats := []struct {
+		name   string
+		export exportEncoder
+	}{
+		{"json", lookupExportFormat("json")},
+		{"yaml", lookupExportFormat("yaml")},
+	}
+
+	for _, f := range formats {
+		t.Run(f.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			err := f.export(buf, creds, &exportOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			secrets, err := importDecoders[f.name](buf)
+			if err != nil {
+				t.Fatalf("expected no errors, got %q", err)
+			}
+
+			if len(secrets) != len(creds) {
+				t.Fatalf("expected %d secrets, got %d", len(creds), len(secrets))
+			}
+
+			for i, secret := range secrets {
+				name := (*creds[i].Body).GetName()
+				value := (*creds[i].Body).GetValue()
+
+				if secret.key != name {
+					t.Errorf("expected name %q, got %q", name, secret.key)
+				}
+
+				expected, _ := value.MarshalJSON()
+				got, _ := secret.value.MarshalJSON()
+				if !bytes.Equal(expected, got) {
+					t.Errorf("expected value %s for %s, got %s", expected, name, got)
+				}
+			}
+		})
+	}
+}
+
+func TestImportFloatRoundTrip(t *testing.T) {
+	exp, err := pathexp.Parse("/o/p/e/s/*/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := map[string]float64{"whole": 1, "negative": -3, "fraction": 1.5, "large": 1e21}
+
+	var creds []apitypes.CredentialEnvelope
+	for name, f := range values {
+		var cBody apitypes.Credential = &apitypes.CredentialV2{
+			State: "set",
+			BaseCredential: apitypes.BaseCredential{
+				Name:    name,
+				PathExp: exp,
+				Value:   apitypes.NewFloatCredentialValue(f),
+			},
+		}
+		creds = append(creds, apitypes.CredentialEnvelope{Body: &cBody})
+	}
+
+	for _, format := range []string{"json", "yaml"} {
+		t.Run(format, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			err := lookupExportFormat(format)(buf, creds, &exportOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			secrets, err := importDecoders[format](buf)
+			if err != nil {
+				t.Fatalf("expected no errors, got %q", err)
+			}
+
+			// Whole floats must not come back as integers.
+			for _, s := range secrets {
+				raw, err := s.value.Raw()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if raw != values[s.key] {
+					t.Errorf("expected float64 %v for %s, got %T %v", values[s.key], s.key, raw, raw)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeJSONSecretsErrors(t *testing.T) {
+	for _, content := range []string{`[]`, `{"foo": {"nested": true}}`, `{"foo": null}`, `{"": "x"}`} {
+		_, err := decodeJSONSecrets(strings.NewReader(content))
+		if err == nil {
+			t.Errorf("decodeJSONSecrets(%s) expected an error", content)
+		}
+	}
+}
+
+func TestImportBinary(t *testing.T) {
+	t.Run("typed values", func(t *testing.T) {
+		for format, content := range map[string]string{
+			"json": `{"cert": {"type": "binary", "value": "AAEC"}, "plain": "base64:AAEC"}`,
+			"yaml": "cert: {type: binary, value: \"AAEC\"}\nplain: \"base64:AAEC\"\n",
+		} {
+			secrets, err := importDecoders[format](strings.NewReader(content))
+			if err != nil {
+				t.Fatalf("%s: expected no errors, got %q", format, err)
+			}
+
+			if len(secrets) != 2 {
+				t.Fatalf("%s: expected 2 secrets, got %d", format, len(secrets))
+			}
+
+			cert, plain := secrets[0].value, secrets[1].value
+			raw, err := cert.Raw()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !cert.IsBinary() || !bytes.Equal(raw.([]byte), []byte{0, 1, 2}) {
+				t.Errorf("%s: expected cert to be binary, got %v", format, raw)
+			}
+
+			// Only typed values are binary; strings are kept as they are.
+			if plain.IsBinary() || plain.String() != "base64:AAEC" {
+				t.Errorf("%s: expected plain to be a string, got %s", format, plain)
+			}
+		}
+	})
+
+	t.Run("env values are strings", func(t *testing.T) {
+		for _, format := range []string{"env", "dotenv"} {
+			secrets, err := importDecoders[format](strings.NewReader("CERT=base64:AAEC\n"))
+			if err != nil {
+				t.Fatalf("%s: expected no errors, got %q", format, err)
+			}
+
+			value := secrets[0].value
+			if value.IsBinary() || value.String() != "base64:AAEC" {
+				t.Errorf("%s: expected a string value, got %s", format, value)
+			}
+		}
+	})
+
+	t.Run("invalid typed values", func(t *testing.T) {
+		for _, content := range []string{
+			`{"cert": {"type": "binary", "value": "not base64!"}}`,
+			`{"cert": {"type": "string", "value": "AAEC"}}`,
+			`{"cert": {"type": "binary"}}`,
+			`{"cert": {"type": "binary", "value": "AAEC", "extra": true}}`,
+		} {
+			_, err := decodeJSONSecrets(strings.NewReader(content))
+			if err == nil {
+				t.Errorf("decodeJSONSecrets(%s) expected an error", content)
+			}
+		}
+	})
+}
+
+func TestImportLargeIntegers(t *testing.T) {
+	decoders := map[string]string{
+		"json": `{"small": 2147483647, "over": 18446744073709551615, "huge": 123456789012345678901234567890, "neg": -9223372036854775809, "float": 1.5, "whole": 1.0}`,
+		"yaml": "small: 2147483647\nover: 18446744073709551615\nhuge: 123456789012345678901234567890\nneg: -9223372036854775809\nfloat: 1.5\nwhole: 1.0\n",
+	}
+
+	for format, content := range decoders {
+		t.Run(format, func(t *testing.T) {
+			secrets, err := importDecoders[format](strings.NewReader(content))
+			if err != nil {
+				t.Fatalf("expected no errors, got %q", err)
+			}
+
+			got := make(map[string]string)
+			for _, s := range secrets {
+				raw, err := s.value.Raw()
+				if err != nil {
+					t.Fatal(err)
+				}
+				got[s.key] = fmt.Sprintf("%T %s", raw, s.value)
+			}
+
+			// Integers which fit are numbers; the rest are kept exactly as
+			// strings.
+			expected := map[string]string{
+				"small": "int 2147483647",
+				"over":  "string 18446744073709551615",
+				"huge":  "string 123456789012345678901234567890",
+				"neg":   "string -9223372036854775809",
+				"float": "float64 1.5",
+				"whole": "float64 1",
+			}
+			if !reflect.DeepEqual(expected, got) {
+				t.Errorf("expected %v, got %v", expected, got)
+			}
+		})
+	}
+}
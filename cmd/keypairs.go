@@ -20,7 +20,7 @@ import (
 func init() {
 	keypairs := cli.Command{
 		Name:     "keypairs",
-		Usage:    "View and generate organization keypairs",
+		Usage:    "View, generate and rotate organization keypairs",
 		Category: "ORGANIZATIONS",
 		Subcommands: []cli.Command{
 			{
@@ -49,6 +49,17 @@ func init() {
 					checkRequiredFlags, generateKeypairs,
 				),
 			},
+			{
+				Name:  "rotate",
+				Usage: "Replace your keypairs for an organization with new ones",
+				Flags: []cli.Flag{
+					orgFlag("org to rotate keypairs for", true),
+				},
+				Action: chain(
+					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+					checkRequiredFlags, rotateKeypairs,
+				),
+			},
 			{
 				Name:  "revoke",
 				Usage: "Revoke the keypairs for an organization (used for testing only)",
@@ -245,6 +256,44 @@ func generateKeypairsForOrg(c context.Context, ctx *cli.Context, client *api.Cli
 	return nil
 }
 
+func rotateKeypairs(ctx *cli.Context) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	orgName := ctx.String("org")
+	org, err := client.Orgs.GetByName(c, orgName)
+	if err != nil || org == nil {
+		return errs.NewExitError("Org '" + orgName + "' not found.")
+	}
+
+	keypairs, err := client.KeyPairs.List(c, org.ID)
+	if err != nil {
+		return errs.NewErrorExitError("Error fetching keypairs.", err)
+	}
+
+	if _, err := keypairs.Select(org.ID, primitive.SigningKeyType); err != nil {
+		msg := fmt.Sprintf("No keypairs to rotate. Run '%s keypairs generate' to create them.", ctx.App.Name)
+		return errs.NewExitError(msg)
+	}
+
+	s, p := spinner("Attempting to rotate keypairs")
+	s.Start()
+	err = client.KeyPairs.Rotate(c, org.ID, p)
+	s.Stop()
+	if err != nil {
+		msg := fmt.Sprintf("Error while rotating keypairs. Run '%s keypairs rotate' again to finish.", ctx.App.Name)
+		return errs.NewErrorExitError(msg, err)
+	}
+
+	fmt.Printf("Keypairs rotated for %s org.\n", org.Body.Name)
+	return nil
+}
+
 func revokeKeypairs(ctx *cli.Context) error {
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/manifoldco/promptui"
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/ui"
+)
+
+func init() {
+	keyrings := cli.Command{
+		Name:     "keyrings",
+		Usage:    "Manage the keyrings secrets are encrypted with",
+		Category: "SECRETS",
+		Subcommands: []cli.Command{
+			{
+				Name:      "rekey",
+				Usage:     "Re-encrypt secrets with new keys, so removed members can no longer decrypt them",
+				ArgsUsage: "[path]",
+				Flags: []cli.Flag{
+					orgFlag("Use this organization.", false),
+					projectFlag("Use this project.", false),
+					cli.BoolFlag{
+						Name:  "force",
+						Usage: "Rekey keyrings even if no member's access has been revoked",
+					},
+				},
+				Action: chain(
+					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+					checkRequiredFlags, rekeyKeyrings,
+				),
+			},
+		},
+	}
+	Cmds = append(Cmds, keyrings)
+}
+
+func rekeyKeyrings(ctx *cli.Context) error {
+	pe, err := orgProjectPathExp(ctx)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	s, p := spinner("Rekeying keyrings")
+	s.Start()
+	rekeyed, err := client.Keyrings.Rekey(c, pe, ctx.Bool("force"), p)
+	s.Stop()
+	if err != nil {
+		return errs.NewErrorExitError("Could not rekey keyrings", err)
+	}
+
+	if len(rekeyed) == 0 {
+		ui.Line("No keyrings in %s need rekeying.", pe)
+		return nil
+	}
+
+	for _, k := range rekeyed {
+		ui.Line("%s %s rekeyed as version %d, with %d secret%s re-encrypted.",
+			promptui.IconGood, k.PathExp, k.KeyringVersion, k.Credentials, plural(k.Credentials))
+	}
+
+	return nil
+}
+
+// orgProjectPathExp returns the PathExp given as the path argument, or one
+// matching everything in the project given by the org and project flags.
+func orgProjectPathExp(ctx *cli.Context) (*pathexp.PathExp, error) {
+	args := ctx.Args()
+	switch len(args) {
+	case 0:
+	case 1:
+		pe, err := parsePathExp(args[0])
+		if err != nil {
+			return nil, errs.NewUsageExitError(err.Error(), ctx)
+		}
+		return pe, nil
+	default:
+		return nil, errs.NewUsageExitError("Too many arguments provided.", ctx)
+	}
+
+	org := ctx.String("org")
+	project := ctx.String("project")
+	if org == "" || project == "" {
+		return nil, errs.NewUsageExitError("A path, or an org and project, must be supplied.", ctx)
+	}
+
+	pe, err := pathexp.New(org, project, []string{"*"}, []string{"*"}, []string{"*"}, []string{"*"})
+	if err != nil {
+		return nil, errs.NewErrorExitError(fmt.Sprintf("Invalid org or project %s/%s", org, project), err)
+	}
+	return pe, nil
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"flag"
+	"testing"
+
+	gm "github.com/onsi/gomega"
+	"github.com/urfave/cli"
+)
+
+func TestOrgProjectPathExp(t *testing.T) {
+	gm.RegisterTestingT(t)
+
+	newCtx := func(org, project string, args ...string) *cli.Context {
+		flagset := flag.NewFlagSet("", flag.ContinueOnError)
+		flagset.String("org", org, "")
+		flagset.String("project", project, "")
+		flagset.Parse(args)
+
+		ctx := cli.NewContext(cli.NewApp(), flagset, nil)
+		ctx.Command = cli.Command{Name: "rekey"}
+		return ctx
+	}
+
+	pe, err := orgProjectPathExp(newCtx("myorg", "api"))
+	gm.Expect(err).ToNot(gm.HaveOccurred())
+	gm.Expect(pe.String()).To(gm.Equal("/myorg/api/*/*/*/*"))
+
+	pe, err = orgProjectPathExp(newCtx("", "", "/myorg/api/production/*/*/*"))
+	gm.Expect(err).ToNot(gm.HaveOccurred())
+	gm.Expect(pe.String()).To(gm.Equal("/myorg/api/production/*/*/*"))
+
+	_, err = orgProjectPathExp(newCtx("myorg", ""))
+	gm.Expect(err).To(gm.HaveOccurred())
+
+	_, err = orgProjectPathExp(newCtx("", "", "/myorg/api/*/*/*/*", "extra"))
+	gm.Expect(err).To(gm.HaveOccurred())
+}
@@ -32,9 +32,13 @@ func init() {
 			envSliceFlag("Use this environment.", false),
 			serviceSliceFlag("Use this service.", "", false),
 			teamSliceFlag("Filter credentials against this team.", false),
+			cli.StringSliceFlag{
+				Name:  "tag",
+				Usage: "Only list secrets with this tag, can be specified multiple times.",
+			},
 			cli.BoolFlag{
 				Name:  "verbose, v",
-				Usage: "Display the full credential path of each secret.",
+				Usage: "Display the full credential path and metadata of each secret.",
 			},
 		},
 		Action: chain(
@@ -49,6 +53,7 @@ type credentialTree map[string]serviceCredentialMap
 
 func listCmd(ctx *cli.Context) error {
 	verbose := ctx.Bool("verbose")
+	tags := ctx.StringSlice("tag")
 
 	args := ctx.Args()
 
@@ -162,8 +167,13 @@ func listCmd(ctx *cli.Context) error {
 	}()
 
 	go func() {
-		// Get credentials
-		credentials, cErr = client.Credentials.Search(c, filterPathExp.String(), teamIDs, nil)
+		// Get credentials. Metadata is encrypted along with the values, so
+		// they must be decrypted to display or filter by it.
+		if verbose || len(tags) > 0 {
+			credentials, cErr = client.Credentials.SearchDecrypted(c, filterPathExp.String(), teamIDs, nil)
+		} else {
+			credentials, cErr = client.Credentials.Search(c, filterPathExp.String(), teamIDs, nil)
+		}
 		getEnvsServicesCreds.Done()
 	}()
 
@@ -252,6 +262,26 @@ func listCmd(ctx *cli.Context) error {
 		}
 	}
 
+	// Filter by tag once secrets redefined in overlapping spaces have been
+	// resolved, so only the secrets which apply are considered.
+	if len(tags) > 0 {
+		for e := range tree {
+			for s, cset := range tree[e] {
+				for name, cred := range cset {
+					if !hasAnyTag((*cred.Body).GetValue(), tags) {
+						delete(cset, name)
+					}
+				}
+				if len(cset) == 0 && !verbose {
+					delete(tree[e], s)
+				}
+			}
+			if len(tree[e]) == 0 && !verbose {
+				delete(tree, e)
+			}
+		}
+	}
+
 	fmt.Println("")
 	w := ansiterm.NewTabWriter(os.Stdout, 0, 0, 0, ' ', 0)
 	for e := range tree {
@@ -268,7 +298,8 @@ func listCmd(ctx *cli.Context) error {
 				credCount++
 				if verbose {
 					credPath := displayPathExp((*cred.Body).GetPathExp()) + "/"
-					fmt.Fprintf(w, "\t\t%s\t (%s)\t\n", c, ui.FaintString(credPath+c))
+					meta := formatMetadata((*cred.Body).GetValue().Metadata())
+					fmt.Fprintf(w, "\t\t%s\t (%s)\t %s\n", c, ui.FaintString(credPath+c), meta)
 				} else {
 					fmt.Fprintf(w, "\t\t%s\t\t\n", c)
 				}
@@ -282,6 +313,20 @@ func listCmd(ctx *cli.Context) error {
 	return nil
 }
 
+// hasAnyTag returns whether the value's metadata includes any of the tags.
+func hasAnyTag(value *apitypes.CredentialValue, tags []string) bool {
+	if value == nil {
+		return false
+	}
+
+	for _, t := range tags {
+		if value.Metadata().HasTag(t) {
+			return true
+		}
+	}
+	return false
+}
+
 func isSecretNameInList(secret string, list []string) bool {
 	for _, s := range list {
 		if s == secret {
@@ -8,7 +8,9 @@ import (
 	"github.com/go-ini/ini"
 	"github.com/urfave/cli"
 
+	"github.com/manifoldco/torus-cli/apitypes"
 	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/pathexp"
 	"github.com/manifoldco/torus-cli/prefs"
 	"github.com/manifoldco/torus-cli/ui"
 )
@@ -77,7 +79,13 @@ func listPref(ctx *cli.Context) error {
 		return errs.NewErrorExitError(loadErr, err)
 	}
 
-	if defaultsCount < 1 && coreCount < 1 {
+	worklogCount := preferences.CountFields("Worklog")
+	err = listSection("[worklog]", worklogCount, &preferences.Worklog)
+	if err != nil {
+		return errs.NewErrorExitError(loadErr, err)
+	}
+
+	if defaultsCount < 1 && coreCount < 1 && worklogCount < 1 {
 		fmt.Println("No preferences set. Use 'torus prefs set' to update.")
 		fmt.Println("")
 	}
@@ -119,6 +127,29 @@ func setPrefByName(key, value string) error {
 		}
 	}
 
+	if key == "worklog.expiry_window" {
+		_, err := parseDays(value)
+		if err != nil {
+			return errs.NewExitError(err.Error())
+		}
+	}
+
+	if key == "worklog.machine_revocations" {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if name != "*" && !pathexp.ValidSlug(name) {
+				return errs.NewExitError("Invalid org name " + name + ": must be an org name or *")
+			}
+		}
+	}
+
+	if key == "worklog.max_secret_age" {
+		_, err := apitypes.ParseSecretAgePolicy(value)
+		if err != nil {
+			return errs.NewExitError(err.Error())
+		}
+	}
+
 	// Set value inside prefs struct
 	result, err := preferences.SetValue(key, value)
 	if err != nil {
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"text/tabwriter"
@@ -36,6 +37,33 @@ func init() {
 					ensureDaemon, ensureSession, setUserEnv, profileEdit,
 				),
 			},
+			{
+				Name:  "password",
+				Usage: "Change your password",
+				Action: chain(
+					ensureDaemon, ensureSession, setUserEnv, profilePassword,
+				),
+			},
+			{
+				Name:  "recovery-kit",
+				Usage: "Manage the recovery kit for your account",
+				Subcommands: []cli.Command{
+					{
+						Name:      "export",
+						Usage:     "Export a recovery kit, protected by a new recovery code",
+						ArgsUsage: "<file>",
+						Action: chain(
+							ensureDaemon, ensureSession, setUserEnv, exportRecoveryKit,
+						),
+					},
+					{
+						Name:      "import",
+						Usage:     "Log in with a recovery kit, and set a new password",
+						ArgsUsage: "<file>",
+						Action:    chain(ensureDaemon, importRecoveryKit),
+					},
+				},
+			},
 		},
 	}
 	Cmds = append(Cmds, profile)
@@ -211,6 +239,189 @@ func profileEdit(ctx *cli.Context) error {
 	return nil
 }
 
+// profilePassword is used to change the password for an account, without
+// logging out.
+func profilePassword(ctx *cli.Context) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	session, err := client.Session.Who(c)
+	if err != nil {
+		return errs.NewErrorExitError("Error fetching user details", err)
+	}
+	if session.Type() == apitypes.MachineSession {
+		return errs.NewExitError("Machines cannot change passwords")
+	}
+
+	oldLabel := "Current Password"
+	currentPassword, err := prompts.Password(false, &oldLabel)
+	if err != nil {
+		return err
+	}
+
+	newLabel := "New Password"
+	newPassword, err := prompts.Password(true, &newLabel)
+	if err != nil {
+		return err
+	}
+
+	s, _ := spinner("Changing password")
+	s.Start()
+	_, err = client.Users.UpdatePassword(c, currentPassword, newPassword)
+	s.Stop()
+	if err != nil {
+		return errs.NewErrorExitError("Failed to change password.", err)
+	}
+
+	fmt.Println("Your password has been changed.")
+	return nil
+}
+
+// exportRecoveryKit writes a recovery kit for the account to a new file, and
+// prints the recovery code which opens it.
+func exportRecoveryKit(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 1 {
+		msg := "A file to write the recovery kit to is required."
+		if len(args) > 1 {
+			msg = "Too many arguments provided."
+		}
+		return errs.NewUsageExitError(msg, ctx)
+	}
+	filename := args[0]
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	session, err := client.Session.Who(c)
+	if err != nil {
+		return errs.NewErrorExitError("Error fetching user details", err)
+	}
+	if session.Type() == apitypes.MachineSession {
+		return errs.NewExitError("Machines cannot export recovery kits")
+	}
+
+	label := "Current Password"
+	password, err := prompts.Password(false, &label)
+	if err != nil {
+		return err
+	}
+
+	// Never overwrite an existing file; it may be an older recovery kit.
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return errs.NewErrorExitError("Could not create recovery kit file.", err)
+	}
+	defer f.Close()
+
+	s, _ := spinner("Exporting recovery kit")
+	s.Start()
+	export, err := client.Users.ExportRecoveryKit(c, password)
+	s.Stop()
+	if err != nil {
+		f.Close()
+		os.Remove(filename)
+		return errs.NewErrorExitError("Failed to export recovery kit.", err)
+	}
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	err = enc.Encode(export.Kit)
+	if err != nil {
+		f.Close()
+		os.Remove(filename)
+		return errs.NewErrorExitError("Could not write recovery kit file.", err)
+	}
+
+	fmt.Printf("Your recovery kit has been written to %s.\n\n", filename)
+	fmt.Printf("Your recovery code is:\n\n    %s\n\n", ui.BoldString(export.Code))
+	fmt.Println("This code will not be shown again. Store it somewhere safe, apart from")
+	fmt.Println("the recovery kit; anyone with both can read your secrets.")
+	return nil
+}
+
+// importRecoveryKit logs in with a recovery kit, restoring the account's master
+// key from it and protecting it with a new password.
+func importRecoveryKit(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 1 {
+		msg := "A recovery kit file is required."
+		if len(args) > 1 {
+			msg = "Too many arguments provided."
+		}
+		return errs.NewUsageExitError(msg, ctx)
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return errs.NewErrorExitError("Could not open recovery kit file.", err)
+	}
+	defer f.Close()
+
+	kit := apitypes.RecoveryKit{}
+	err = json.NewDecoder(f).Decode(&kit)
+	if err != nil {
+		return errs.NewErrorExitError("Could not read recovery kit file.", err)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	_, err = client.Session.Get(c)
+	if err == nil {
+		return errs.NewExitError("You must log out before importing a recovery kit.")
+	}
+	if cerr, ok := err.(*apitypes.Error); !ok || cerr.Type != apitypes.UnauthorizedError {
+		return errs.NewErrorExitError("Could not communicate with daemon.", err)
+	}
+
+	fmt.Printf("Importing the recovery kit for %s.\n\n", kit.Username)
+
+	email, err := prompts.Email("", false)
+	if err != nil {
+		return err
+	}
+
+	code, err := prompts.RecoveryCode("", false)
+	if err != nil {
+		return err
+	}
+
+	newLabel := "New Password"
+	newPassword, err := prompts.Password(true, &newLabel)
+	if err != nil {
+		return err
+	}
+
+	s, _ := spinner("Importing recovery kit")
+	s.Start()
+	_, err = client.Users.ImportRecoveryKit(c, &kit, code, email, newPassword)
+	s.Stop()
+	if err != nil {
+		return errs.NewErrorExitError("Failed to import recovery kit.", err)
+	}
+
+	fmt.Println("Your password has been changed, and you are now logged in.")
+	fmt.Println("\nThis recovery kit can't be used again, as it was made for your old")
+	fmt.Println("password. Run `torus profile recovery-kit export` to create a new one.")
+	return nil
+}
+
 func changePassword(c *context.Context, client *api.Client, session *api.Session) (string, error) {
 	// Retrieve current password value
 	oldLabel := "Current Password"
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/hints"
+	"github.com/manifoldco/torus-cli/prompts"
+	"github.com/manifoldco/torus-cli/ui"
+)
+
+func init() {
+	rollback := cli.Command{
+		Name:      "rollback",
+		Usage:     "Restore a secret to a previous version",
+		ArgsUsage: "<name|path>",
+		Category:  "SECRETS",
+		Flags: append(setUnsetFlags,
+			newPlaceholder("to", "ID|VERSION",
+				"Restore this credential ID or version number, instead of the previous value.", "", "", false),
+			stdAutoAcceptFlag,
+		),
+		Action: chain(
+			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+			setSliceDefaults, rollbackCmd,
+		),
+	}
+
+	Cmds = append(Cmds, rollback)
+}
+
+func rollbackCmd(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 1 {
+		msg := "Name or path is required."
+		if len(args) > 1 {
+			msg = "Too many arguments provided."
+		}
+		return errs.NewUsageExitError(msg, ctx)
+	}
+
+	pe, cname, err := determinePath(ctx, args[0])
+	if err != nil {
+		return errs.NewErrorExitError("Could not roll back credential", err)
+	}
+
+	name := strings.ToLower(*cname)
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	s, p := spinner(fmt.Sprintf("Retrieving history of %s", name))
+	s.Start()
+	versions, err := client.Credentials.History(c, pe.String(), name, true, p)
+	s.Stop()
+	if err != nil {
+		return errs.NewErrorExitError("Could not retrieve history.", err)
+	}
+
+	if len(versions) == 0 {
+		return errs.NewExitError(fmt.Sprintf("Credential %s not found at %s", name, displayPathExp(pe)))
+	}
+
+	target, err := selectRollbackTarget(versions, ctx.String("to"))
+	if err != nil {
+		return errs.NewExitError(err.Error())
+	}
+
+	head := versions[0]
+	current := ui.FaintString("(unset)")
+	if head.Value != nil {
+		current = maskValue(head.Value.String())
+	} else if !head.Unset {
+		current = ui.FaintString("(no access)")
+	}
+
+	fmt.Printf("\nRolling back %s/%s to version %d (%s)\n\n", displayPathExp(pe), name,
+		target.CredentialVersion, target.ID)
+	fmt.Printf("  %s %s\n", ui.ColorString(ui.Red, "-"), current)
+	fmt.Printf("  %s %s\n\n", ui.ColorString(ui.Green, "+"), maskValue(target.Value.String()))
+
+	if !ctx.Bool("yes") {
+		preamble := fmt.Sprintf("You are about to replace the value of %s with version %d.", name, target.CredentialVersion)
+		success, err := prompts.Confirm(nil, &preamble, true, false)
+		if err != nil {
+			return errs.NewErrorExitError("Failed to retrieve confirmation", err)
+		}
+		if !success {
+			return errs.ErrAbort
+		}
+	}
+
+	makers := valueMakers{}
+	makers[name] = func() *apitypes.CredentialValue {
+		return target.Value
+	}
+
+	s, p = spinner(fmt.Sprintf("Attempting to roll back credential %s", name))
+	s.Start()
+	_, err = setCredentials(ctx, pe, makers, p)
+	s.Stop()
+	if err != nil {
+		return errs.NewErrorExitError("Could not roll back credential.", err)
+	}
+
+	fmt.Printf("\nCredential %s has been rolled back to version %d at %s/%s\n",
+		name, target.CredentialVersion, displayPathExp(pe), name)
+
+	hints.Display(hints.View, hints.Run)
+	return nil
+}
+
+// selectRollbackTarget returns the version of a credential to roll back to.
+// versions must be ordered from most to least recent.
+//
+// If to is empty, the most recent set version prior to the head is chosen.
+// Otherwise, to is either a credential ID or a credential version number.
+func selectRollbackTarget(versions []apitypes.CredentialVersion, to string) (*apitypes.CredentialVersion, error) {
+	var target *apitypes.CredentialVersion
+
+	if to == "" {
+		for i := 1; i < len(versions); i++ {
+			if !versions[i].Unset {
+				target = &versions[i]
+				break
+			}
+		}
+		if target == nil {
+			return nil, errors.New("No previous value to roll back to")
+		}
+	} else {
+		n, nErr := strconv.Atoi(to)
+		for i, v := range versions {
+			if (nErr == nil && v.CredentialVersion == n) || (v.ID != nil && v.ID.String() == to) {
+				target = &versions[i]
+				break
+			}
+		}
+		if target == nil {
+			return nil, fmt.Errorf("Version %s not found", to)
+		}
+		if target == &versions[0] {
+			return nil, fmt.Errorf("Version %s is already the current version", to)
+		}
+		if target.Unset {
+			return nil, fmt.Errorf("Version %s was an unset, and has no value to roll back to", to)
+		}
+	}
+
+	if target.Value == nil {
+		return nil, fmt.Errorf("Version %d could not be decrypted; you may no longer have access to it",
+			target.CredentialVersion)
+	}
+
+	return target, nil
+}
+
+// maskValue hides all but the first and last characters of a value. Values
+// shorter than 16 characters are hidden entirely, as revealing even two of
+// their characters gives too much away.
+func maskValue(v string) string {
+	n := utf8.RuneCountInString(v)
+	if n < 16 {
+		return "********"
+	}
+
+	r := []rune(v)
+	return string(r[:1]) + "****" + string(r[n-1:])
+}
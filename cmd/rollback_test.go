@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"testing"
+
+	gm "github.com/onsi/gomega"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/identity"
+)
+
+func rollbackVersionsHelper(t *testing.T) []apitypes.CredentialVersion {
+	var ids []identity.ID
+	for _, raw := range []string{
+		"04100000000000000000000001000",
+		"04100000000000000000000000100",
+		"04100000000000000000000000010",
+		"04100000000000000000000000001",
+	} {
+		id, err := identity.DecodeFromString(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	return []apitypes.CredentialVersion{
+		{ID: &ids[0], CredentialVersion: 4, Value: apitypes.NewStringCredentialValue("bad")},
+		{ID: &ids[1], CredentialVersion: 3, Unset: true},
+		{ID: &ids[2], CredentialVersion: 2, Value: apitypes.NewStringCredentialValue("good")},
+		{ID: &ids[3], CredentialVersion: 1},
+	}
+}
+
+func TestSelectRollbackTarget(t *testing.T) {
+	gm.RegisterTestingT(t)
+	versions := rollbackVersionsHelper(t)
+
+	t.Run("previous value", func(t *testing.T) {
+		target, err := selectRollbackTarget(versions, "")
+		gm.Expect(err).To(gm.BeNil())
+		gm.Expect(target.CredentialVersion).To(gm.Equal(2))
+	})
+
+	t.Run("by version", func(t *testing.T) {
+		target, err := selectRollbackTarget(versions, "2")
+		gm.Expect(err).To(gm.BeNil())
+		gm.Expect(target.Value.String()).To(gm.Equal("good"))
+	})
+
+	t.Run("by id", func(t *testing.T) {
+		target, err := selectRollbackTarget(versions, versions[2].ID.String())
+		gm.Expect(err).To(gm.BeNil())
+		gm.Expect(target.CredentialVersion).To(gm.Equal(2))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for _, to := range []string{"4", "3", "1", "9", "nope"} {
+			_, err := selectRollbackTarget(versions, to)
+			gm.Expect(err).ToNot(gm.BeNil(), to)
+		}
+
+		_, err := selectRollbackTarget(versions[:2], "")
+		gm.Expect(err).ToNot(gm.BeNil())
+	})
+}
+
+func TestMaskValue(t *testing.T) {
+	gm.RegisterTestingT(t)
+
+	gm.Expect(maskValue("")).To(gm.Equal("********"))
+	gm.Expect(maskValue("short")).To(gm.Equal("********"))
+	gm.Expect(maskValue("password")).To(gm.Equal("********"))
+	gm.Expect(maskValue("fifteen-letters")).To(gm.Equal("********"))
+	gm.Expect(maskValue("sixteen-letters!")).To(gm.Equal("s****!"))
+	gm.Expect(maskValue("héllo wörld, 👋 hi")).To(gm.Equal("h****i"))
+}
@@ -1,18 +1,30 @@
 package cmd
 
 import (
+	"context"
 	"os"
 	"os/exec"
 	"os/signal"
+	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/apitypes"
 	"github.com/manifoldco/torus-cli/errs"
 	"github.com/manifoldco/torus-cli/pathexp"
-
-	"github.com/urfave/cli"
+	"github.com/manifoldco/torus-cli/ui"
 )
 
+// stopTimeout is how long a process being restarted by --watch is given to
+// exit before it is killed.
+const stopTimeout = 10 * time.Second
+
+// restartWindow is the period over which --watch-max-restarts is applied.
+const restartWindow = time.Minute
+
 func init() {
 	run := cli.Command{
 		Name:      "run",
@@ -24,6 +36,22 @@ func init() {
 			stdProjectFlag,
 			stdEnvFlag,
 			serviceFlag("Use this service.", "default", true),
+			cli.BoolFlag{
+				Name:  "secrets-dir",
+				Usage: "Also write each secret to a file in a private temporary directory, whose path is given to the process in TORUS_SECRETS_DIR",
+			},
+			cli.BoolFlag{
+				Name:  "watch, w",
+				Usage: "Watch for changes to secrets, restarting or signalling the process when they change",
+			},
+			newPlaceholder("watch-interval", "DURATION",
+				"How often to check for changes to secrets.", "10s", "TORUS_WATCH_INTERVAL", false),
+			newPlaceholder("watch-debounce", "DURATION",
+				"How long secrets must remain unchanged before acting on a change.", "2s", "TORUS_WATCH_DEBOUNCE", false),
+			newPlaceholder("watch-signal", "SIGNAL",
+				"Send this signal (e.g. HUP) instead of restarting the process.", "", "TORUS_WATCH_SIGNAL", false),
+			newPlaceholder("watch-max-restarts", "COUNT",
+				"Maximum number of restarts per minute.", "5", "TORUS_WATCH_MAX_RESTARTS", false),
 		},
 		Action: chain(
 			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
@@ -43,29 +71,41 @@ func runCmd(ctx *cli.Context) error {
 		args = strings.Split(args[0], " ")
 	}
 
+	var opts *watchOptions
+	if ctx.Bool("watch") {
+		var err error
+		opts, err = parseWatchOptions(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
 	secrets, path, err := getSecrets(ctx)
 	if err != nil {
 		return err
 	}
 
-	// Create the command. It gets this processes's stdio.
-	cmd := exec.Command(args[0], args[1:]...)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	cmd.Env = manipulateEnv(path)
+	var dir *secretsDir
+	if ctx.Bool("secrets-dir") {
+		dir, err = newSecretsDir()
+		if err != nil {
+			return errs.NewErrorExitError("Failed to create secrets directory", err)
+		}
+		defer dir.remove()
 
-	// Add the secrets into the env
-	for _, secret := range secrets {
-		value := (*secret.Body).GetValue()
-		key := strings.ToUpper((*secret.Body).GetName())
+		err = dir.write(secrets)
+		if err != nil {
+			return errs.NewErrorExitError("Failed to write secrets", err)
+		}
+	}
 
-		cmd.Env = append(cmd.Env, key+"="+value.String())
+	if opts != nil {
+		return runWatch(ctx, args, path, secrets, dir, opts)
 	}
 
-	err = cmd.Start()
+	cmd, err := startProcess(args, path, secrets, dir)
 	if err != nil {
-		return errs.NewErrorExitError("Failed to run command", err)
+		return err
 	}
 
 	done := make(chan bool)
@@ -84,6 +124,239 @@ func runCmd(ctx *cli.Context) error {
 
 	err = cmd.Wait()
 	close(done)
+	dir.remove()
+	return processExit(err)
+}
+
+// watchOptions holds the configuration for `torus run --watch`.
+type watchOptions struct {
+	interval    time.Duration
+	debounce    time.Duration
+	signal      os.Signal // nil means restart the process
+	maxRestarts int
+}
+
+func parseWatchOptions(ctx *cli.Context) (*watchOptions, error) {
+	opts := &watchOptions{}
+
+	var err error
+	opts.interval, err = time.ParseDuration(ctx.String("watch-interval"))
+	if err != nil || opts.interval <= 0 {
+		return nil, errs.NewUsageExitError("--watch-interval must be a positive duration (e.g. 10s)", ctx)
+	}
+
+	opts.debounce, err = time.ParseDuration(ctx.String("watch-debounce"))
+	if err != nil || opts.debounce < 0 {
+		return nil, errs.NewUsageExitError("--watch-debounce must be a duration (e.g. 2s)", ctx)
+	}
+
+	opts.maxRestarts, err = strconv.Atoi(ctx.String("watch-max-restarts"))
+	if err != nil || opts.maxRestarts < 1 {
+		return nil, errs.NewUsageExitError("--watch-max-restarts must be a positive number", ctx)
+	}
+
+	if name := ctx.String("watch-signal"); name != "" {
+		opts.signal, err = parseSignal(name)
+		if err != nil {
+			return nil, errs.NewUsageExitError(err.Error(), ctx)
+		}
+	}
+
+	return opts, nil
+}
+
+// runWatch runs the given command, polling for changes to its secrets. When a
+// change is seen, and the secrets have settled for the debounce period, the
+// process is either sent the configured signal or restarted with the new
+// secrets in its environment.
+func runWatch(ctx *cli.Context, args []string, path *pathexp.PathExp,
+	secrets []apitypes.CredentialEnvelope, dir *secretsDir, opts *watchOptions) error {
+
+	f, err := newSecretsFetcher(ctx)
+	if err != nil {
+		return err
+	}
+
+	cmd, err := startProcess(args, path, secrets, dir)
+	if err != nil {
+		return err
+	}
+	exited := waitProcess(cmd)
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs) // give us all signals to relay
+	defer signal.Stop(sigs)
+
+	ticker := time.NewTicker(opts.interval)
+	defer ticker.Stop()
+
+	// Secrets are fetched in the background, so signals are still relayed
+	// while the daemon is slow to respond. fetched is nil when no fetch is in
+	// progress.
+	c, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var fetched <-chan fetchResult
+
+	current := secretsEnv(secrets)
+	var pending []apitypes.CredentialEnvelope
+	var settle <-chan time.Time
+	var kill <-chan time.Time
+
+	limiter := newRestartLimiter(opts.maxRestarts, restartWindow)
+	restarting := false
+	stopping := false
+
+	for {
+		select {
+		case s := <-sigs:
+			// A signal received while restarting is meant for us as well as
+			// the process; exit once it has stopped rather than restarting.
+			if restarting {
+				stopping = true
+			}
+			cmd.Process.Signal(s)
+		case err := <-exited:
+			kill = nil
+			if stopping || !restarting {
+				dir.remove()
+				return processExit(err)
+			}
+
+			restarting = false
+			cmd, err = startProcess(args, path, secrets, dir)
+			if err != nil {
+				return err
+			}
+			exited = waitProcess(cmd)
+		case <-kill:
+			kill = nil
+			cmd.Process.Kill()
+		case <-ticker.C:
+			if fetched == nil {
+				fetched = fetchSecrets(c, f)
+			}
+		case r := <-fetched:
+			fetched = nil
+			if r.err != nil {
+				ui.Warn("Could not check for changes to secrets: %s", r.err)
+				continue
+			}
+
+			latest := r.secrets
+			env := secretsEnv(latest)
+			if !envChanged(current, env) {
+				pending = nil
+				settle = nil
+				continue
+			}
+
+			if pending == nil || envChanged(secretsEnv(pending), env) {
+				pending = latest
+				settle = time.After(opts.debounce)
+			}
+		case <-settle:
+			settle = nil
+			if restarting {
+				continue
+			}
+
+			if opts.signal != nil {
+				secrets = pending
+				current = secretsEnv(secrets)
+				pending = nil
+
+				if dir != nil {
+					if err := dir.write(secrets); err != nil {
+						ui.Warn("Could not write secrets to %s: %s", dir.path, err)
+					}
+				}
+
+				ui.Info("Secrets changed; sending %s to process", opts.signal)
+				cmd.Process.Signal(opts.signal)
+				continue
+			}
+
+			if wait := limiter.reserve(time.Now()); wait > 0 {
+				ui.Warn("Secrets changed, but the process has been restarted %d times in the last minute; restarting in %s",
+					opts.maxRestarts, wait.Round(time.Second))
+				settle = time.After(wait)
+				continue
+			}
+
+			secrets = pending
+			current = secretsEnv(secrets)
+			pending = nil
+
+			if dir != nil {
+				if err := dir.write(secrets); err != nil {
+					ui.Warn("Could not write secrets to %s: %s", dir.path, err)
+				}
+			}
+
+			ui.Info("Secrets changed; restarting process")
+			restarting = true
+			stopProcess(cmd.Process)
+			kill = time.After(stopTimeout)
+		}
+	}
+}
+
+// startProcess starts the given command, with the provided secrets in its
+// environment. It gets this process's stdio.
+//
+// If dir is not nil, its location is also added to the environment.
+func startProcess(args []string, path *pathexp.PathExp,
+	secrets []apitypes.CredentialEnvelope, dir *secretsDir) (*exec.Cmd, error) {
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(manipulateEnv(path), secretsEnv(secrets)...)
+	if dir != nil {
+		cmd.Env = append(cmd.Env, secretsDirEnv+"="+dir.path)
+	}
+
+	err := cmd.Start()
+	if err != nil {
+		return nil, errs.NewErrorExitError("Failed to run command", err)
+	}
+
+	return cmd, nil
+}
+
+// waitProcess waits for cmd to exit in the background, sending the result on
+// the returned channel.
+func waitProcess(cmd *exec.Cmd) <-chan error {
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+
+	return exited
+}
+
+// fetchResult holds the outcome of a background fetch of secrets.
+type fetchResult struct {
+	secrets []apitypes.CredentialEnvelope
+	err     error
+}
+
+// fetchSecrets fetches secrets in the background, sending the result on the
+// returned channel.
+func fetchSecrets(c context.Context, f *secretsFetcher) <-chan fetchResult {
+	fetched := make(chan fetchResult, 1)
+	go func() {
+		secrets, err := f.fetch(c, nil)
+		fetched <- fetchResult{secrets: secrets, err: err}
+	}()
+
+	return fetched
+}
+
+// processExit exits with the same status as the child process, if it exited
+// unsuccessfully.
+func processExit(err error) error {
 	if err != nil {
 		if exiterr, ok := err.(*exec.ExitError); ok {
 			if status, ok := exiterr.Sys().(syscall.WaitStatus); ok {
@@ -97,6 +370,77 @@ func runCmd(ctx *cli.Context) error {
 	return nil
 }
 
+// secretsEnv returns the secrets as a list of KEY=value environment
+// variables.
+func secretsEnv(secrets []apitypes.CredentialEnvelope) []string {
+	env := make([]string, 0, len(secrets))
+	for _, secret := range secrets {
+		value := (*secret.Body).GetValue()
+		key := strings.ToUpper((*secret.Body).GetName())
+
+		env = append(env, key+"="+value.String())
+	}
+
+	return env
+}
+
+// envChanged returns whether the two sets of environment variables differ.
+// Both are expected to be in the same (sorted) order.
+func envChanged(a, b []string) bool {
+	if len(a) != len(b) {
+		return true
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseSignal returns the signal with the given name. The SIG prefix is
+// optional, and names are case insensitive.
+func parseSignal(name string) (os.Signal, error) {
+	n := strings.TrimPrefix(strings.ToUpper(name), "SIG")
+	if s, ok := signals[n]; ok {
+		return s, nil
+	}
+
+	return nil, errs.NewExitError("Unknown signal: " + name)
+}
+
+// restartLimiter caps the number of restarts allowed within a sliding window.
+type restartLimiter struct {
+	max      int
+	window   time.Duration
+	restarts []time.Time
+}
+
+func newRestartLimiter(max int, window time.Duration) *restartLimiter {
+	return &restartLimiter{max: max, window: window}
+}
+
+// reserve records a restart at now if one is allowed, returning zero.
+// Otherwise, it returns how long to wait until a restart will be allowed.
+func (r *restartLimiter) reserve(now time.Time) time.Duration {
+	recent := r.restarts[:0]
+	for _, t := range r.restarts {
+		if now.Sub(t) < r.window {
+			recent = append(recent, t)
+		}
+	}
+	r.restarts = recent
+
+	if len(r.restarts) >= r.max {
+		return r.restarts[0].Add(r.window).Sub(now)
+	}
+
+	r.restarts = append(r.restarts, now)
+	return 0
+}
+
 // manipulateEnv removes any sensitive torus environment variables and sets
 // `TORUS_ORG`, `TORUS_PROJECT`, `TORUS_ENVIRONMENT`, and `TORUS_SERVICE` for
 // use by the running process.
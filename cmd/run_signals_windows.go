@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"os"
+	"syscall"
+)
+
+// signals are the signals that may be sent to a process by --watch-signal
+var signals = map[string]os.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"TERM": syscall.SIGTERM,
+}
+
+// stopProcess stops the process so it can be restarted. Windows does not
+// support sending signals to processes, so it is killed outright.
+func stopProcess(p *os.Process) error {
+	return p.Kill()
+}
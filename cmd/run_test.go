@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	gm "github.com/onsi/gomega"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/pathexp"
+)
+
+func TestSecretsEnv(t *testing.T) {
+	gm.RegisterTestingT(t)
+
+	creds, _ := viewCredentialsHelper(t)
+	gm.Expect(secretsEnv(creds)).To(gm.Equal([]string{"FOO=bar", "BAZ=two words"}))
+
+	// Binary values may hold NUL and invalid UTF-8, which can't be placed in
+	// the environment, so they're base64 encoded.
+	creds = exportCredentialsHelper(t, false)
+	for _, kv := range secretsEnv(creds) {
+		gm.Expect(kv).ToNot(gm.ContainSubstring("\x00"))
+		gm.Expect(utf8.ValidString(kv)).To(gm.BeTrue())
+	}
+	gm.Expect(secretsEnv(creds)).To(gm.ContainElement("KEYSTORE=base64:AP/+KGtleSkK"))
+}
+
+func TestSecretsEnvBinary(t *testing.T) {
+	gm.RegisterTestingT(t)
+
+	exp, err := pathexp.Parse("/o/p/e/s/*/*")
+	gm.Expect(err).To(gm.BeNil())
+
+	var cBody apitypes.Credential = &apitypes.CredentialV2{
+		State: "set",
+		BaseCredential: apitypes.BaseCredential{
+			Name:    "cert",
+			PathExp: exp,
+			Value:   apitypes.NewBinaryCredentialValue([]byte{0, 1, 2, 0xff}),
+		},
+	}
+	creds := []apitypes.CredentialEnvelope{{Body: &cBody}}
+
+	// The environment holds binary values base64 encoded with a prefix, while
+	// --secrets-dir writes the same value's raw bytes.
+	gm.Expect(secretsEnv(creds)).To(gm.Equal([]string{"CERT=base64:AAEC/w=="}))
+
+	dir, err := newSecretsDir()
+	gm.Expect(err).To(gm.BeNil())
+	defer dir.remove()
+
+	gm.Expect(dir.write(creds)).To(gm.BeNil())
+
+	b, err := ioutil.ReadFile(filepath.Join(dir.path, "cert"))
+	gm.Expect(err).To(gm.BeNil())
+	gm.Expect(b).To(gm.Equal([]byte{0, 1, 2, 0xff}))
+}
+
+func TestEnvChanged(t *testing.T) {
+	gm.RegisterTestingT(t)
+
+	a := []string{"A=1", "B=2"}
+
+	gm.Expect(envChanged(a, []string{"A=1", "B=2"})).To(gm.BeFalse())
+	gm.Expect(envChanged(a, []string{"A=1", "B=3"})).To(gm.BeTrue())
+	gm.Expect(envChanged(a, []string{"A=1"})).To(gm.BeTrue())
+	gm.Expect(envChanged(nil, []string{})).To(gm.BeFalse())
+}
+
+func TestParseSignal(t *testing.T) {
+	gm.RegisterTestingT(t)
+
+	for _, name := range []string{"HUP", "hup", "SIGHUP", "sighup"} {
+		s, err := parseSignal(name)
+		gm.Expect(err).To(gm.BeNil())
+		gm.Expect(s).To(gm.Equal(signals["HUP"]))
+	}
+
+	_, err := parseSignal("NOTASIGNAL")
+	gm.Expect(err).ToNot(gm.BeNil())
+}
+
+func TestRestartLimiter(t *testing.T) {
+	gm.RegisterTestingT(t)
+
+	now := time.Now()
+	l := newRestartLimiter(2, time.Minute)
+
+	gm.Expect(l.reserve(now)).To(gm.BeZero())
+	gm.Expect(l.reserve(now.Add(10 * time.Second))).To(gm.BeZero())
+	gm.Expect(l.reserve(now.Add(20 * time.Second))).To(gm.Equal(40 * time.Second))
+
+	// The first restart has left the window
+	gm.Expect(l.reserve(now.Add(time.Minute))).To(gm.BeZero())
+	gm.Expect(l.reserve(now.Add(61 * time.Second))).To(gm.Equal(9 * time.Second))
+}
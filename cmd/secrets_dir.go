@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+)
+
+// secretsDirEnv is the environment variable used to tell a process run via
+// `torus run --secrets-dir` where its secrets have been written.
+const secretsDirEnv = "TORUS_SECRETS_DIR"
+
+// secretsDir is a private temporary directory holding one file per secret,
+// for processes that need to read secrets from disk rather than from their
+// environment.
+type secretsDir struct {
+	path string
+}
+
+// newSecretsDir creates a new, empty secrets directory readable only by the
+// current user.
+func newSecretsDir() (*secretsDir, error) {
+	path, err := ioutil.TempDir("", "torus-secrets-")
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.Chmod(path, 0700)
+	if err != nil {
+		os.RemoveAll(path)
+		return nil, err
+	}
+
+	return &secretsDir{path: path}, nil
+}
+
+// write writes each secret into a file named after the secret, readable only
+// by the current user. Files for secrets which no longer exist are removed.
+// Binary secrets are written as their raw bytes.
+//
+// Each file is replaced atomically, so a process reading from the directory
+// will never see a partially written value.
+func (d *secretsDir) write(secrets []apitypes.CredentialEnvelope) error {
+	names := make(map[string]bool)
+	for _, secret := range secrets {
+		name := (*secret.Body).GetName()
+		value := (*secret.Body).GetValue()
+		names[name] = true
+
+		data := []byte(value.String())
+		if value.IsBinary() {
+			raw, err := value.Raw()
+			if err != nil {
+				return err
+			}
+			data = raw.([]byte)
+		}
+
+		err := writeFileAtomic(filepath.Join(d.path, name), data, 0600)
+		if err != nil {
+			return err
+		}
+	}
+
+	files, err := ioutil.ReadDir(d.path)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		if !names[f.Name()] {
+			err = os.Remove(filepath.Join(d.path, f.Name()))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// remove deletes the directory and all of the secrets within it. It is safe
+// to call on a nil secretsDir, and to call more than once.
+func (d *secretsDir) remove() error {
+	if d == nil {
+		return nil
+	}
+
+	return os.RemoveAll(d.path)
+}
+
+// writeFileAtomic writes data to a temporary file in the same directory as
+// filename, and then renames it into place.
+func writeFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	f, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename)+".")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmp, filename)
+	}
+
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	gm "github.com/onsi/gomega"
+)
+
+func TestSecretsDir(t *testing.T) {
+	gm.RegisterTestingT(t)
+
+	creds, _ := viewCredentialsHelper(t)
+
+	dir, err := newSecretsDir()
+	gm.Expect(err).To(gm.BeNil())
+	defer dir.remove()
+
+	// A file for a secret that doesn't exist should be removed
+	stale := filepath.Join(dir.path, "stale")
+	err = ioutil.WriteFile(stale, []byte("old"), 0600)
+	gm.Expect(err).To(gm.BeNil())
+
+	err = dir.write(creds)
+	gm.Expect(err).To(gm.BeNil())
+
+	files, err := ioutil.ReadDir(dir.path)
+	gm.Expect(err).To(gm.BeNil())
+	gm.Expect(files).To(gm.HaveLen(2))
+
+	for name, value := range map[string]string{"foo": "bar", "baz": "two words"} {
+		path := filepath.Join(dir.path, name)
+		b, err := ioutil.ReadFile(path)
+		gm.Expect(err).To(gm.BeNil())
+		gm.Expect(string(b)).To(gm.Equal(value))
+
+		if runtime.GOOS != "windows" {
+			fi, err := os.Stat(path)
+			gm.Expect(err).To(gm.BeNil())
+			gm.Expect(fi.Mode().Perm()).To(gm.Equal(os.FileMode(0600)))
+		}
+	}
+
+	// Binary secrets are written as their raw bytes
+	err = dir.write(exportCredentialsHelper(t, false))
+	gm.Expect(err).To(gm.BeNil())
+
+	b, err := ioutil.ReadFile(filepath.Join(dir.path, "keystore"))
+	gm.Expect(err).To(gm.BeNil())
+	gm.Expect(b).To(gm.Equal([]byte("\x00\xff\xfe(key)\n")))
+
+	gm.Expect(dir.remove()).To(gm.BeNil())
+	_, err = os.Stat(dir.path)
+	gm.Expect(os.IsNotExist(err)).To(gm.BeTrue())
+
+	// Removing twice, or a nil dir, is a no-op
+	gm.Expect(dir.remove()).To(gm.BeNil())
+	gm.Expect((*secretsDir)(nil).remove()).To(gm.BeNil())
+}
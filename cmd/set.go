@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
 	"strings"
 
 	"github.com/urfave/cli"
@@ -29,9 +32,17 @@ func init() {
 	set := cli.Command{
 		Name:      "set",
 		Usage:     "Set a secret for a service and environment",
-		ArgsUsage: "<name|path> <value> or <name|path>=<value>",
+		ArgsUsage: "<name|path> <value> or <name|path>=<value> or --generate <generator> <name|path> or --file <file> <name|path>",
 		Category:  "SECRETS",
-		Flags:     setUnsetFlags,
+		Flags: append(append(setUnsetFlags, generateFlag,
+			newPlaceholder("file, f", "FILE",
+				"Set the value to the contents of FILE, stored as binary. Use - to read from stdin.",
+				"", "", false),
+			cli.BoolFlag{
+				Name:  "reference, r",
+				Usage: "Interpolate ${NAME} and ${/path/NAME} references to other secrets in the value when it is read",
+			},
+		), metadataFlags...),
 		Action: chain(
 			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
 			setSliceDefaults, setCmd,
@@ -43,12 +54,36 @@ func init() {
 
 func setCmd(ctx *cli.Context) error {
 	args := ctx.Args()
-	key, value, err := parseSetArgs(args)
+
+	var g *secretGenerator
+	var key, value string
+	var err error
+	spec := ctx.String("generate")
+	file := ctx.String("file")
+	if spec != "" && file != "" {
+		err = errors.New("Only one of --generate or --file may be used")
+	} else if ctx.Bool("reference") && (spec != "" || file != "") {
+		err = errors.New("--reference cannot be used with --generate or --file")
+	} else if spec != "" {
+		g, err = parseGenerator(spec)
+		if err == nil {
+			key, err = parseNameArgs(args)
+		}
+	} else if file != "" {
+		key, err = parseNameArgs(args)
+	} else {
+		key, value, err = parseSetArgs(args)
+	}
 
 	if err != nil {
 		return errs.NewUsageExitError(err.Error(), ctx)
 	}
 
+	edit := metadataEditFromFlags(ctx)
+	if err := edit.Validate(); err != nil {
+		return errs.NewUsageExitError(err.Error(), ctx)
+	}
+
 	path, cname, err := determinePath(ctx, key)
 	if err != nil {
 		return err
@@ -60,19 +95,69 @@ func setCmd(ctx *cli.Context) error {
 	}
 
 	makers := valueMakers{}
-	makers[name] = func() *apitypes.CredentialValue {
-		return apitypes.NewStringCredentialValue(value)
+	if file != "" {
+		b, err := readFileValue(file, os.Stdin)
+		if err != nil {
+			return errs.NewErrorExitError("Could not read value.", err)
+		}
+
+		makers[name] = func() *apitypes.CredentialValue {
+			return apitypes.NewBinaryCredentialValue(b)
+		}
+	} else if g != nil {
+		makers, err = g.ValueMakers(name)
+		if err != nil {
+			return errs.NewErrorExitError("Could not generate value.", err)
+		}
+	} else if ctx.Bool("reference") {
+		cv := apitypes.NewReferenceCredentialValue(value)
+		if _, err := cv.References(); err != nil {
+			return errs.NewUsageExitError(err.Error(), ctx)
+		}
+
+		makers[name] = func() *apitypes.CredentialValue {
+			return cv
+		}
+	} else {
+		makers[name] = func() *apitypes.CredentialValue {
+			return apitypes.NewStringCredentialValue(value)
+		}
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	if edit.owner != nil && *edit.owner != "" {
+		err = checkOwner(c, client, path.Org.String(), *edit.owner)
+		if err != nil {
+			return err
+		}
 	}
 
 	s, p := spinner(fmt.Sprintf("Attempting to set credential %s", name))
 	s.Start()
-	_, err = setCredentials(ctx, path, makers, p)
+	err = describeValueMaker(c, client, path, name, makers, edit)
+	if err != nil {
+		s.Stop()
+		return errs.NewErrorExitError("Could not retrieve the existing credential.", err)
+	}
+
+	creds, err := setCredentials(ctx, path, makers, p)
 	s.Stop()
 	if err != nil {
 		return errs.NewErrorExitError("Could not set credential.", err)
 	}
 
-	fmt.Printf("\nCredential %s has been set at %s/%s\n", name, displayPathExp(path), name)
+	fmt.Println()
+	for _, cred := range creds {
+		cname := (*cred.Body).GetName()
+		fmt.Printf("Credential %s has been set at %s/%s\n", cname, displayPathExp(path), cname)
+	}
 
 	hints.Display(hints.View, hints.Run, hints.Unset, hints.Import, hints.Export)
 	return nil
@@ -101,6 +186,57 @@ func parseSetArgs(args []string) (key string, value string, err error) {
 	return key, value, nil
 }
 
+// parseNameArgs returns the key of a secret whose value is generated or read
+// from a file, rather than supplied as an argument.
+func parseNameArgs(args []string) (string, error) {
+	if len(args) < 1 {
+		return "", errors.New("A secret name must be supplied")
+	} else if len(args) > 1 {
+		return "", errors.New("A value cannot be supplied with --generate or --file")
+	}
+
+	key := strings.ToLower(args[0])
+	if key == "" {
+		return "", errors.New("A secret must have a name")
+	}
+
+	return key, nil
+}
+
+// maxFileValueSize is the largest file which can be stored as a secret.
+const maxFileValueSize = 64 * 1024
+
+// readFileValue returns the contents of the named file, or of stdin if the
+// name is -. Files larger than maxFileValueSize are rejected.
+func readFileValue(name string, stdin io.Reader) ([]byte, error) {
+	r := stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	} else {
+		name = "stdin"
+	}
+
+	b, err := ioutil.ReadAll(io.LimitReader(r, maxFileValueSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b) > maxFileValueSize {
+		return nil, fmt.Errorf("%s is larger than the maximum secret size of %d KiB",
+			name, maxFileValueSize/1024)
+	}
+	if len(b) == 0 {
+		return nil, fmt.Errorf("%s is empty", name)
+	}
+
+	return b, nil
+}
+
 // determinePath returns a PathExp and a possible credential name if a full
 // path was provided.
 func determinePath(ctx *cli.Context, path string) (*pathexp.PathExp, *string, error) {
@@ -1,6 +1,11 @@
 package cmd
 
 import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -51,3 +56,58 @@ func TestParseSetArgs(t *testing.T) {
 		}
 	}
 }
+
+func TestReadFileValue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "torus-set")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "tls.key")
+	contents := []byte{0, 1, 2, 0xff}
+	if err := ioutil.WriteFile(name, contents, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("file", func(t *testing.T) {
+		b, err := readFileValue(name, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !bytes.Equal(b, contents) {
+			t.Errorf("expected %v, got %v", contents, b)
+		}
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		b, err := readFileValue("-", strings.NewReader("from stdin"))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if string(b) != "from stdin" {
+			t.Errorf("expected %q, got %q", "from stdin", b)
+		}
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		_, err := readFileValue("-", bytes.NewReader(make([]byte, maxFileValueSize+1)))
+		if err == nil || err.Error() != "stdin is larger than the maximum secret size of 64 KiB" {
+			t.Errorf("expected size error, got %v", err)
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		_, err := readFileValue("-", strings.NewReader(""))
+		if err == nil {
+			t.Error("expected an error for an empty value")
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		_, err := readFileValue(filepath.Join(dir, "missing"), nil)
+		if err == nil {
+			t.Error("expected an error for a missing file")
+		}
+	})
+}
@@ -0,0 +1,341 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/ui"
+)
+
+func init() {
+	tmpl := cli.Command{
+		Name:      "template",
+		Usage:     "Render templates using the secrets for a specific environment and service",
+		ArgsUsage: "<template>[:<output>[:<mode>]]...",
+		Category:  "SECRETS",
+		Flags: []cli.Flag{
+			stdOrgFlag,
+			stdProjectFlag,
+			stdEnvFlag,
+			serviceFlag("Use this service.", "default", true),
+			newPlaceholder("mode, m", "MODE",
+				"File mode for rendered outputs, unless given for a template.", "0644", "TORUS_TEMPLATE_MODE", false),
+			cli.BoolFlag{
+				Name:  "watch, w",
+				Usage: "Watch for changes to secrets, re-rendering the templates when they change",
+			},
+			newPlaceholder("watch-interval", "DURATION",
+				"How often to check for changes to secrets.", "10s", "TORUS_WATCH_INTERVAL", false),
+			newPlaceholder("exec", "COMMAND",
+				"Run this shell command after templates are re-rendered with changes.", "", "TORUS_TEMPLATE_EXEC", false),
+		},
+		Action: chain(
+			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+			setUserEnv, checkRequiredFlags, templateCmd,
+		),
+	}
+
+	Cmds = append(Cmds, tmpl)
+}
+
+// templateSpec describes a template to render, and where to write it. An
+// empty output means the template is rendered to stdout.
+type templateSpec struct {
+	source string
+	output string
+	mode   os.FileMode
+}
+
+// templateData is the data made available to templates.
+type templateData struct {
+	Org         string
+	Project     string
+	Environment string
+	Service     string
+	Secrets     map[string]string
+}
+
+func templateCmd(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) == 0 {
+		return errs.NewUsageExitError("At least one template is required", ctx)
+	}
+
+	mode, err := parseFileMode(ctx.String("mode"))
+	if err != nil {
+		return errs.NewUsageExitError("Invalid file mode: "+ctx.String("mode"), ctx)
+	}
+
+	specs := make([]templateSpec, len(args))
+	for i, arg := range args {
+		specs[i], err = parseTemplateSpec(arg, mode)
+		if err != nil {
+			return errs.NewUsageExitError(err.Error(), ctx)
+		}
+	}
+
+	var interval time.Duration
+	if ctx.Bool("watch") {
+		interval, err = time.ParseDuration(ctx.String("watch-interval"))
+		if err != nil || interval <= 0 {
+			return errs.NewUsageExitError("--watch-interval must be a positive duration (e.g. 10s)", ctx)
+		}
+	}
+
+	command := ctx.String("exec")
+
+	secrets, path, err := getSecrets(ctx)
+	if err != nil {
+		return err
+	}
+
+	data := newTemplateData(ctx, secrets)
+	_, err = renderTemplates(specs, data)
+	if err != nil {
+		return err
+	}
+
+	if !ctx.Bool("watch") {
+		return nil
+	}
+
+	f, err := newSecretsFetcher(ctx)
+	if err != nil {
+		return err
+	}
+
+	current := secretsEnv(secrets)
+	for range time.Tick(interval) {
+		latest, err := f.fetch(context.Background(), nil)
+		if err != nil {
+			ui.Warn("Could not check for changes to secrets: %s", err)
+			continue
+		}
+
+		env := secretsEnv(latest)
+		if !envChanged(current, env) {
+			continue
+		}
+		current = env
+
+		changed, err := renderTemplates(specs, newTemplateData(ctx, latest))
+		if err != nil {
+			ui.Warn("Could not render templates: %s", err)
+			continue
+		}
+
+		if !changed || command == "" {
+			continue
+		}
+
+		cmd := shellCommand(command)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		cmd.Env = manipulateEnv(path)
+
+		err = cmd.Run()
+		if err != nil {
+			ui.Warn("Command %q failed: %s", command, err)
+		}
+	}
+
+	return nil
+}
+
+// parseTemplateSpec parses a template argument of the form
+// <template>[:<output>[:<mode>]].
+//
+// The template ends at its first colon, other than one following a Windows
+// drive letter. The output may contain colons; a final colon is only taken to
+// start a mode if it is followed by digits alone.
+func parseTemplateSpec(arg string, mode os.FileMode) (templateSpec, error) {
+	i := strings.Index(arg[driveLen(arg):], ":")
+	if i == -1 {
+		i = len(arg)
+	} else {
+		i += driveLen(arg)
+	}
+	if i == 0 {
+		return templateSpec{}, fmt.Errorf("Invalid template: %s", arg)
+	}
+
+	spec := templateSpec{source: arg[:i], mode: mode}
+	if i == len(arg) {
+		return spec, nil
+	}
+	spec.output = arg[i+1:]
+
+	j := strings.LastIndex(spec.output, ":")
+	if j == -1 || j < driveLen(spec.output) || !isDigits(spec.output[j+1:]) {
+		return spec, nil
+	}
+
+	rawMode := spec.output[j+1:]
+	spec.output = spec.output[:j]
+	if spec.output == "" {
+		return templateSpec{}, fmt.Errorf("A mode requires an output file: %s", arg)
+	}
+
+	var err error
+	spec.mode, err = parseFileMode(rawMode)
+	if err != nil {
+		return templateSpec{}, fmt.Errorf("Invalid file mode for %s: %s", spec.source, rawMode)
+	}
+
+	return spec, nil
+}
+
+// driveLen returns the length of the Windows drive letter and colon at the
+// start of path, such as C: in C:\config.tmpl, or 0 if there is none.
+func driveLen(path string) int {
+	if len(path) > 2 && path[1] == ':' && (path[2] == '\\' || path[2] == '/') &&
+		('a' <= path[0] && path[0] <= 'z' || 'A' <= path[0] && path[0] <= 'Z') {
+		return 2
+	}
+
+	return 0
+}
+
+func isDigits(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+// shellCommand returns a command which runs the given command line in the
+// system shell.
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+
+	return exec.Command("/bin/sh", "-c", command)
+}
+
+func parseFileMode(s string) (os.FileMode, error) {
+	m, err := strconv.ParseUint(s, 8, 32)
+	if err != nil {
+		return 0, err
+	}
+	if m > 0777 {
+		return 0, fmt.Errorf("mode out of range: %s", s)
+	}
+
+	return os.FileMode(m), nil
+}
+
+func newTemplateData(ctx *cli.Context, secrets []apitypes.CredentialEnvelope) *templateData {
+	data := &templateData{
+		Org:         ctx.String("org"),
+		Project:     ctx.String("project"),
+		Environment: ctx.String("environment"),
+		Service:     ctx.String("service"),
+		Secrets:     make(map[string]string),
+	}
+
+	for _, secret := range secrets {
+		data.Secrets[(*secret.Body).GetName()] = (*secret.Body).GetValue().String()
+	}
+
+	return data
+}
+
+// templateFuncs returns the helper functions available to templates.
+func templateFuncs(data *templateData) template.FuncMap {
+	return template.FuncMap{
+		"secret": func(name string) (string, error) {
+			v, ok := data.Secrets[strings.ToLower(name)]
+			if !ok {
+				return "", fmt.Errorf("secret %q not found", name)
+			}
+			return v, nil
+		},
+		"quote": strconv.Quote,
+		"base64": func(s string) string {
+			return base64.StdEncoding.EncodeToString([]byte(s))
+		},
+		"base64Decode": func(s string) (string, error) {
+			b, err := base64.StdEncoding.DecodeString(s)
+			return string(b), err
+		},
+		"default": func(def string, v interface{}) string {
+			if s, ok := v.(string); ok && s != "" {
+				return s
+			}
+			return def
+		},
+	}
+}
+
+// renderTemplate renders the template found at path with the given data.
+func renderTemplate(path string, data *templateData) ([]byte, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := template.New(filepath.Base(path)).Funcs(templateFuncs(data)).Parse(string(b))
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	err = t.Execute(buf, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// renderTemplates renders each template, writing the results once all have
+// rendered successfully. Outputs are replaced atomically, and only if their
+// contents have changed.
+//
+// It returns whether any output was changed.
+func renderTemplates(specs []templateSpec, data *templateData) (bool, error) {
+	outputs := make([][]byte, len(specs))
+	for i, spec := range specs {
+		out, err := renderTemplate(spec.source, data)
+		if err != nil {
+			return false, errs.NewErrorExitError("Could not render template "+spec.source, err)
+		}
+		outputs[i] = out
+	}
+
+	changed := false
+	for i, spec := range specs {
+		if spec.output == "" {
+			os.Stdout.Write(outputs[i])
+			changed = true
+			continue
+		}
+
+		existing, err := ioutil.ReadFile(spec.output)
+		if err == nil && bytes.Equal(existing, outputs[i]) {
+			continue
+		}
+
+		err = writeFileAtomic(spec.output, outputs[i], spec.mode)
+		if err != nil {
+			return changed, errs.NewErrorExitError("Could not write to "+spec.output, err)
+		}
+		changed = true
+	}
+
+	return changed, nil
+}
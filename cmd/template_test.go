@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	gm "github.com/onsi/gomega"
+)
+
+func TestParseTemplateSpec(t *testing.T) {
+	gm.RegisterTestingT(t)
+
+	spec, err := parseTemplateSpec("in.tmpl", 0644)
+	gm.Expect(err).To(gm.BeNil())
+	gm.Expect(spec).To(gm.Equal(templateSpec{source: "in.tmpl", mode: 0644}))
+
+	spec, err = parseTemplateSpec("in.tmpl:out.conf", 0644)
+	gm.Expect(err).To(gm.BeNil())
+	gm.Expect(spec).To(gm.Equal(templateSpec{source: "in.tmpl", output: "out.conf", mode: 0644}))
+
+	spec, err = parseTemplateSpec("in.tmpl:out.conf:0600", 0644)
+	gm.Expect(err).To(gm.BeNil())
+	gm.Expect(spec).To(gm.Equal(templateSpec{source: "in.tmpl", output: "out.conf", mode: 0600}))
+
+	spec, err = parseTemplateSpec(`C:\torus\in.tmpl:D:\out\app.conf:0600`, 0644)
+	gm.Expect(err).To(gm.BeNil())
+	gm.Expect(spec).To(gm.Equal(templateSpec{source: `C:\torus\in.tmpl`, output: `D:\out\app.conf`, mode: 0600}))
+
+	spec, err = parseTemplateSpec("in.tmpl:/run/app:8080/out.conf", 0644)
+	gm.Expect(err).To(gm.BeNil())
+	gm.Expect(spec).To(gm.Equal(templateSpec{source: "in.tmpl", output: "/run/app:8080/out.conf", mode: 0644}))
+
+	spec, err = parseTemplateSpec("in.tmpl:D:/out.conf", 0644)
+	gm.Expect(err).To(gm.BeNil())
+	gm.Expect(spec).To(gm.Equal(templateSpec{source: "in.tmpl", output: "D:/out.conf", mode: 0644}))
+
+	for _, arg := range []string{"", ":out", "in::0600", "in:out:999", "in:out:0800"} {
+		_, err = parseTemplateSpec(arg, 0644)
+		gm.Expect(err).ToNot(gm.BeNil(), arg)
+	}
+}
+
+func TestRenderTemplates(t *testing.T) {
+	gm.RegisterTestingT(t)
+
+	dir, err := ioutil.TempDir("", "torus-template-test")
+	gm.Expect(err).To(gm.BeNil())
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "in.tmpl")
+	out := filepath.Join(dir, "out.conf")
+	tmpl := `env={{ .Environment }}
+foo={{ secret "FOO" }}
+baz={{ quote .Secrets.baz }}
+enc={{ base64 .Secrets.foo }}
+dec={{ base64Decode "YmFy" }}
+port={{ .Secrets.port | default "8080" }}
+`
+	err = ioutil.WriteFile(src, []byte(tmpl), 0644)
+	gm.Expect(err).To(gm.BeNil())
+
+	data := &templateData{
+		Environment: "production",
+		Secrets:     map[string]string{"foo": "bar", "baz": "two words"},
+	}
+	specs := []templateSpec{{source: src, output: out, mode: 0600}}
+
+	changed, err := renderTemplates(specs, data)
+	gm.Expect(err).To(gm.BeNil())
+	gm.Expect(changed).To(gm.BeTrue())
+
+	b, err := ioutil.ReadFile(out)
+	gm.Expect(err).To(gm.BeNil())
+	gm.Expect(string(b)).To(gm.Equal(`env=production
+foo=bar
+baz="two words"
+enc=YmFy
+dec=bar
+port=8080
+`))
+
+	// Rendering the same values again leaves the output untouched
+	changed, err = renderTemplates(specs, data)
+	gm.Expect(err).To(gm.BeNil())
+	gm.Expect(changed).To(gm.BeFalse())
+
+	// Referencing a missing secret is an error, and nothing is written
+	err = ioutil.WriteFile(src, []byte(`{{ secret "missing" }}`), 0644)
+	gm.Expect(err).To(gm.BeNil())
+
+	_, err = renderTemplates(specs, data)
+	gm.Expect(err).ToNot(gm.BeNil())
+
+	b, err = ioutil.ReadFile(out)
+	gm.Expect(err).To(gm.BeNil())
+	gm.Expect(string(b)).To(gm.ContainSubstring("foo=bar"))
+}
+
+func TestShellCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sh is not available on windows")
+	}
+	gm.RegisterTestingT(t)
+
+	out, err := shellCommand(`printf '%s|%s' "two words" three`).Output()
+	gm.Expect(err).To(gm.BeNil())
+	gm.Expect(string(out)).To(gm.Equal("two words|three"))
+}
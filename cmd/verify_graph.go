@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/ui"
+)
+
+func init() {
+	verifyGraph := cli.Command{
+		Name:      "verify-graph",
+		Usage:     "Verify the signatures and claims of secrets, keyrings, and keys, without trusting the registry",
+		ArgsUsage: "[path]",
+		Category:  "SECRETS",
+		Flags: []cli.Flag{
+			orgFlag("Use this organization.", false),
+			projectFlag("Use this project.", false),
+			formatFlag("text", "Format used to display the results (text, json)"),
+		},
+		Action: chain(
+			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+			checkRequiredFlags, verifyGraphCmd,
+		),
+	}
+
+	Cmds = append(Cmds, verifyGraph)
+}
+
+func verifyGraphCmd(ctx *cli.Context) error {
+	format := ctx.String("format")
+	if format != "text" && format != "json" {
+		return errs.NewUsageExitError(fmt.Sprintf("Invalid format provided: %s", format), ctx)
+	}
+
+	pe, err := orgProjectPathExp(ctx)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	s, p := spinner("Verifying credential graph")
+	s.Start()
+	result, err := client.Graph.Verify(c, pe, p)
+	s.Stop()
+	if err != nil {
+		return errs.NewErrorExitError("Could not verify credential graph", err)
+	}
+
+	return writeGraphVerifyResult(os.Stdout, result, format)
+}
+
+// writeGraphVerifyResult writes the result in the given format. If any
+// objects failed verification, an error with a non-zero exit code is
+// returned. Warnings don't affect the exit code.
+func writeGraphVerifyResult(w io.Writer, result *apitypes.GraphVerifyResult, format string) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		err := enc.Encode(result)
+		if err != nil {
+			return errs.NewErrorExitError("Could not marshal to json", err)
+		}
+	} else {
+		writeGraphVerifyResultText(w, result)
+	}
+
+	if result.Failed() {
+		return cli.NewExitError("", 1)
+	}
+
+	return nil
+}
+
+func writeGraphVerifyResultText(w io.Writer, result *apitypes.GraphVerifyResult) {
+	if len(result.Issues) == 0 {
+		fmt.Fprintf(w, "All %d object%s verified.\n", result.Verified, plural(result.Verified))
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 2, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", ui.BoldString("PROBLEM"), ui.BoldString("TYPE"),
+		ui.BoldString("ID"), ui.BoldString("DETAIL"))
+	warnings := 0
+	for _, issue := range result.Issues {
+		detail := issue.Detail
+		if issue.Path != "" {
+			detail = fmt.Sprintf("%s %s", ui.FaintString(issue.Path), detail)
+		}
+
+		color := ui.Red
+		if issue.Warning {
+			color = ui.Yellow
+			warnings++
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", ui.ColorString(color, string(issue.Problem)),
+			issue.Type, issue.ID, detail)
+	}
+	tw.Flush()
+
+	problems := len(result.Issues) - warnings
+	fmt.Fprintf(w, "\n%d problem%s", problems, plural(problems))
+	if warnings > 0 {
+		fmt.Fprintf(w, " and %d warning%s", warnings, plural(warnings))
+	}
+	fmt.Fprintf(w, " found in %d object%s verified.\n", result.Verified, plural(result.Verified))
+}
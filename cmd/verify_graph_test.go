@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	gm "github.com/onsi/gomega"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/prefs"
+	"github.com/manifoldco/torus-cli/ui"
+)
+
+func TestWriteGraphVerifyResult(t *testing.T) {
+	ui.Init(&prefs.Preferences{})
+
+	t.Run("verified", func(t *testing.T) {
+		gm.RegisterTestingT(t)
+
+		buf := &bytes.Buffer{}
+		err := writeGraphVerifyResult(buf, &apitypes.GraphVerifyResult{Verified: 12}, "text")
+		gm.Expect(err).To(gm.BeNil())
+		gm.Expect(buf.String()).To(gm.Equal("All 12 objects verified.\n"))
+	})
+
+	id, err := identity.DecodeFromString("04100000000000000000000000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := &apitypes.GraphVerifyResult{
+		Verified: 1,
+		Issues: []apitypes.GraphIssue{{
+			ID:      &id,
+			Type:    "credential",
+			Path:    "/myorg/api/production/default/*/password",
+			Problem: apitypes.InvalidSignatureProblem,
+			Detail:  "signature does not match",
+		}},
+	}
+
+	t.Run("issues", func(t *testing.T) {
+		gm.RegisterTestingT(t)
+
+		buf := &bytes.Buffer{}
+		err := writeGraphVerifyResult(buf, result, "text")
+		gm.Expect(err).ToNot(gm.BeNil())
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		gm.Expect(lines).To(gm.HaveLen(4))
+		gm.Expect(strings.Fields(lines[1])[:3]).To(gm.Equal([]string{
+			"invalid-signature", "credential", id.String(),
+		}))
+		gm.Expect(lines[1]).To(gm.ContainSubstring("/myorg/api/production/default/*/password"))
+		gm.Expect(lines[3]).To(gm.Equal("1 problem found in 1 object verified."))
+	})
+
+	t.Run("warnings", func(t *testing.T) {
+		gm.RegisterTestingT(t)
+
+		warned := &apitypes.GraphVerifyResult{
+			Verified: 1,
+			Issues: []apitypes.GraphIssue{{
+				ID:      &id,
+				Type:    "credential",
+				Path:    "/myorg/api/production/default/*/password",
+				Problem: apitypes.RevokedSignerProblem,
+				Detail:  "signed by a revoked key",
+				Warning: true,
+			}},
+		}
+
+		buf := &bytes.Buffer{}
+		err := writeGraphVerifyResult(buf, warned, "text")
+		gm.Expect(err).To(gm.BeNil())
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		gm.Expect(lines[len(lines)-1]).To(gm.Equal("0 problems and 1 warning found in 1 object verified."))
+	})
+
+	t.Run("json", func(t *testing.T) {
+		gm.RegisterTestingT(t)
+
+		buf := &bytes.Buffer{}
+		err := writeGraphVerifyResult(buf, result, "json")
+		gm.Expect(err).ToNot(gm.BeNil())
+
+		out := &apitypes.GraphVerifyResult{}
+		gm.Expect(json.Unmarshal(buf.Bytes(), out)).To(gm.BeNil())
+		gm.Expect(out).To(gm.Equal(result))
+	})
+}
@@ -30,7 +30,7 @@ func init() {
 			serviceFlag("Use this service.", "default", true),
 			cli.BoolFlag{
 				Name:  "verbose, v",
-				Usage: "Lists the sources of the secrets (shortcut for --format verbose)",
+				Usage: "Lists the sources and metadata of the secrets, and the unresolved values of references (shortcut for --format verbose)",
 			},
 		},
 		Action: chain(
@@ -56,18 +56,24 @@ func viewCmd(ctx *cli.Context) error {
 
 	tw := ansiterm.NewTabWriter(w, 2, 0, 2, ' ', 0)
 	for _, secret := range secrets {
-		value := (*secret.Body).GetValue().String()
+		cv := (*secret.Body).GetValue()
+		value := cv.String()
 		name := (*secret.Body).GetName()
 		spath := displayPathExp((*secret.Body).GetPathExp()) + "/" + name
+		if ref := (*secret.Body).GetReference(); ref != "" {
+			spath += ", from " + ref
+		}
+		meta := formatMetadata(cv.Metadata())
 
+		quote := strings.Contains(value, " ")
 		if verbose {
-			if strings.Contains(value, " ") {
-				fmt.Fprintf(tw, "%s\t=\t%q\t(%s)\n", ui.BoldString(name), value, ui.FaintString(spath))
+			if quote {
+				fmt.Fprintf(tw, "%s\t=\t%q\t(%s)\t%s\n", ui.BoldString(name), value, ui.FaintString(spath), meta)
 			} else {
-				fmt.Fprintf(tw, "%s\t=\t%s\t(%s)\n", ui.BoldString(name), value, ui.FaintString(spath))
+				fmt.Fprintf(tw, "%s\t=\t%s\t(%s)\t%s\n", ui.BoldString(name), value, ui.FaintString(spath), meta)
 			}
 		} else {
-			if strings.Contains(value, " ") {
+			if quote {
 				fmt.Fprintf(tw, "%s\t=\t%q\n", ui.BoldString(name), value)
 			} else {
 				fmt.Fprintf(tw, "%s\t=\t%s\n", ui.BoldString(name), value)
@@ -83,49 +89,96 @@ func viewCmd(ctx *cli.Context) error {
 }
 
 func getSecrets(ctx *cli.Context) ([]apitypes.CredentialEnvelope, *pathexp.PathExp, error) {
-	cfg, err := config.LoadConfig()
+	f, err := newSecretsFetcher(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	client := api.NewClient(cfg)
-	c := context.Background()
+	s, p := spinner("Decrypting credentials")
+	s.Start()
+	secrets, err := f.fetch(context.Background(), p)
+	s.Stop()
+	if err != nil {
+		return nil, nil, err
+	}
 
-	session, err := client.Session.Who(c)
+	out, err := pathexp.New(ctx.String("org"), ctx.String("project"),
+		[]string{ctx.String("environment")}, []string{ctx.String("service")},
+		[]string{"*"}, []string{"*"})
 	if err != nil {
 		return nil, nil, err
 	}
 
+	return secrets, out, nil
+}
+
+// secretsFetcher retrieves the compacted set of secrets for a single, fully
+// derived path. It can be used repeatedly to poll for changes.
+//
+// Secrets whose references couldn't be resolved are omitted, with a warning
+// the first time each is seen.
+type secretsFetcher struct {
+	client *api.Client
+	path   *pathexp.PathExp
+	warned map[string]bool
+}
+
+func newSecretsFetcher(ctx *cli.Context) (*secretsFetcher, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	client := api.NewClient(cfg)
+
+	session, err := client.Session.Who(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
 	identity := deriveIdentity(session)
 	path, err := deriveExplicitPathExp(ctx.String("org"), ctx.String("project"),
 		ctx.String("environment"), ctx.String("service"), identity)
 	if err != nil {
-		return nil, nil, errs.NewErrorExitError("Error deriving credential path", err)
+		return nil, errs.NewErrorExitError("Error deriving credential path", err)
 	}
 
-	s, p := spinner("Decrypting credentials")
-	s.Start()
-	defer s.Stop()
-	secrets, err := client.Credentials.Get(c, path.String(), p)
+	return &secretsFetcher{client: client, path: path}, nil
+}
+
+func (f *secretsFetcher) fetch(c context.Context, p api.ProgressFunc) ([]apitypes.CredentialEnvelope, error) {
+	secrets, err := f.client.Credentials.Get(c, f.path.String(), p)
 	if err != nil {
-		return nil, nil, errs.NewErrorExitError("Error fetching secrets", err)
+		return nil, errs.NewErrorExitError("Error fetching secrets", err)
 	}
 
 	cset := credentialSet{}
 	for _, c := range secrets {
 		if err := cset.Add(c); err != nil {
-			return nil, nil, errs.NewErrorExitError("Error compacting secrets", err)
+			return nil, errs.NewErrorExitError("Error compacting secrets", err)
 		}
 	}
 
-	out, err := pathexp.New(ctx.String("org"), ctx.String("project"),
-		[]string{ctx.String("environment")}, []string{ctx.String("service")},
-		[]string{"*"}, []string{"*"})
-	if err != nil {
-		return nil, nil, err
+	all := cset.ToSlice()
+	resolved := all[:0]
+	for _, c := range all {
+		name := (*c.Body).GetName()
+		reason := (*c.Body).GetUnresolved()
+		if reason == "" {
+			resolved = append(resolved, c)
+			continue
+		}
+
+		if f.warned == nil {
+			f.warned = make(map[string]bool)
+		}
+		if !f.warned[name+reason] {
+			f.warned[name+reason] = true
+			ui.Warn("Omitting %s: %s", name, reason)
+		}
 	}
 
-	return cset.ToSlice(), out, nil
+	return resolved, nil
 }
 
 func deriveExplicitPathExp(org, project, env, service, identity string) (*pathexp.PathExp, error) {
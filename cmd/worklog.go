@@ -2,7 +2,14 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/manifoldco/promptui"
 	"github.com/urfave/cli"
@@ -12,6 +19,8 @@ import (
 	"github.com/manifoldco/torus-cli/config"
 	"github.com/manifoldco/torus-cli/envelope"
 	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/prefs"
 	"github.com/manifoldco/torus-cli/primitive"
 	"github.com/manifoldco/torus-cli/prompts"
 	"github.com/manifoldco/torus-cli/ui"
@@ -23,6 +32,24 @@ var catOrder = []apitypes.WorklogType{
 	apitypes.UserKeyringMembersWorklogType,
 	apitypes.MachineKeyringMembersWorklogType,
 	apitypes.SecretRotateWorklogType,
+	apitypes.SecretExpiryWorklogType,
+	apitypes.SecretAgeWorklogType,
+}
+
+// expiryWindowFlag sets how far ahead to look for expiring secrets.
+var expiryWindowFlag = newPlaceholder("expiry-window", "DAYS",
+	"List secrets expiring within this many days", "", "", false)
+
+// maxSecretAgeFlag sets the maximum age of secrets, past which they are listed
+// for rotation.
+var maxSecretAgeFlag = newPlaceholder("max-secret-age", "POLICY",
+	"List secrets older than this many days, optionally per path (e.g. 365,/myorg/*/production=90)", "", "", false)
+
+// includeMachinesFlag treats the revocation of machines' access as a reason to
+// rotate secrets.
+var includeMachinesFlag = cli.BoolFlag{
+	Name:  "include-machines",
+	Usage: "List secrets to rotate because a machine's access was revoked",
 }
 
 var (
@@ -42,7 +69,20 @@ func init() {
 			{
 				Name:  "list",
 				Usage: "List worklog maintenance tasks",
-				Flags: []cli.Flag{stdOrgFlag},
+				Flags: []cli.Flag{
+					stdOrgFlag,
+					expiryWindowFlag,
+					maxSecretAgeFlag,
+					includeMachinesFlag,
+					cli.BoolFlag{
+						Name:  "all",
+						Usage: "Include snoozed and dismissed items",
+					},
+					formatFlag("text", "Format used to display the worklog (text, json)"),
+					newPlaceholder("fail-on", "TYPES",
+						"Exit with a non-zero status if there are items of these comma separated types (e.g. secret-rotate,secret-expiry, or any)",
+						"", "", false),
+				},
 				Action: chain(
 					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
 					checkRequiredFlags, worklogList,
@@ -52,7 +92,7 @@ func init() {
 				Name:      "view",
 				Usage:     "Show the details of a worklog item",
 				ArgsUsage: "<identity>",
-				Flags:     []cli.Flag{stdOrgFlag},
+				Flags:     []cli.Flag{stdOrgFlag, expiryWindowFlag, maxSecretAgeFlag, includeMachinesFlag},
 				Action: chain(
 					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
 					checkRequiredFlags, worklogView,
@@ -62,12 +102,54 @@ func init() {
 				Name:      "resolve",
 				Usage:     "Act on and resolve the given worklog items",
 				ArgsUsage: "[identity...]",
-				Flags:     []cli.Flag{stdOrgFlag},
+				Flags: []cli.Flag{
+					stdOrgFlag, generateFlag, expiryWindowFlag, maxSecretAgeFlag,
+					includeMachinesFlag, stdAutoAcceptFlag,
+				},
 				Action: chain(
 					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
 					checkRequiredFlags, worklogResolve,
 				),
 			},
+			{
+				Name:      "snooze",
+				Usage:     "Hide worklog items until a date",
+				ArgsUsage: "<identity...>",
+				Flags: []cli.Flag{
+					stdOrgFlag,
+					newPlaceholder("until", "DATE", "Hide the items until this date (YYYY-MM-DD)", "", "", true),
+					newPlaceholder("reason", "TEXT", "Why the items are snoozed", "", "", false),
+					expiryWindowFlag, maxSecretAgeFlag, includeMachinesFlag,
+				},
+				Action: chain(
+					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+					checkRequiredFlags, worklogSnooze,
+				),
+			},
+			{
+				Name:      "dismiss",
+				Usage:     "Hide worklog items until their cause changes",
+				ArgsUsage: "<identity...>",
+				Flags: []cli.Flag{
+					stdOrgFlag,
+					newPlaceholder("reason", "TEXT", "Why the items are dismissed", "", "", true),
+					expiryWindowFlag, maxSecretAgeFlag, includeMachinesFlag,
+				},
+				Action: chain(
+					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+					checkRequiredFlags, worklogDismiss,
+				),
+			},
+			{
+				Name:      "restore",
+				Usage:     "Show snoozed or dismissed worklog items again",
+				ArgsUsage: "<identity...>",
+				Flags:     []cli.Flag{stdOrgFlag},
+				Action: chain(
+					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+					checkRequiredFlags, worklogRestore,
+				),
+			},
 		},
 	}
 	Cmds = append(Cmds, worklog)
@@ -85,6 +167,10 @@ func groupMsgFor(typ apitypes.WorklogType) string {
 		return "Machines missing granted access to secrets in the %s org:"
 	case apitypes.SecretRotateWorklogType:
 		return "Secrets that should be rotated in the %s org:"
+	case apitypes.SecretExpiryWorklogType:
+		return "Secrets expiring soon or expired in the %s org:"
+	case apitypes.SecretAgeWorklogType:
+		return "Secrets older than their maximum age in the %s org:"
 	default:
 		return ""
 	}
@@ -100,6 +186,14 @@ func subjectFor(item *apitypes.WorklogItem) string {
 		return underline(d.Name)
 	case *apitypes.SecretRotateWorklogDetails:
 		return item.Subject()
+	case *apitypes.SecretExpiryWorklogDetails:
+		return fmt.Sprintf("%s %s", item.Subject(), italic(expiryFor(d)))
+	case *apitypes.SecretAgeWorklogDetails:
+		age := fmt.Sprintf("set %d days ago", d.AgeDays(time.Now()))
+		if d.Approximate {
+			age = fmt.Sprintf("set up to %d days ago", d.AgeDays(time.Now()))
+		}
+		return fmt.Sprintf("%s %s", item.Subject(), italic(age))
 	default:
 		return item.Subject()
 	}
@@ -134,18 +228,72 @@ func detailsFor(org *envelope.Org, item *apitypes.WorklogItem) {
 				rm = "was removed from the org."
 			case primitive.KeyRevocationRevocationType:
 				rm = "changed their encryption key."
+			case primitive.MachineDestroyRevocationType:
+				rm = "was destroyed."
+			case primitive.MachineTokenDestroyRevocationType:
+				rm = "had a token destroyed."
 			default:
 				rm = "lost access."
 			}
 
+			if r.Machine != "" {
+				c.LineIndent(2, "Machine %s %s", underline(r.Machine), rm)
+				continue
+			}
 			c.LineIndent(2, "%s %s", underline(r.Username), rm)
 		}
+	case *apitypes.SecretExpiryWorklogDetails:
+		u.Line("%s Set a new value with an updated expiry date:", d.Summary())
+		c := u.Child(2)
+		c.LineIndent(2, "torus set --expires-at DATE %s <value>", item.Subject())
+	case *apitypes.SecretAgeWorklogDetails:
+		u.Line("%s Rotate it by setting a new value, or by generating one:", d.Summary())
+		c := u.Child(2)
+		c.LineIndent(2, "torus set %s <value>", item.Subject())
+		c.LineIndent(2, "torus worklog resolve --generate <generator> %s", item.ID)
+		if d.Link != "" {
+			u.Line("Rotation instructions may be found at %s", underline(d.Link))
+		}
 	default:
 		u.Line(item.Subject())
 	}
+
+	if item.Dismissal != nil {
+		fmt.Println()
+		u.Line("This item is %s. Show it again with:", dismissalFor(item.Dismissal))
+		u.Child(2).LineIndent(2, "torus worklog restore %s", item.ID)
+	}
+}
+
+// dismissalFor returns a short description of the item's snooze or
+// dismissal.
+func dismissalFor(d *apitypes.WorklogDismissal) string {
+	msg := "dismissed"
+	if d.Until != nil {
+		msg = "snoozed until " + d.Until.Local().Format(expiryDateFormat)
+	}
+
+	if d.Reason != "" {
+		msg += ": " + d.Reason
+	}
+	return msg
 }
 
 func worklogList(ctx *cli.Context) error {
+	format := ctx.String("format")
+	if format != "text" && format != "json" {
+		return errs.NewUsageExitError(fmt.Sprintf("Invalid format provided: %s", format), ctx)
+	}
+
+	var failOn apitypes.WorklogType
+	if raw := ctx.String("fail-on"); raw != "" {
+		var err error
+		failOn, err = apitypes.ParseWorklogTypes(raw)
+		if err != nil {
+			return errs.NewUsageExitError(err.Error(), ctx)
+		}
+	}
+
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		return err
@@ -159,14 +307,62 @@ func worklogList(ctx *cli.Context) error {
 		return err
 	}
 
-	items, err := client.Worklog.List(c, org.ID)
+	opts, err := worklogOptions(ctx, org.Body.Name)
+	if err != nil {
+		return err
+	}
+	opts.All = ctx.Bool("all")
+
+	items, err := client.Worklog.List(c, org.ID, opts)
 	if err != nil {
 		return errs.NewErrorExitError("Could not retrieve worklog items", err)
 	}
 
+	items = sortWorklogItems(items)
+
+	if format == "json" {
+		err = writeWorklogJSON(os.Stdout, items)
+	} else {
+		writeWorklogText(org, items)
+	}
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, item := range items {
+		if item.Type()&failOn != 0 && item.Dismissal == nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		msg := fmt.Sprintf("%d worklog item(s) outstanding.", failed)
+		if format == "text" {
+			msg = "\n" + msg
+		}
+		return errs.NewExitError(msg)
+	}
+
+	return nil
+}
+
+// sortWorklogItems returns the items in the order their types are displayed.
+func sortWorklogItems(items []apitypes.WorklogItem) []apitypes.WorklogItem {
+	sorted := make([]apitypes.WorklogItem, 0, len(items))
+	for _, cat := range catOrder {
+		for _, item := range items {
+			if item.Type() == cat {
+				sorted = append(sorted, item)
+			}
+		}
+	}
+	return sorted
+}
+
+func writeWorklogText(org *envelope.Org, items []apitypes.WorklogItem) {
 	if len(items) == 0 {
 		ui.Line("Worklog complete! No items left to resolve. 👍")
-		return nil
+		return
 	}
 
 	itemsByCat := make(map[apitypes.WorklogType][]apitypes.WorklogItem)
@@ -191,13 +387,125 @@ func worklogList(ctx *cli.Context) error {
 		ui.Line("%s %s\n", yellow(cat.String()), groupMsg)
 		c := ui.Child(2)
 		for _, item := range items {
+			if item.Dismissal != nil {
+				c.LineIndent(2, "%s %s %s", faint(item.ID.String()), subjectFor(&item),
+					faint("("+dismissalFor(item.Dismissal)+")"))
+				continue
+			}
 			c.LineIndent(2, "%s %s", faint(item.ID.String()), subjectFor(&item))
 		}
 	}
+}
+
+// worklogItemJSON is the machine readable form of a worklog item.
+type worklogItemJSON struct {
+	ID        string                     `json:"id"`
+	Type      string                     `json:"type"`
+	Subject   string                     `json:"subject"`
+	Summary   string                     `json:"summary"`
+	Details   apitypes.WorklogDetails    `json:"details"`
+	Dismissal *apitypes.WorklogDismissal `json:"dismissal,omitempty"`
+}
+
+func writeWorklogJSON(w io.Writer, items []apitypes.WorklogItem) error {
+	out := make([]worklogItemJSON, 0, len(items))
+	for _, item := range items {
+		out = append(out, worklogItemJSON{
+			ID:        item.ID.String(),
+			Type:      item.Type().Name(),
+			Subject:   item.Subject(),
+			Summary:   item.Summary(),
+			Details:   item.Details,
+			Dismissal: item.Dismissal,
+		})
+	}
 
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	err := enc.Encode(out)
+	if err != nil {
+		return errs.NewErrorExitError("Could not marshal to json", err)
+	}
 	return nil
 }
 
+// worklogOptions returns the options for finding worklog items in the named
+// org, from the --expiry-window, --max-secret-age, and --include-machines
+// flags, or the worklog preferences.
+func worklogOptions(ctx *cli.Context, orgName string) (*apitypes.WorklogOptions, error) {
+	p, err := prefs.NewPreferences()
+	if err != nil {
+		return nil, errs.NewErrorExitError("Failed to load prefs.", err)
+	}
+
+	opts := &apitypes.WorklogOptions{}
+
+	raw := ctx.String("expiry-window")
+	if raw == "" {
+		raw = p.Worklog.ExpiryWindow
+	}
+	if raw != "" {
+		opts.ExpiryWindow, err = parseDays(raw)
+		if err != nil {
+			return nil, errs.NewUsageExitError(err.Error(), ctx)
+		}
+	}
+
+	raw = ctx.String("max-secret-age")
+	if raw == "" {
+		raw = p.Worklog.MaxSecretAge
+	}
+	opts.SecretAge, err = apitypes.ParseSecretAgePolicy(raw)
+	if err != nil {
+		return nil, errs.NewUsageExitError(err.Error(), ctx)
+	}
+
+	opts.MachineRevocations = ctx.Bool("include-machines") ||
+		orgListed(p.Worklog.MachineRevocations, orgName)
+
+	return opts, nil
+}
+
+// orgListed returns whether the org is in the comma separated list of org
+// names, or the list is "*".
+func orgListed(list, orgName string) bool {
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "*" || name == orgName {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDays parses a positive whole number of days.
+func parseDays(raw string) (time.Duration, error) {
+	days, err := strconv.Atoi(raw)
+	if err != nil || days < 1 {
+		return 0, fmt.Errorf("Invalid number of days %q: must be a positive whole number", raw)
+	}
+
+	return time.Duration(days) * 24 * time.Hour, nil
+}
+
+// expiryFor returns a short description of when the secret expires.
+func expiryFor(d *apitypes.SecretExpiryWorklogDetails) string {
+	days := d.DaysRemaining(time.Now())
+	switch {
+	case days < -1:
+		return fmt.Sprintf("expired %d days ago", -days)
+	case days == -1:
+		return "expired yesterday"
+	case days == 0:
+		return "expires today"
+	case days == 1:
+		return "expires tomorrow"
+	default:
+		return fmt.Sprintf("expires in %d days", days)
+	}
+}
+
 func worklogView(ctx *cli.Context) error {
 	args := ctx.Args()
 	if len(args) != 1 {
@@ -226,7 +534,12 @@ func worklogView(ctx *cli.Context) error {
 		return errs.NewExitError("Malformed id for worklog item.")
 	}
 
-	item, err := client.Worklog.Get(c, org.ID, &ident)
+	opts, err := worklogOptions(ctx, org.Body.Name)
+	if err != nil {
+		return err
+	}
+
+	item, err := client.Worklog.Get(c, org.ID, &ident, opts)
 	if err != nil {
 		return errs.NewErrorExitError("Could not retrieve worklog item", err)
 	}
@@ -236,7 +549,152 @@ func worklogView(ctx *cli.Context) error {
 	return nil
 }
 
+func worklogSnooze(ctx *cli.Context) error {
+	until, err := parseUntil(ctx.String("until"), time.Now())
+	if err != nil {
+		return errs.NewUsageExitError(err.Error(), ctx)
+	}
+
+	return dismissItems(ctx, &until, ctx.String("reason"))
+}
+
+func worklogDismiss(ctx *cli.Context) error {
+	reason := strings.TrimSpace(ctx.String("reason"))
+	if reason == "" {
+		return errs.NewUsageExitError("A reason is required to dismiss worklog items.", ctx)
+	}
+
+	return dismissItems(ctx, nil, reason)
+}
+
+// dismissItems snoozes the worklog items given as arguments until the given
+// time, or dismisses them if until is nil.
+func dismissItems(ctx *cli.Context, until *time.Time, reason string) error {
+	idents, err := worklogIdents(ctx)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	org, err := getOrg(c, client, ctx.String("org"))
+	if err != nil {
+		return err
+	}
+
+	opts, err := worklogOptions(ctx, org.Body.Name)
+	if err != nil {
+		return err
+	}
+
+	for _, ident := range idents {
+		item, err := client.Worklog.Dismiss(c, org.ID, &ident, until, reason, opts)
+		if err != nil {
+			return errs.NewErrorExitError("Could not dismiss worklog item "+ident.String(), err)
+		}
+
+		ui.Line("%s %s %s", faint(item.ID.String()), subjectFor(item),
+			faint("("+dismissalFor(item.Dismissal)+")"))
+	}
+
+	return nil
+}
+
+func worklogRestore(ctx *cli.Context) error {
+	idents, err := worklogIdents(ctx)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	org, err := getOrg(c, client, ctx.String("org"))
+	if err != nil {
+		return err
+	}
+
+	for _, ident := range idents {
+		err := client.Worklog.Restore(c, org.ID, &ident)
+		if err != nil {
+			return errs.NewErrorExitError("Could not restore worklog item "+ident.String(), err)
+		}
+
+		ui.Line("%s Worklog item %s restored.", promptui.IconGood, yellow(ident.String()))
+	}
+
+	return nil
+}
+
+// worklogIdents returns the worklog item ids given as arguments. At least one
+// is required.
+func worklogIdents(ctx *cli.Context) ([]apitypes.WorklogID, error) {
+	if len(ctx.Args()) == 0 {
+		return nil, errs.NewUsageExitError("At least one identity is required.", ctx)
+	}
+
+	var idents []apitypes.WorklogID
+	for _, raw := range ctx.Args() {
+		ident, err := apitypes.DecodeWorklogIDFromString(raw)
+		if err != nil {
+			return nil, errs.NewExitError("Malformed id for worklog item.")
+		}
+
+		idents = append(idents, ident)
+	}
+
+	return idents, nil
+}
+
+// parseUntil parses the date a worklog item is snoozed until, which must be
+// after now.
+func parseUntil(raw string, now time.Time) (time.Time, error) {
+	until, err := time.ParseInLocation(expiryDateFormat, raw, time.Local)
+	if err != nil {
+		until, err = time.Parse(time.RFC3339, raw)
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid date %q: dates must be YYYY-MM-DD or RFC 3339", raw)
+	}
+
+	if !until.After(now) {
+		return time.Time{}, fmt.Errorf("Invalid date %q: must be in the future", raw)
+	}
+
+	return until.UTC(), nil
+}
+
 func worklogResolve(ctx *cli.Context) error {
+	// Secrets needing rotation are only resolved when a generator is given,
+	// so their new values never need to be entered by hand.
+	var g *secretGenerator
+	if spec := ctx.String("generate"); spec != "" {
+		var err error
+		g, err = parseGenerator(spec)
+		if err != nil {
+			return errs.NewUsageExitError(err.Error(), ctx)
+		}
+
+		// A generated value replaces the secret outright, which would
+		// destroy externally issued values such as API keys if applied to
+		// every item.
+		if len(ctx.Args()) == 0 {
+			return errs.NewUsageExitError(
+				"The identities of the secrets to rotate are required when using --generate.", ctx)
+		}
+	}
+
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		return err
@@ -260,7 +718,13 @@ func worklogResolve(ctx *cli.Context) error {
 		idents = append(idents, ident)
 	}
 
-	items, err := client.Worklog.List(c, org.ID)
+	opts, err := worklogOptions(ctx, org.Body.Name)
+	if err != nil {
+		return err
+	}
+	opts.All = len(idents) > 0 // explicit ids may be snoozed or dismissed
+
+	items, err := client.Worklog.List(c, org.ID, opts)
 	if err != nil {
 		return errs.NewErrorExitError("Could not retrieve worklog items", err)
 	}
@@ -282,13 +746,62 @@ func worklogResolve(ctx *cli.Context) error {
 		}
 	}
 
+	grouped := len(idents) == 0 // no manual ids; doing all of them
+
+	// Invites are approved only once confirmed, unless given explicitly.
+	var confirmed []apitypes.WorklogItem
+	for _, item := range sortWorklogItems(toResolve) {
+		if item.Type() == apitypes.InviteApproveWorklogType && grouped {
+			msg := fmt.Sprintf("%s%s Approve invite for %s", promptui.ResetCode,
+				faint(item.ID.String()), subjectFor(&item))
+			success, err := prompts.Confirm(&msg, nil, false, true)
+			if err != nil {
+				return err
+			}
+			if !success {
+				continue // skip it!
+			}
+		}
+
+		confirmed = append(confirmed, item)
+	}
+
+	if g != nil {
+		if err := confirmRotations(ctx, confirmed); err != nil {
+			return err
+		}
+	}
+
+	// Everything but secrets is resolved by the daemon, in one batch.
+	var batch []apitypes.WorklogID
+	for _, item := range confirmed {
+		switch item.Type() {
+		case apitypes.SecretRotateWorklogType, apitypes.SecretAgeWorklogType, apitypes.SecretExpiryWorklogType:
+		default:
+			batch = append(batch, *item.ID)
+		}
+	}
+
+	resultsByID := make(map[apitypes.WorklogID]apitypes.WorklogResult)
+	if len(batch) > 0 {
+		s, p := spinner("Resolving worklog items")
+		s.Start()
+		results, err := client.Worklog.Resolve(c, org.ID, batch, opts, p)
+		s.Stop()
+		if err != nil {
+			return errs.NewErrorExitError("Could not resolve worklog items", err)
+		}
+
+		for _, r := range results {
+			resultsByID[*r.ID] = r
+		}
+	}
+
 	itemsByCat := make(map[apitypes.WorklogType][]apitypes.WorklogItem)
-	for _, item := range toResolve {
+	for _, item := range confirmed {
 		itemsByCat[item.Type()] = append(itemsByCat[item.Type()], item)
 	}
 
-	grouped := len(idents) == 0 // no manual ids; doing all of them
-
 	newlineNeeded := false
 
 	for _, cat := range catOrder {
@@ -308,34 +821,111 @@ func worklogResolve(ctx *cli.Context) error {
 		}
 
 		for _, item := range items {
-			// An explicit invite id won't trigger a prompt
-			if item.Type() == apitypes.InviteApproveWorklogType && grouped {
-				msg := fmt.Sprintf("%s%s Approve invite for %s", promptui.ResetCode,
-					faint(item.ID.String()), subjectFor(&item))
-				success, err := prompts.Confirm(&msg, nil, false, true)
-				if err != nil {
-					return err
+			switch item.Type() {
+			case apitypes.SecretRotateWorklogType, apitypes.SecretAgeWorklogType:
+				if g == nil {
+					displayResult(&item, nil, grouped, true)
+					continue
 				}
-				if !success {
-					continue // skip it!
+
+				err := rotateSecret(ctx, c, client, g, &item)
+				displayResult(&item, err, grouped, false)
+			case apitypes.SecretExpiryWorklogType:
+				// A new value and expiry date must be chosen by the user.
+				displayResult(&item, nil, grouped, true)
+			default:
+				r := resultsByID[*item.ID]
+				var err error
+				if r.State == apitypes.WorklogResultFailure {
+					err = errors.New(r.Message)
 				}
-			} else if item.Type() == apitypes.SecretRotateWorklogType {
-				displayResult(&item, nil, grouped)
-				continue
+				displayResult(&item, err, grouped, r.State == apitypes.WorklogResultManual)
 			}
+		}
+	}
+
+	return nil
+}
 
-			err := client.Worklog.Resolve(c, org.ID, item.ID)
-			displayResult(&item, err, grouped)
+// rotationTarget returns the path and name of the secret a worklog item
+// rotates, or false if it doesn't rotate a secret.
+func rotationTarget(item *apitypes.WorklogItem) (*pathexp.PathExp, string, bool) {
+	switch d := item.Details.(type) {
+	case *apitypes.SecretRotateWorklogDetails:
+		return d.PathExp, d.Name, true
+	case *apitypes.SecretAgeWorklogDetails:
+		return d.PathExp, d.Name, true
+	default:
+		return nil, "", false
+	}
+}
+
+// confirmRotations lists the secrets which will be overwritten with generated
+// values, and asks the user to confirm before any are written.
+func confirmRotations(ctx *cli.Context, items []apitypes.WorklogItem) error {
+	var paths []string
+	for _, item := range items {
+		if pe, name, ok := rotationTarget(&item); ok {
+			paths = append(paths, displayPathExp(pe)+"/"+name)
 		}
 	}
 
+	if len(paths) == 0 {
+		return nil
+	}
+
+	fmt.Println("The following secrets will be overwritten with generated values:")
+	fmt.Println()
+	for _, path := range paths {
+		fmt.Printf("  %s\n", path)
+	}
+	fmt.Println()
+
+	if ctx.Bool("yes") {
+		return nil
+	}
+
+	preamble := "Their current values can only be recovered with `torus rollback`."
+	success, err := prompts.Confirm(nil, &preamble, true, false)
+	if err != nil {
+		return errs.NewErrorExitError("Failed to retrieve confirmation", err)
+	}
+	if !success {
+		return errs.ErrAbort
+	}
+
 	return nil
 }
 
-func displayResult(item *apitypes.WorklogItem, err error, grouped bool) {
+// rotateSecret sets a newly generated value for the secret needing rotation.
+func rotateSecret(ctx *cli.Context, c context.Context, client *api.Client,
+	g *secretGenerator, item *apitypes.WorklogItem) error {
+
+	pe, name, ok := rotationTarget(item)
+	if !ok {
+		return errs.NewExitError("Worklog item is not a secret to rotate")
+	}
+
+	makers, err := g.ValueMakers(name)
+	if err != nil {
+		return err
+	}
+
+	err = describeValueMaker(c, client, pe, name, makers, metadataEdit{})
+	if err != nil {
+		return err
+	}
+
+	_, err = setCredentials(ctx, pe, makers, nil)
+	return err
+}
+
+// displayResult displays the outcome of resolving a worklog item. manual is
+// true for items the user must act on themselves.
+func displayResult(item *apitypes.WorklogItem, err error, grouped, manual bool) {
 	icon := promptui.IconGood
 
-	if item.Type() == apitypes.SecretRotateWorklogType {
+	if manual {
 		icon = promptui.IconWarn
 	}
 
@@ -361,8 +951,8 @@ func displayResult(item *apitypes.WorklogItem, err error, grouped bool) {
 			fallthrough
 		case apitypes.MachineKeyringMembersWorklogType:
 			typ = "reconciling secret access"
-		case apitypes.SecretRotateWorklogType:
-			typ = "rotating secret" // this one will never happen; its manual.
+		case apitypes.SecretRotateWorklogType, apitypes.SecretExpiryWorklogType, apitypes.SecretAgeWorklogType:
+			typ = "rotating secret"
 		}
 
 		message = fmt.Sprintf("Error %s: %s", typ, err)
@@ -376,8 +966,13 @@ func displayResult(item *apitypes.WorklogItem, err error, grouped bool) {
 			message = "Secret access for user %s has been reconciled."
 		case apitypes.MachineKeyringMembersWorklogType:
 			message = "Secret access for machine %s has been reconciled."
-		case apitypes.SecretRotateWorklogType:
-			message = "Please set a new value for %s"
+		case apitypes.SecretRotateWorklogType, apitypes.SecretAgeWorklogType:
+			message = "Secret %s has been rotated."
+			if manual {
+				message = "Please set a new value for %s"
+			}
+		case apitypes.SecretExpiryWorklogType:
+			message = "Please set a new value and expiry date for %s"
 		}
 
 		message = fmt.Sprintf(message, subjectFor(item))
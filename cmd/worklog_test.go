@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	gm "github.com/onsi/gomega"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+func TestParseDays(t *testing.T) {
+	gm.RegisterTestingT(t)
+
+	d, err := parseDays("14")
+	gm.Expect(err).ToNot(gm.HaveOccurred())
+	gm.Expect(d).To(gm.Equal(14 * 24 * time.Hour))
+
+	for _, raw := range []string{"0", "-3", "1.5", "two", ""} {
+		_, err := parseDays(raw)
+		gm.Expect(err).To(gm.HaveOccurred(), raw)
+	}
+}
+
+func TestOrgListed(t *testing.T) {
+	gm.RegisterTestingT(t)
+
+	gm.Expect(orgListed("", "myorg")).To(gm.BeFalse())
+	gm.Expect(orgListed("other", "myorg")).To(gm.BeFalse())
+	gm.Expect(orgListed("other, myorg", "myorg")).To(gm.BeTrue())
+	gm.Expect(orgListed("*", "myorg")).To(gm.BeTrue())
+}
+
+func TestParseUntil(t *testing.T) {
+	gm.RegisterTestingT(t)
+
+	now := time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	until, err := parseUntil("2017-06-02T00:00:00Z", now)
+	gm.Expect(err).ToNot(gm.HaveOccurred())
+	gm.Expect(until).To(gm.Equal(time.Date(2017, 6, 2, 0, 0, 0, 0, time.UTC)))
+
+	_, err = parseUntil("2017-07-01", now)
+	gm.Expect(err).ToNot(gm.HaveOccurred())
+
+	for _, raw := range []string{"2017-05-01", "2017-06-01T11:00:00Z", "next week", ""} {
+		_, err := parseUntil(raw, now)
+		gm.Expect(err).To(gm.HaveOccurred(), raw)
+	}
+}
+
+func TestWriteWorklogJSON(t *testing.T) {
+	gm.RegisterTestingT(t)
+
+	pe, err := pathexp.Parse("/o/p/e/s/*/*")
+	gm.Expect(err).ToNot(gm.HaveOccurred())
+
+	item := apitypes.WorklogItem{Details: &apitypes.SecretRotateWorklogDetails{
+		PathExp: pe,
+		Name:    "token",
+		Reasons: []apitypes.SecretRotateWorklogReason{
+			{Username: "jeff", Type: primitive.OrgRemovalRevocationType},
+		},
+	}}
+	item.CreateID(apitypes.SecretRotateWorklogType)
+
+	buf := &bytes.Buffer{}
+	err = writeWorklogJSON(buf, []apitypes.WorklogItem{item})
+	gm.Expect(err).ToNot(gm.HaveOccurred())
+
+	var out []map[string]interface{}
+	gm.Expect(json.Unmarshal(buf.Bytes(), &out)).To(gm.Succeed())
+	gm.Expect(out).To(gm.HaveLen(1))
+	gm.Expect(out[0]["id"]).To(gm.Equal(item.ID.String()))
+	gm.Expect(out[0]["type"]).To(gm.Equal("secret-rotate"))
+	gm.Expect(out[0]["subject"]).To(gm.Equal("/o/p/e/s/*/*/token"))
+	gm.Expect(out[0]["summary"]).To(gm.Equal(item.Summary()))
+	gm.Expect(out[0]).ToNot(gm.HaveKey("dismissal"))
+
+	details := out[0]["details"].(map[string]interface{})
+	gm.Expect(details["pathexp"]).To(gm.Equal("/o/p/e/s/*/*"))
+	gm.Expect(details["reasons"]).To(gm.HaveLen(1))
+
+	buf.Reset()
+	err = writeWorklogJSON(buf, nil)
+	gm.Expect(err).ToNot(gm.HaveOccurred())
+	gm.Expect(buf.String()).To(gm.Equal("[]\n"))
+}
+
+func TestRotationTarget(t *testing.T) {
+	gm.RegisterTestingT(t)
+
+	pe, err := pathexp.Parse("/o/p/e/s/*/*")
+	gm.Expect(err).ToNot(gm.HaveOccurred())
+
+	rotate := apitypes.WorklogItem{Details: &apitypes.SecretRotateWorklogDetails{PathExp: pe, Name: "api_key"}}
+	gotPE, name, ok := rotationTarget(&rotate)
+	gm.Expect(ok).To(gm.BeTrue())
+	gm.Expect(gotPE).To(gm.Equal(pe))
+	gm.Expect(name).To(gm.Equal("api_key"))
+
+	age := apitypes.WorklogItem{Details: &apitypes.SecretAgeWorklogDetails{PathExp: pe, Name: "password"}}
+	_, name, ok = rotationTarget(&age)
+	gm.Expect(ok).To(gm.BeTrue())
+	gm.Expect(name).To(gm.Equal("password"))
+
+	expiry := apitypes.WorklogItem{Details: &apitypes.SecretExpiryWorklogDetails{PathExp: pe, Name: "cert"}}
+	_, _, ok = rotationTarget(&expiry)
+	gm.Expect(ok).To(gm.BeFalse())
+}
@@ -38,6 +38,10 @@ type Config struct {
 	ManifestURI *url.URL
 	CABundle    *x509.CertPool
 	PublicKey   *prefs.PublicKey
+
+	// VerifyGraph enables verification of credential graphs by the daemon
+	// before their credentials are decrypted.
+	VerifyGraph bool
 }
 
 // NewConfig returns a new Config, with loaded user preferences.
@@ -87,6 +91,7 @@ func NewConfig(torusRoot string) (*Config, error) {
 		GatekeeperAddress: preferences.Core.GatekeeperAddress,
 		CABundle:          caBundle,
 		PublicKey:         publicKey,
+		VerifyGraph:       preferences.Core.VerifyGraph,
 	}
 
 	// set OS specific transport address
@@ -205,7 +205,12 @@ func CreateMasterKeyObject(ctx context.Context, password []byte, masterKey *[]by
 func DeriveLoginKeypair(ctx context.Context, secret []byte, salt *base64url.Value) (
 	*LoginKeypair, error) {
 
-	key, err := deriveHash(ctx, secret, salt.String())
+	seed, err := deriveLoginSeed(ctx, secret, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	keypair, err := newLoginKeypair(seed, salt)
 	if err != nil {
 		return nil, err
 	}
@@ -215,8 +220,13 @@ func DeriveLoginKeypair(ctx context.Context, secret []byte, salt *base64url.Valu
 		return nil, err
 	}
 
-	r := bytes.NewReader(key[224:]) // Use last 32 bytes of 256 to derive key
-	pubKey, privKey, err := ed25519.GenerateKey(r)
+	return keypair, nil
+}
+
+// deriveLoginSeed derives the seed of the ed25519 login keypair from the given
+// salt and secret values.
+func deriveLoginSeed(ctx context.Context, secret []byte, salt *base64url.Value) ([]byte, error) {
+	key, err := deriveHash(ctx, secret, salt.String())
 	if err != nil {
 		return nil, err
 	}
@@ -226,6 +236,16 @@ func DeriveLoginKeypair(ctx context.Context, secret []byte, salt *base64url.Valu
 		return nil, err
 	}
 
+	return key[224:], nil // Use last 32 bytes of 256 to derive key
+}
+
+// newLoginKeypair returns the ed25519 login keypair for the given seed.
+func newLoginKeypair(seed []byte, salt *base64url.Value) (*LoginKeypair, error) {
+	pubKey, privKey, err := ed25519.GenerateKey(bytes.NewReader(seed))
+	if err != nil {
+		return nil, err
+	}
+
 	keypair := &LoginKeypair{
 		public:  pubKey,
 		private: privKey,
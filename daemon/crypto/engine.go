@@ -597,8 +597,15 @@ func (e *Engine) ChangePassword(ctx context.Context, newPassword string) (*primi
 		return nil, nil, nil, err
 	}
 
+	return ReencryptMasterKey(ctx, newPassword, cmk)
+}
+
+// ReencryptMasterKey creates a password object for the new password, and
+// encrypts the given master key with it. It returns these along with the
+// login public key derived from the new password.
+func ReencryptMasterKey(ctx context.Context, newPassword string, mk *secure.Secret) (*primitive.UserPassword, *primitive.MasterKey, *primitive.LoginPublicKey, error) {
 	// Encrypt the new password and re-encrypt the original master key
-	b := cmk.Buffer()
+	b := mk.Buffer()
 	pw, master, err := EncryptPasswordObject(ctx, newPassword, &b)
 	if err != nil {
 		return nil, nil, nil, err
@@ -0,0 +1,273 @@
+package crypto
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dchest/blake2b"
+	"golang.org/x/crypto/nacl/secretbox"
+
+	"github.com/manifoldco/go-base64"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/identity"
+
+	"github.com/manifoldco/torus-cli/daemon/crypto/secure"
+	"github.com/manifoldco/torus-cli/daemon/ctxutil"
+)
+
+// recoveryCodeBytes is the number of random bytes in a recovery code (160
+// bits).
+const recoveryCodeBytes = 20
+
+// recoveryCodeGroup is the number of characters in each dash separated group
+// of a formatted recovery code.
+const recoveryCodeGroup = 4
+
+// loginSeedBytes is the size of the login keypair seed held in a recovery kit.
+const loginSeedBytes = 32
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// ErrInvalidRecoveryCode is returned when a recovery code is malformed.
+var ErrInvalidRecoveryCode = errors.New("Invalid recovery code")
+
+// ErrRecoveryKitInvalid is returned when a recovery kit can't be opened, either
+// because the recovery code is wrong or the kit has been modified.
+var ErrRecoveryKitInvalid = errors.New("Could not open recovery kit; the recovery code is incorrect or the kit has been modified")
+
+// ExportRecoveryKit encrypts the user's master key, and the seed of the login
+// keypair derived from their password and salt, with a newly generated
+// recovery code. It returns the recovery kit and the formatted code.
+func (e *Engine) ExportRecoveryKit(ctx context.Context, userID *identity.ID,
+	username string, password []byte, salt *base64.Value) (*apitypes.RecoveryKit, string, error) {
+
+	mk, err := e.unsealMasterKey(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	defer mk.Destroy()
+
+	seed, err := deriveLoginSeed(ctx, password, salt)
+	if err != nil {
+		return nil, "", err
+	}
+	defer wipe(seed)
+
+	code, key, err := newRecoveryCode()
+	if err != nil {
+		return nil, "", err
+	}
+
+	kit, err := sealRecoveryKit(ctx, mk.Buffer(), seed, userID, username, time.Now().UTC(), key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return kit, code, nil
+}
+
+// OpenRecoveryKit decrypts the master key and login keypair held in the
+// recovery kit using the given recovery code.
+func (e *Engine) OpenRecoveryKit(ctx context.Context, kit *apitypes.RecoveryKit,
+	code string) (*secure.Secret, *LoginKeypair, error) {
+
+	key, err := parseRecoveryCode(code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mk, seed, err := openRecoveryKit(ctx, kit, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer wipe(mk)
+	defer wipe(seed)
+
+	keypair, err := newLoginKeypair(seed, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	secret, err := e.guard.Secret(mk)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return secret, keypair, nil
+}
+
+// newRecoveryCode returns a random recovery code, formatted for display, and
+// the key it represents.
+func newRecoveryCode() (string, []byte, error) {
+	key := make([]byte, recoveryCodeBytes)
+	_, err := rand.Read(key)
+	if err != nil {
+		return "", nil, err
+	}
+
+	encoded := recoveryCodeEncoding.EncodeToString(key)
+	groups := make([]string, 0, len(encoded)/recoveryCodeGroup)
+	for i := 0; i < len(encoded); i += recoveryCodeGroup {
+		groups = append(groups, encoded[i:i+recoveryCodeGroup])
+	}
+
+	return strings.Join(groups, "-"), key, nil
+}
+
+// parseRecoveryCode returns the key represented by the recovery code. Dashes,
+// whitespace and case are ignored.
+func parseRecoveryCode(code string) ([]byte, error) {
+	normalized := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' || r == '\t' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(code)))
+
+	key, err := recoveryCodeEncoding.DecodeString(normalized)
+	if err != nil || len(key) != recoveryCodeBytes {
+		return nil, ErrInvalidRecoveryCode
+	}
+
+	return key, nil
+}
+
+// sealRecoveryKit encrypts the master key mk and login keypair seed with
+// secretbox, using a key derived from the recovery code key and a random nonce.
+//
+// A digest of the kit's other fields is encrypted along with the keys, so they
+// can't be modified without detection.
+func sealRecoveryKit(ctx context.Context, mk, seed []byte, userID *identity.ID,
+	username string, created time.Time, key []byte) (*apitypes.RecoveryKit, error) {
+
+	err := ctxutil.ErrIfDone(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := [24]byte{}
+	_, err = rand.Read(nonce[:])
+	if err != nil {
+		return nil, err
+	}
+
+	kit := &apitypes.RecoveryKit{
+		Version:  apitypes.RecoveryKitVersion,
+		UserID:   userID,
+		Username: username,
+		Created:  created,
+		Alg:      SecretBox,
+		Nonce:    base64.New(nonce[:]),
+	}
+
+	digest, err := recoveryKitDigest(kit)
+	if err != nil {
+		return nil, err
+	}
+
+	pt := make([]byte, 0, len(digest)+len(mk)+len(seed))
+	pt = append(pt, digest...)
+	pt = append(pt, mk...)
+	pt = append(pt, seed...)
+	defer wipe(pt)
+
+	kitKey := recoveryKitKey(key, nonce[:])
+	defer wipe(kitKey[:])
+
+	kit.Value = base64.New(secretbox.Seal([]byte{}, pt, &nonce, kitKey))
+	return kit, nil
+}
+
+// openRecoveryKit decrypts and verifies the recovery kit with the recovery
+// code key, returning the master key and login keypair seed held within it.
+func openRecoveryKit(ctx context.Context, kit *apitypes.RecoveryKit, key []byte) ([]byte, []byte, error) {
+	if kit == nil || kit.Value == nil || kit.Nonce == nil || kit.UserID == nil {
+		return nil, nil, ErrRecoveryKitInvalid
+	}
+
+	if kit.Version != apitypes.RecoveryKitVersion {
+		return nil, nil, fmt.Errorf("Unsupported recovery kit version: %d", kit.Version)
+	}
+
+	if kit.Alg != SecretBox {
+		return nil, nil, fmt.Errorf("Unsupported recovery kit algorithm: %s", kit.Alg)
+	}
+
+	if len(*kit.Nonce) != 24 {
+		return nil, nil, ErrRecoveryKitInvalid
+	}
+
+	err := ctxutil.ErrIfDone(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	digest, err := recoveryKitDigest(kit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nonce := [24]byte{}
+	copy(nonce[:], *kit.Nonce)
+
+	kitKey := recoveryKitKey(key, nonce[:])
+	defer wipe(kitKey[:])
+
+	pt, ok := secretbox.Open([]byte{}, *kit.Value, &nonce, kitKey)
+	if !ok {
+		return nil, nil, ErrRecoveryKitInvalid
+	}
+
+	if len(pt) != len(digest)+masterKeyBytes+loginSeedBytes {
+		wipe(pt)
+		return nil, nil, ErrRecoveryKitInvalid
+	}
+
+	if subtle.ConstantTimeCompare(pt[:len(digest)], digest) != 1 {
+		wipe(pt)
+		return nil, nil, ErrRecoveryKitInvalid
+	}
+
+	keys := pt[len(digest):]
+	return keys[:masterKeyBytes], keys[masterKeyBytes:], nil
+}
+
+// recoveryKitKey derives the secretbox key for a recovery kit from the
+// recovery code key and the kit's nonce via blake2b.
+func recoveryKitKey(key, nonce []byte) *[32]byte {
+	h := blake2b.NewMAC(32, key)
+	h.Write(nonce)
+
+	out := [32]byte{}
+	copy(out[:], h.Sum(nil))
+	return &out
+}
+
+// recoveryKitDigest returns a blake2b digest of every field in the kit other
+// than its encrypted value.
+func recoveryKitDigest(kit *apitypes.RecoveryKit) ([]byte, error) {
+	header := *kit
+	header.Value = nil
+
+	b, err := json.Marshal(&header)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := blake2b.Sum256(b)
+	return sum[:], nil
+}
+
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,192 @@
+package crypto
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/manifoldco/go-base64"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+func TestRecoveryCode(t *testing.T) {
+	code, key, err := newRecoveryCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(code) != 39 || strings.Count(code, "-") != 7 {
+		t.Errorf("unexpected recovery code format: %s", code)
+	}
+
+	for _, raw := range []string{code, strings.ToLower(code), strings.Replace(code, "-", "", -1), " " + code + "\n"} {
+		parsed, err := parseRecoveryCode(raw)
+		if err != nil {
+			t.Errorf("unexpected error parsing %q: %s", raw, err)
+			continue
+		}
+		if !bytes.Equal(parsed, key) {
+			t.Errorf("expected %q to parse to the generated key", raw)
+		}
+	}
+
+	for _, raw := range []string{"", "ABCD-EFGH", code + "-ABCD", strings.Replace(code, code[:1], "1", 1)} {
+		if _, err := parseRecoveryCode(raw); err != ErrInvalidRecoveryCode {
+			t.Errorf("expected %q to be an invalid code, got %v", raw, err)
+		}
+	}
+}
+
+func TestRecoveryKit(t *testing.T) {
+	ctx := context.Background()
+
+	userID, err := identity.NewMutable(&primitive.User{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mk := make([]byte, masterKeyBytes)
+	if _, err := rand.Read(mk); err != nil {
+		t.Fatal(err)
+	}
+
+	seed := make([]byte, loginSeedBytes)
+	if _, err := rand.Read(seed); err != nil {
+		t.Fatal(err)
+	}
+
+	_, key, err := newRecoveryCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created := time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)
+	kit, err := sealRecoveryKit(ctx, mk, seed, &userID, "jeff", created, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Open a copy of the kit after it has been written to and read from a
+	// file.
+	reload := func(kit *apitypes.RecoveryKit) *apitypes.RecoveryKit {
+		b, err := json.Marshal(kit)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out := &apitypes.RecoveryKit{}
+		if err := json.Unmarshal(b, out); err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		openedMK, openedSeed, err := openRecoveryKit(ctx, reload(kit), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(openedMK, mk) {
+			t.Error("expected the opened master key to match")
+		}
+		if !bytes.Equal(openedSeed, seed) {
+			t.Error("expected the opened login seed to match")
+		}
+	})
+
+	t.Run("wrong code", func(t *testing.T) {
+		_, other, err := newRecoveryCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, _, err := openRecoveryKit(ctx, reload(kit), other); err != ErrRecoveryKitInvalid {
+			t.Errorf("expected invalid kit error, got %v", err)
+		}
+	})
+
+	t.Run("tampering", func(t *testing.T) {
+		otherID, err := identity.NewMutable(&primitive.User{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tcs := map[string]func(*apitypes.RecoveryKit){
+			"user id":  func(k *apitypes.RecoveryKit) { k.UserID = &otherID },
+			"username": func(k *apitypes.RecoveryKit) { k.Username = "mallory" },
+			"created":  func(k *apitypes.RecoveryKit) { k.Created = k.Created.Add(time.Second) },
+			"value": func(k *apitypes.RecoveryKit) {
+				v := []byte(*k.Value)
+				v[len(v)-1] ^= 0x01
+				k.Value = base64.New(v)
+			},
+			"truncated": func(k *apitypes.RecoveryKit) {
+				v := []byte(*k.Value)
+				k.Value = base64.New(v[:len(v)-16])
+			},
+			"nonce": func(k *apitypes.RecoveryKit) {
+				v := []byte(*k.Nonce)
+				v[0] ^= 0x01
+				k.Nonce = base64.New(v)
+			},
+			"missing value": func(k *apitypes.RecoveryKit) { k.Value = nil },
+		}
+
+		for name, tamper := range tcs {
+			tampered := reload(kit)
+			tamper(tampered)
+
+			if _, _, err := openRecoveryKit(ctx, tampered, key); err != ErrRecoveryKitInvalid {
+				t.Errorf("%s: expected invalid kit error, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		tampered := reload(kit)
+		tampered.Version = apitypes.RecoveryKitVersion + 1
+		if _, _, err := openRecoveryKit(ctx, tampered, key); err == nil || err == ErrRecoveryKitInvalid {
+			t.Errorf("expected unsupported version error, got %v", err)
+		}
+
+		tampered = reload(kit)
+		tampered.Alg = Triplesec
+		if _, _, err := openRecoveryKit(ctx, tampered, key); err == nil || err == ErrRecoveryKitInvalid {
+			t.Errorf("expected unsupported algorithm error, got %v", err)
+		}
+	})
+}
+
+func TestRecoveryKitLoginKeypair(t *testing.T) {
+	ctx := context.Background()
+	password := []byte("hunter22")
+	salt := base64.New([]byte("0123456789abcdef"))
+
+	expected, err := DeriveLoginKeypair(ctx, password, salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The seed held in a recovery kit must recreate the keypair derived from
+	// the password, so the kit can be used to log in.
+	seed, err := deriveLoginSeed(ctx, password, salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keypair, err := newLoginKeypair(seed, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if keypair.PublicKey().String() != expected.PublicKey().String() {
+		t.Error("expected the keypair from the seed to match the derived keypair")
+	}
+}
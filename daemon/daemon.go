@@ -77,7 +77,7 @@ func New(cfg *config.Config, groupShared bool) (*Daemon, error) {
 	transport := utils.CreateHTTPTransport(cfg.CABundle, strings.Split(cfg.RegistryURI.Host, ":")[0])
 	client := registry.NewClient(cfg.RegistryURI.String(), cfg.APIVersion,
 		cfg.Version, session, transport)
-	logic := logic.NewEngine(session, db, cryptoEngine, client, guard)
+	logic := logic.NewEngine(cfg, session, db, cryptoEngine, client, guard)
 
 	mTransport := utils.CreateHTTPTransport(cfg.CABundle, strings.Split(cfg.ManifestURI.Host, ":")[0])
 	updates := updates.NewEngine(cfg, mTransport)
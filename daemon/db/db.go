@@ -12,6 +12,7 @@ import (
 
 	"github.com/boltdb/bolt"
 
+	"github.com/manifoldco/torus-cli/apitypes"
 	"github.com/manifoldco/torus-cli/envelope"
 	"github.com/manifoldco/torus-cli/identity"
 )
@@ -141,3 +142,79 @@ func (db *DB) Get(id *identity.ID, env envelope.Envelope) error {
 		return json.Unmarshal(b, env)
 	})
 }
+
+var worklogBucket = []byte("worklog")
+
+// SetWorklogDismissal stores the dismissal of the worklog item with the given
+// id in the given org.
+func (db *DB) SetWorklogDismissal(orgID *identity.ID, ident *apitypes.WorklogID,
+	d *apitypes.WorklogDismissal) error {
+
+	b, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	return db.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(worklogBucket)
+		if err != nil {
+			return err
+		}
+
+		return bucket.Put(worklogKey(orgID, ident), b)
+	})
+}
+
+// WorklogDismissals returns all stored worklog item dismissals for the given
+// org, keyed by worklog item id.
+func (db *DB) WorklogDismissals(orgID *identity.ID) (map[apitypes.WorklogID]apitypes.WorklogDismissal, error) {
+	out := make(map[apitypes.WorklogID]apitypes.WorklogDismissal)
+	err := db.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(worklogBucket)
+		if bucket == nil {
+			return nil
+		}
+
+		c := bucket.Cursor()
+		for k, v := c.Seek(orgID[:]); k != nil && bytes.HasPrefix(k, orgID[:]); k, v = c.Next() {
+			var ident apitypes.WorklogID
+			copy(ident[:], k[len(orgID):])
+
+			var d apitypes.WorklogDismissal
+			err := json.Unmarshal(v, &d)
+			if err != nil {
+				return err
+			}
+
+			out[ident] = d
+		}
+
+		return nil
+	})
+
+	return out, err
+}
+
+// DeleteWorklogDismissals removes the dismissals of the worklog items with
+// the given ids in the given org. Missing dismissals are ignored.
+func (db *DB) DeleteWorklogDismissals(orgID *identity.ID, idents ...apitypes.WorklogID) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(worklogBucket)
+		if bucket == nil {
+			return nil
+		}
+
+		for _, ident := range idents {
+			err := bucket.Delete(worklogKey(orgID, &ident))
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func worklogKey(orgID *identity.ID, ident *apitypes.WorklogID) []byte {
+	return append(append([]byte{}, orgID[:]...), ident[:]...)
+}
@@ -169,11 +169,13 @@ type RotationReason struct {
 //
 // A Credential needs to be rotated if its most recent set version is in a
 // CredentialGraph version that contains a revocation of a user's share to
-// that Keyring.
-func (cgs *credentialGraphSet) NeedRotation() ([]RotationReason, error) {
+// that Keyring. Revocations of machine tokens' shares are only considered when
+// includeMachines is true.
+func (cgs *credentialGraphSet) NeedRotation(includeMachines bool) ([]RotationReason, error) {
 	var needRotation []RotationReason
 
-	typ := (&primitive.User{}).Type()
+	userType := (&primitive.User{}).Type()
+	tokenType := (&primitive.MachineToken{}).Type()
 	for _, graphs := range cgs.graphs {
 		var parents []identity.ID
 
@@ -188,9 +190,15 @@ func (cgs *credentialGraphSet) NeedRotation() ([]RotationReason, error) {
 
 			var reasons []primitive.KeyringMemberClaim
 			for _, c := range graph.GetClaims() {
-				if c.Body.ClaimType == primitive.RevocationClaimType {
-					// Ignore machines
-					if c.Body.OwnerID.Type() == typ {
+				if c.Body.ClaimType != primitive.RevocationClaimType {
+					continue
+				}
+
+				switch c.Body.OwnerID.Type() {
+				case userType:
+					reasons = append(reasons, *c.Body)
+				case tokenType:
+					if includeMachines {
 						reasons = append(reasons, *c.Body)
 					}
 				}
@@ -210,6 +218,45 @@ func (cgs *credentialGraphSet) NeedRotation() ([]RotationReason, error) {
 	return needRotation, nil
 }
 
+// NeedRekey returns the head of each CredentialGraph whose active credentials
+// could be decrypted by a member whose access has since been revoked: those
+// held in a version no newer than the most recent version with a revocation.
+//
+// If force is true, the head of every CredentialGraph holding active
+// credentials is returned.
+func (cgs *credentialGraphSet) NeedRekey(force bool) ([]registry.CredentialGraph, error) {
+	var heads []registry.CredentialGraph
+
+	for _, graphs := range cgs.graphs {
+		sort.Sort(graphSorter(graphs))
+
+		revokedUpTo := -1
+		for _, graph := range graphs {
+			if graph.HasRevocations() {
+				revokedUpTo = graph.KeyringVersion()
+				break
+			}
+		}
+
+		var parents []identity.ID
+		for _, graph := range graphs {
+			var activeCreds []envelope.CredentialInf
+			var err error
+			activeCreds, parents, err = cgs.activeCreds(parents, graph)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(activeCreds) > 0 && (force || graph.KeyringVersion() <= revokedUpTo) {
+				heads = append(heads, graphs[0])
+				break
+			}
+		}
+	}
+
+	return heads, nil
+}
+
 // Head returns the most recent version of a CredentialGraph that would contain
 // the given PathExp.
 func (cgs *credentialGraphSet) Head(pe *pathexp.PathExp) (registry.CredentialGraph, error) {
@@ -261,6 +308,36 @@ func (cgs *credentialGraphSet) HeadCredential(pe *pathexp.PathExp, name string)
 	return head, nil
 }
 
+// credentialVersion is a single version of a Credential, and the
+// CredentialGraph that holds it.
+type credentialVersion struct {
+	graph registry.CredentialGraph
+	cred  envelope.CredentialInf
+}
+
+// History returns every version of the Credential that shares the provided
+// PathExp and Name, across all versions of the CredentialGraph, ordered from
+// most to least recent. Unset versions are included.
+func (cgs *credentialGraphSet) History(pe *pathexp.PathExp, name string) ([]credentialVersion, error) {
+	gpe, err := pe.WithInstance("*")
+	if err != nil {
+		return nil, err
+	}
+
+	var history []credentialVersion
+	for _, graph := range cgs.graphs[gpe.String()] {
+		for _, cred := range graph.GetCredentials() {
+			if cred.PathExp().Equal(pe) && cred.Name() == name {
+				history = append(history, credentialVersion{graph: graph, cred: cred})
+			}
+		}
+	}
+
+	sort.Sort(historySorter(history))
+
+	return history, nil
+}
+
 // graphSorter implements sort.Interface, for sorting CredentialGraphs
 // by version in decreasing order
 type graphSorter []registry.CredentialGraph
@@ -268,3 +345,17 @@ type graphSorter []registry.CredentialGraph
 func (g graphSorter) Len() int           { return len(g) }
 func (g graphSorter) Swap(i, j int)      { g[i], g[j] = g[j], g[i] }
 func (g graphSorter) Less(i, j int) bool { return g[i].KeyringVersion() > g[j].KeyringVersion() }
+
+// historySorter implements sort.Interface, for sorting credentialVersions
+// by credential version, and then keyring version, in decreasing order
+type historySorter []credentialVersion
+
+func (h historySorter) Len() int      { return len(h) }
+func (h historySorter) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h historySorter) Less(i, j int) bool {
+	a, b := h[i], h[j]
+	if a.cred.CredentialVersion() != b.cred.CredentialVersion() {
+		return a.cred.CredentialVersion() > b.cred.CredentialVersion()
+	}
+	return a.graph.KeyringVersion() > b.graph.KeyringVersion()
+}
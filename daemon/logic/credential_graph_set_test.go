@@ -14,6 +14,8 @@ var (
 	id1 = mustID("04100000000000000000000000001")
 	id2 = mustID("04100000000000000000000000010")
 	id3 = mustID("04100000000000000000000000100")
+	id4 = mustID("04100000000000000000000001000")
+	id5 = mustID("04100000000000000000000010000")
 
 	unset = "unset"
 )
@@ -24,6 +26,8 @@ type cred struct {
 	state *string
 	pe    *string
 	name  *string
+
+	version int
 }
 
 func mustID(raw string) *identity.ID {
@@ -62,7 +66,8 @@ func buildGraph(rawPathExp string, version int, secrets ...cred) registry.Creden
 
 	for _, secret := range secrets {
 		base := primitive.BaseCredential{
-			Previous: secret.prev,
+			Previous:          secret.prev,
+			CredentialVersion: secret.version,
 		}
 
 		if secret.pe != nil {
@@ -87,12 +92,16 @@ func buildGraph(rawPathExp string, version int, secrets ...cred) registry.Creden
 }
 
 func buildGraphWithRevocation(rawPathExp string, version int, secrets ...cred) registry.CredentialGraph {
+	return buildGraphWithOwnerRevocation(rawPathExp, version, 0x01, secrets...)
+}
+
+func buildGraphWithOwnerRevocation(rawPathExp string, version int, ownerType byte, secrets ...cred) registry.CredentialGraph {
 	cg := buildGraph(rawPathExp, version, secrets...)
 
 	cg.(*registry.CredentialGraphV2).Claims = []envelope.KeyringMemberClaim{
 		{Body: &primitive.KeyringMemberClaim{
 			ClaimType: primitive.RevocationClaimType,
-			OwnerID:   &identity.ID{0x00, 0x01},
+			OwnerID:   &identity.ID{0x00, ownerType},
 		}},
 	}
 
@@ -594,6 +603,70 @@ func TestCredentialGraphSetHeadCredential(t *testing.T) {
 	})
 }
 
+func TestCredentialGraphSetHistory(t *testing.T) {
+	t.Run("no match", func(t *testing.T) {
+		cgs := newCredentialGraphSet()
+
+		pe := "/o/p/e/s/u/i"
+		name := "cred"
+		cgs.Add(buildGraph("/o/p/e/s/u/*", 1, cred{id: id1, pe: &pe, name: &name, version: 1}))
+
+		out, err := cgs.History(mustPathExp(pe), "nomatchie")
+		if err != nil {
+			t.Fatal("error seen:", err)
+		}
+
+		if len(out) != 0 {
+			t.Error("History found when there should be none")
+		}
+	})
+
+	t.Run("across CredentialGraphs", func(t *testing.T) {
+		cgs := newCredentialGraphSet()
+
+		pe := "/o/p/e/s/u/i"
+		otherpe := "/o/p/e/s/u/other"
+		name := "cred"
+		othername := "othercred"
+
+		cgs.Add(buildGraph("/o/p/e/s/u/*", 1,
+			cred{id: id1, pe: &pe, name: &name, version: 1},
+			cred{id: id2, prev: id1, pe: &pe, name: &name, state: &unset, version: 2},
+			cred{id: id4, pe: &pe, name: &othername, version: 1},
+		))
+		cgs.Add(buildGraph("/o/p/e/s/u/*", 2,
+			cred{id: id3, prev: id2, pe: &pe, name: &name, version: 3},
+			cred{id: id5, pe: &otherpe, name: &name, version: 1},
+		))
+
+		out, err := cgs.History(mustPathExp(pe), name)
+		if err != nil {
+			t.Fatal("error seen:", err)
+		}
+
+		if len(out) != 3 {
+			t.Fatal("Wrong history length. wanted: 3 got:", len(out))
+		}
+
+		want := []struct {
+			id      *identity.ID
+			keyring int
+		}{{id3, 2}, {id2, 1}, {id1, 1}}
+		for i, w := range want {
+			if out[i].cred.GetID() != w.id {
+				t.Error("Wrong credential at", i, "wanted:", w.id, "got:", out[i].cred.GetID())
+			}
+			if out[i].graph.KeyringVersion() != w.keyring {
+				t.Error("Wrong keyring version at", i, "wanted:", w.keyring, "got:", out[i].graph.KeyringVersion())
+			}
+		}
+
+		if !out[1].cred.Unset() {
+			t.Error("Unset version should be included in history")
+		}
+	})
+}
+
 func TestCredentialGraphSetNeedRotation(t *testing.T) {
 	t.Run("no credentials need rotation", func(t *testing.T) {
 		cgs := newCredentialGraphSet()
@@ -602,7 +675,7 @@ func TestCredentialGraphSetNeedRotation(t *testing.T) {
 		name := "cred"
 		cgs.Add(buildGraph("/o/p/e/s/u/*", 3, cred{id: id3, pe: &pe, name: &name}))
 
-		out, err := cgs.NeedRotation()
+		out, err := cgs.NeedRotation(false)
 		if err != nil {
 			t.Fatal("error seen:", err)
 		}
@@ -620,7 +693,7 @@ func TestCredentialGraphSetNeedRotation(t *testing.T) {
 
 		cgs.Add(buildGraphWithRevocation("/o/p/e/s/u/*", 3, cred{id: id3, pe: &pe, name: &name}))
 
-		out, err := cgs.NeedRotation()
+		out, err := cgs.NeedRotation(false)
 		if err != nil {
 			t.Fatal("error seen:", err)
 		}
@@ -640,7 +713,7 @@ func TestCredentialGraphSetNeedRotation(t *testing.T) {
 		cgs.Add(buildGraph("/o/p/e/s/u/*", 3, cred{id: id3, pe: &pe, name: &name}))
 		cgs.Add(buildGraphWithRevocation("/o/p/e/s/u/*", 2, cred{id: id2, pe: &pe, name: &othername}))
 
-		out, err := cgs.NeedRotation()
+		out, err := cgs.NeedRotation(false)
 		if err != nil {
 			t.Fatal("error seen:", err)
 		}
@@ -663,7 +736,7 @@ func TestCredentialGraphSetNeedRotation(t *testing.T) {
 		cgs.Add(buildGraph("/o/p/e/s/u/*", 3, cred{id: id3, prev: id2, pe: &pe, name: &name}))
 		cgs.Add(buildGraphWithRevocation("/o/p/e/s/u/*", 2, cred{id: id2, pe: &pe, name: &name}))
 
-		out, err := cgs.NeedRotation()
+		out, err := cgs.NeedRotation(false)
 		if err != nil {
 			t.Fatal("error seen:", err)
 		}
@@ -672,4 +745,106 @@ func TestCredentialGraphSetNeedRotation(t *testing.T) {
 			t.Error("Wrong number of credentials needing revision found")
 		}
 	})
+	t.Run("machine revocations are opt-in", func(t *testing.T) {
+		cgs := newCredentialGraphSet()
+
+		pe := "/o/p/e/s/u/i"
+		name := "cred"
+
+		machineTokenType := (&primitive.MachineToken{}).Type()
+		cgs.Add(buildGraphWithOwnerRevocation("/o/p/e/s/u/*", 3, machineTokenType,
+			cred{id: id3, pe: &pe, name: &name}))
+
+		out, err := cgs.NeedRotation(false)
+		if err != nil {
+			t.Fatal("error seen:", err)
+		}
+
+		if len(out) != 0 {
+			t.Error("Machine revocation reported without being included")
+		}
+
+		out, err = cgs.NeedRotation(true)
+		if err != nil {
+			t.Fatal("error seen:", err)
+		}
+
+		if len(out) != 1 {
+			t.Fatal("Wrong number of credentials needing revision found")
+		}
+
+		if out[0].Reasons[0].OwnerID.Type() != machineTokenType {
+			t.Error("Wrong reason for credential needing revision")
+		}
+	})
+}
+
+func TestCredentialGraphSetNeedRekey(t *testing.T) {
+	pe := "/o/p/e/s/u/i"
+	name := "cred"
+
+	t.Run("no revocations", func(t *testing.T) {
+		cgs := newCredentialGraphSet()
+		cgs.Add(buildGraph("/o/p/e/s/u/*", 1, cred{id: id1, pe: &pe, name: &name}))
+
+		out, err := cgs.NeedRekey(false)
+		if err != nil {
+			t.Fatal("error seen:", err)
+		}
+		if len(out) != 0 {
+			t.Error("Keyring reported as needing rekey when it shouldn't")
+		}
+
+		out, err = cgs.NeedRekey(true)
+		if err != nil {
+			t.Fatal("error seen:", err)
+		}
+		if len(out) != 1 {
+			t.Error("Keyring not reported as needing rekey when forced")
+		}
+	})
+
+	t.Run("active credential in revoked version", func(t *testing.T) {
+		cgs := newCredentialGraphSet()
+		cgs.Add(buildGraph("/o/p/e/s/u/*", 1, cred{id: id1, pe: &pe, name: &name}))
+		cgs.Add(buildGraphWithRevocation("/o/p/e/s/u/*", 2))
+
+		out, err := cgs.NeedRekey(false)
+		if err != nil {
+			t.Fatal("error seen:", err)
+		}
+		if len(out) != 1 {
+			t.Fatal("Keyring not reported as needing rekey")
+		}
+		if out[0].KeyringVersion() != 2 {
+			t.Error("Head of keyring not returned, got version", out[0].KeyringVersion())
+		}
+	})
+
+	t.Run("credentials replaced after revocation", func(t *testing.T) {
+		cgs := newCredentialGraphSet()
+		cgs.Add(buildGraphWithRevocation("/o/p/e/s/u/*", 1, cred{id: id1, pe: &pe, name: &name}))
+		cgs.Add(buildGraph("/o/p/e/s/u/*", 2, cred{id: id2, prev: id1, pe: &pe, name: &name}))
+
+		out, err := cgs.NeedRekey(false)
+		if err != nil {
+			t.Fatal("error seen:", err)
+		}
+		if len(out) != 0 {
+			t.Error("Keyring reported as needing rekey when it shouldn't")
+		}
+	})
+
+	t.Run("unset credentials are not rekeyed", func(t *testing.T) {
+		cgs := newCredentialGraphSet()
+		cgs.Add(buildGraphWithRevocation("/o/p/e/s/u/*", 1, cred{id: id1, state: &unset, pe: &pe, name: &name}))
+
+		out, err := cgs.NeedRekey(true)
+		if err != nil {
+			t.Fatal("error seen:", err)
+		}
+		if len(out) != 0 {
+			t.Error("Keyring with only unset credentials reported as needing rekey")
+		}
+	})
 }
@@ -9,12 +9,15 @@ import (
 	"log"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/manifoldco/go-base64"
 
 	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
 	"github.com/manifoldco/torus-cli/envelope"
 	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/pathexp"
 	"github.com/manifoldco/torus-cli/primitive"
 	"github.com/manifoldco/torus-cli/registry"
 
@@ -30,6 +33,7 @@ import (
 // All data passing in and out of the engine is unencrypted for the currently
 // logged in user.
 type Engine struct {
+	config  *config.Config
 	session session.Session
 	db      Database
 	crypto  *crypto.Engine
@@ -44,12 +48,17 @@ type Engine struct {
 // Database interface for logic engine
 type Database interface {
 	Set(envs ...envelope.Envelope) error
+
+	SetWorklogDismissal(*identity.ID, *apitypes.WorklogID, *apitypes.WorklogDismissal) error
+	WorklogDismissals(*identity.ID) (map[apitypes.WorklogID]apitypes.WorklogDismissal, error)
+	DeleteWorklogDismissals(*identity.ID, ...apitypes.WorklogID) error
 }
 
 // NewEngine returns a new Engine
-func NewEngine(s session.Session, db Database, e *crypto.Engine,
+func NewEngine(c *config.Config, s session.Session, db Database, e *crypto.Engine,
 	client *registry.Client, guard *secure.Guard) *Engine {
 	engine := &Engine{
+		config:  c,
 		session: s,
 		db:      db,
 		crypto:  e,
@@ -155,57 +164,18 @@ func (e *Engine) AppendCredentials(ctx context.Context, notifier *observer.Notif
 
 	n.Notify(observer.Progress, "Encrypting key retrieved", true)
 
+	now := time.Now().UTC()
 	toCreate := []envelope.CredentialInf{}
 	for _, c := range creds {
-		// Find the  most recent version of this credential to act as our previous.
-		previousCred, err := cgs.HeadCredential(c.Body.PathExp, c.Body.Name)
+		value, err := stampSetAt(c.Body.Value, now)
 		if err != nil {
-			log.Printf("error finding credentials to match: %s", err)
+			log.Printf("Error recording when credential was set: %s", err)
 			return nil, err
 		}
 
-		// Construct an encrypted and signed version of the credential
-		credBody := primitive.Credential{
-			State: c.Body.State,
-			BaseCredential: primitive.BaseCredential{
-				Name:      c.Body.Name,
-				PathExp:   c.Body.PathExp,
-				KeyringID: graph.GetKeyring().GetID(),
-				ProjectID: c.Body.ProjectID,
-				OrgID:     c.Body.OrgID,
-				Credential: &primitive.CredentialValue{
-					Algorithm: crypto.SecretBox,
-				},
-			},
-		}
-
-		if previousCred == nil {
-			log.Printf("no previous")
-			credBody.Previous = nil
-			credBody.CredentialVersion = 1
-		} else {
-			credBody.Previous = previousCred.GetID()
-			credBody.CredentialVersion = previousCred.CredentialVersion() + 1
-		}
-
-		// Derive a key for the credential using the keyring master key
-		// and use the derived key to encrypt the credential
-		cekNonce, ctNonce, ct, err := e.crypto.BoxCredential(
-			ctx, []byte(c.Body.Value), *mekshare.Key.Value, *mekshare.Key.Nonce,
-			&kp.Encryption, *encryptingKey.Key.Value)
+		signed, err := e.boxCredential(ctx, cgs, graph, c, value, mekshare, kp,
+			encryptingKey, sigID)
 		if err != nil {
-			log.Printf("Error encrypting credential: %s", err)
-			return nil, err
-		}
-
-		credBody.Nonce = base64.New(cekNonce)
-
-		credBody.Credential.Nonce = base64.New(ctNonce)
-		credBody.Credential.Value = base64.New(ct)
-
-		signed, err := e.crypto.SignedCredential(ctx, &credBody, sigID, &kp.Signature)
-		if err != nil {
-			log.Printf("Error signing credential body: %s", err)
 			return nil, err
 		}
 
@@ -229,9 +199,101 @@ func (e *Engine) AppendCredentials(ctx context.Context, notifier *observer.Notif
 	return creds, nil
 }
 
+// boxCredential encrypts the plaintext value of c with the keyring's master
+// key, and signs it, as the next version of the credential in graph.
+func (e *Engine) boxCredential(ctx context.Context, cgs *credentialGraphSet,
+	graph registry.CredentialGraph, c *PlaintextCredentialEnvelope, value string,
+	mekshare *primitive.MEKShare, kp *crypto.KeyPairs,
+	encryptingKey *primitive.PublicKey, sigID *identity.ID) (envelope.CredentialInf, error) {
+
+	// Find the  most recent version of this credential to act as our previous.
+	previousCred, err := cgs.HeadCredential(c.Body.PathExp, c.Body.Name)
+	if err != nil {
+		log.Printf("error finding credentials to match: %s", err)
+		return nil, err
+	}
+
+	// Construct an encrypted and signed version of the credential
+	credBody := primitive.Credential{
+		State: c.Body.State,
+		BaseCredential: primitive.BaseCredential{
+			Name:      c.Body.Name,
+			PathExp:   c.Body.PathExp,
+			KeyringID: graph.GetKeyring().GetID(),
+			ProjectID: c.Body.ProjectID,
+			OrgID:     c.Body.OrgID,
+			Credential: &primitive.CredentialValue{
+				Algorithm: crypto.SecretBox,
+			},
+		},
+	}
+
+	if previousCred == nil {
+		log.Printf("no previous")
+		credBody.Previous = nil
+		credBody.CredentialVersion = 1
+	} else {
+		credBody.Previous = previousCred.GetID()
+		credBody.CredentialVersion = previousCred.CredentialVersion() + 1
+	}
+
+	// Derive a key for the credential using the keyring master key
+	// and use the derived key to encrypt the credential
+	cekNonce, ctNonce, ct, err := e.crypto.BoxCredential(
+		ctx, []byte(value), *mekshare.Key.Value, *mekshare.Key.Nonce,
+		&kp.Encryption, *encryptingKey.Key.Value)
+	if err != nil {
+		log.Printf("Error encrypting credential: %s", err)
+		return nil, err
+	}
+
+	credBody.Nonce = base64.New(cekNonce)
+
+	credBody.Credential.Nonce = base64.New(ctNonce)
+	credBody.Credential.Value = base64.New(ct)
+
+	signed, err := e.crypto.SignedCredential(ctx, &credBody, sigID, &kp.Signature)
+	if err != nil {
+		log.Printf("Error signing credential body: %s", err)
+		return nil, err
+	}
+
+	return signed, nil
+}
+
 // RetrieveCredentials returns all credentials for the given CPath string
+//
+// Unless decryption is skipped, the values of reference credentials are
+// resolved, with their unresolved values returned in the Reference field.
+// References which can't be resolved are left unresolved, with the reason in
+// the Unresolved field.
 func (e *Engine) RetrieveCredentials(ctx context.Context,
 	notifier *observer.Notifier, cpath, cpathexp *string, teamIDs []identity.ID, skipDecryption bool) ([]PlaintextCredentialEnvelope, error) {
+
+	creds, err := e.retrieveCredentials(ctx, notifier, cpath, cpathexp, teamIDs, skipDecryption)
+	if err != nil || skipDecryption {
+		return creds, err
+	}
+
+	r := newReferenceResolver(func(pe *pathexp.PathExp) ([]PlaintextCredentialEnvelope, error) {
+		s := pe.String()
+		return e.retrieveCredentials(ctx, notifier, nil, &s, teamIDs, false)
+	}, cpath != nil)
+
+	err = r.ResolveAll(creds)
+	if err != nil {
+		log.Printf("error resolving credential references: %s", err)
+		return nil, &apitypes.Error{
+			Type: apitypes.BadRequestError,
+			Err:  []string{err.Error()},
+		}
+	}
+
+	return creds, nil
+}
+
+func (e *Engine) retrieveCredentials(ctx context.Context,
+	notifier *observer.Notifier, cpath, cpathexp *string, teamIDs []identity.ID, skipDecryption bool) ([]PlaintextCredentialEnvelope, error) {
 	if cpath != nil && cpathexp != nil {
 		panic("cannot use both cpath and cpathexp")
 	}
@@ -353,6 +415,11 @@ Decryption:
 		return nil, ctErr
 	}
 
+	err = e.verifyCredentialGraphs(claimtree, graphs)
+	if err != nil {
+		return nil, err
+	}
+
 	// Cache the bundled crypto keypairs for reuse
 	keypairs := make(map[identity.ID]*crypto.KeyPairs)
 	for encryptingKeyID, graphs := range idx.GetIndex() {
@@ -430,6 +497,153 @@ Decryption:
 	return creds, nil
 }
 
+// CredentialHistory returns every version of the named Credential at the given
+// PathExp, most recent first. If limit is positive, only that many of the most
+// recent versions are returned.
+//
+// If decrypt is true, the value of each version is decrypted where the
+// current user still has access to the keyring version holding it. Versions
+// which cannot be decrypted are returned without a value.
+func (e *Engine) CredentialHistory(ctx context.Context, notifier *observer.Notifier,
+	pe *pathexp.PathExp, name string, decrypt bool, limit int) ([]PlaintextCredentialVersion, error) {
+
+	graphs, err := e.client.CredentialGraph.List(ctx, "", pe, e.session.AuthID(), nil)
+	if err != nil {
+		log.Printf("error retrieving credential graphs: %s", err)
+		return nil, err
+	}
+
+	cgs := newCredentialGraphSet()
+	err = cgs.Add(graphs...)
+	if err != nil {
+		log.Printf("error creating credential graph set: %s", err)
+		return nil, err
+	}
+
+	history, err := cgs.History(pe, name)
+	if err != nil {
+		log.Printf("error finding credential history: %s", err)
+		return nil, err
+	}
+
+	if limit > 0 && len(history) > limit {
+		history = history[:limit]
+	}
+
+	versions := []PlaintextCredentialVersion{}
+	if len(history) == 0 {
+		return versions, nil
+	}
+
+	n := notifier.Notifier(1 + uint(len(history)))
+
+	orgID := history[0].cred.OrgID()
+	claimtree, err := e.client.ClaimTree.Get(ctx, orgID, nil)
+	if err != nil {
+		log.Printf("Could not fetch claimtree for org[%s]: %s", orgID, err)
+		return nil, err
+	}
+
+	// Only v1 credentials must be decrypted to know if they were unset.
+	if decrypt {
+		decrypt = false
+		for _, v := range history {
+			if v.cred.GetVersion() == 1 || !v.cred.Unset() {
+				decrypt = true
+			}
+		}
+	}
+
+	var kp *crypto.KeyPairs
+	if decrypt {
+		kps, err := e.client.KeyPairs.List(ctx, orgID)
+		if err != nil {
+			log.Printf("Cannot fetch keypairs for org[%s]: %s", orgID, err)
+			return nil, err
+		}
+
+		_, _, kp, err = fetchKeyPairs(kps, orgID)
+		if err != nil {
+			log.Printf("Error fetching keypairs: %s", err)
+			return nil, err
+		}
+	}
+
+	n.Notify(observer.Progress, "Credentials retrieved", true)
+
+	for _, v := range history {
+		cred := v.cred
+		version := PlaintextCredentialVersion{
+			ID:                cred.GetID(),
+			Previous:          cred.Previous(),
+			Name:              cred.Name(),
+			PathExp:           cred.PathExp(),
+			CredentialVersion: cred.CredentialVersion(),
+			KeyringVersion:    v.graph.KeyringVersion(),
+			KeyringCreated:    v.graph.GetKeyring().Created(),
+			Unset:             cred.Unset(),
+		}
+
+		// The signer is the owner of the key that signed the credential. The
+		// key may since have been revoked.
+		if signingKey, err := claimtree.Find(cred.SigningKeyID(), false); err == nil {
+			version.SignerID = signingKey.PublicKey.Body.OwnerID
+		}
+
+		if decrypt && !version.Unset {
+			pt, err := e.unboxHistoricCredential(ctx, v, claimtree, kp)
+			if err != nil {
+				log.Printf("Could not decrypt credential[%s]: %s", cred.GetID(), err)
+			} else {
+				if cred.GetVersion() == 1 {
+					cValue, err := extractCredentialValue(pt)
+					if err != nil {
+						log.Printf("could not unmarshal credential value from v1 cred: %s", err)
+						return nil, err
+					}
+
+					version.Unset = cValue.IsUnset()
+				}
+
+				if !version.Unset {
+					value := string(pt)
+					version.Value = &value
+				}
+			}
+		}
+
+		versions = append(versions, version)
+		n.Notify(observer.Progress, "Credential version retrieved", true)
+	}
+
+	return versions, nil
+}
+
+// unboxHistoricCredential decrypts a single version of a Credential using the
+// current user's share of the master key for the keyring version holding it.
+func (e *Engine) unboxHistoricCredential(ctx context.Context, v credentialVersion,
+	claimtree *registry.ClaimTree, kp *crypto.KeyPairs) ([]byte, error) {
+
+	krm, mekshare, err := v.graph.FindMember(e.session.AuthID())
+	if err != nil {
+		return nil, err
+	}
+	if mekshare == nil {
+		return nil, registry.ErrMemberNotFound
+	}
+
+	encKeySegment, err := claimtree.Find(krm.EncryptingKeyID, false)
+	if err != nil {
+		return nil, err
+	}
+	encryptingKey := encKeySegment.PublicKey.Body
+
+	cred := v.cred
+	return e.crypto.UnboxCredential(ctx, *cred.Credential().Value,
+		*mekshare.Key.Value, *mekshare.Key.Nonce, *cred.Nonce(),
+		*cred.Credential().Nonce, &kp.Encryption, *encryptingKey.Key.Value)
+}
+
 // ApproveInvite approves an invitation of a user into an organzation by
 // encoding them into a Keyring.
 func (e *Engine) ApproveInvite(ctx context.Context, notifier *observer.Notifier,
@@ -503,11 +717,24 @@ func (e *Engine) GenerateKeypairs(ctx context.Context, notifier *observer.Notifi
 
 	n.Notify(observer.Progress, "Keypairs generated", true)
 
+	pubsig, err := e.uploadSigningKeypair(ctx, n, OrgID, kp)
+	if err != nil {
+		return err
+	}
+
+	return e.uploadEncryptionKeypair(ctx, n, OrgID, kp, pubsig)
+}
+
+// uploadSigningKeypair packages, claims and uploads the signing keypair in kp,
+// storing the result in the local db.
+func (e *Engine) uploadSigningKeypair(ctx context.Context, n *observer.Notifier,
+	OrgID *identity.ID, kp *crypto.KeyPairs) (*envelope.PublicKey, error) {
+
 	pubsig, privsig, err := packageSigningKeypair(ctx, e.crypto, e.session.AuthID(),
 		OrgID, kp)
 	if err != nil {
 		log.Printf("Error packaging signing keypair: %s", err)
-		return err
+		return nil, err
 	}
 
 	sigBody := primitive.NewClaim(OrgID, e.session.AuthID(), pubsig.ID, pubsig.ID,
@@ -515,7 +742,7 @@ func (e *Engine) GenerateKeypairs(ctx context.Context, notifier *observer.Notifi
 	sigclaim, err := e.crypto.SignedClaim(ctx, sigBody, pubsig.ID, &kp.Signature)
 	if err != nil {
 		log.Printf("Error creating signature claim: %s", err)
-		return err
+		return nil, err
 	}
 
 	n.Notify(observer.Progress, "Signing keys signed", true)
@@ -524,7 +751,7 @@ func (e *Engine) GenerateKeypairs(ctx context.Context, notifier *observer.Notifi
 		privsig, sigclaim)
 	if err != nil {
 		log.Printf("Error uploading signature keypair: %s", err)
-		return err
+		return nil, err
 	}
 
 	objs := make([]envelope.Envelope, len(claims)+2)
@@ -536,15 +763,24 @@ func (e *Engine) GenerateKeypairs(ctx context.Context, notifier *observer.Notifi
 	err = e.db.Set(objs...)
 	if err != nil {
 		log.Printf("Error storing signing keys in local db: %s", err)
-		return err
+		return nil, err
 	}
 
 	n.Notify(observer.Progress, "Signing keys uploaded", true)
 
+	return pubsig, nil
+}
+
+// uploadEncryptionKeypair packages, claims and uploads the encryption keypair
+// in kp, signed by the signing key pubsig, storing the result in the local db.
+func (e *Engine) uploadEncryptionKeypair(ctx context.Context, n *observer.Notifier,
+	OrgID *identity.ID, kp *crypto.KeyPairs, pubsig *envelope.PublicKey) error {
+
 	pubenc, privenc, err := packageEncryptionKeypair(ctx, e.crypto, e.session.AuthID(),
 		OrgID, kp, pubsig)
 	if err != nil {
 		log.Printf("Error packaging encryption keypair: %s", err)
+		return err
 	}
 
 	encBody := primitive.NewClaim(OrgID, e.session.AuthID(), pubenc.ID, pubenc.ID,
@@ -557,14 +793,14 @@ func (e *Engine) GenerateKeypairs(ctx context.Context, notifier *observer.Notifi
 
 	n.Notify(observer.Progress, "Encryption keys signed", true)
 
-	pubenc, privenc, claims, err = e.client.KeyPairs.Create(ctx, pubenc,
+	pubenc, privenc, claims, err := e.client.KeyPairs.Create(ctx, pubenc,
 		privenc, encclaim)
 	if err != nil {
 		log.Printf("Error uploading encryption keypair: %s", err)
 		return err
 	}
 
-	objs = make([]envelope.Envelope, len(claims)+2)
+	objs := make([]envelope.Envelope, len(claims)+2)
 	objs[0] = pubenc
 	objs[1] = privenc
 	for i, claim := range claims {
@@ -587,7 +823,7 @@ func (e *Engine) GenerateKeypairs(ctx context.Context, notifier *observer.Notifi
 func (e *Engine) RevokeKeypairs(ctx context.Context, notifier *observer.Notifier,
 	orgID *identity.ID) error {
 
-	n := notifier.Notifier(5)
+	n := notifier.Notifier(3)
 
 	keypairs, err := e.client.KeyPairs.List(ctx, orgID)
 	if err != nil {
@@ -621,54 +857,263 @@ func (e *Engine) RevokeKeypairs(ctx context.Context, notifier *observer.Notifier
 	kp := bundleKeypairs(sigKP, encKP)
 
 	if encKP != nil { // the encryption keypair might already be revoked
-		encID := encKP.PublicKey.ID
+		err = e.revokeKeypair(ctx, orgID, encKP, sigID, &kp.Signature)
+		if err != nil {
+			return err
+		}
 
-		prevEncClaim, err := encKP.HeadClaim()
+		n.Notify(observer.Progress, "Encryption keys revoked", true)
+	}
+
+	err = e.revokeKeypair(ctx, orgID, sigKP, sigID, &kp.Signature)
+	if err != nil {
+		return err
+	}
+
+	n.Notify(observer.Progress, "Signing keys revoked", true)
+
+	return nil
+}
+
+// revokeKeypair creates and uploads a revocation claim for the given keypair,
+// signed by the signing keypair sigID.
+func (e *Engine) revokeKeypair(ctx context.Context, orgID *identity.ID,
+	ckp *registry.ClaimedKeyPair, sigID *identity.ID, sigKP *crypto.SignatureKeyPair) error {
+
+	keyType := ckp.PublicKey.Body.KeyType
+
+	prevClaim, err := ckp.HeadClaim()
+	if err != nil {
+		return err
+	}
+
+	body := primitive.NewClaim(orgID, e.session.AuthID(), prevClaim.ID,
+		ckp.PublicKey.ID, primitive.RevocationClaimType)
+	claim, err := e.crypto.SignedClaim(ctx, body, sigID, sigKP)
+	if err != nil {
+		log.Printf("Error creating revocation claim for %s key: %s", keyType, err)
+		return err
+	}
+
+	_, err = e.client.Claims.Create(ctx, claim)
+	if err != nil {
+		log.Printf("Error uploading %s keypair revocation: %s", keyType, err)
+		return err
+	}
+
+	return nil
+}
+
+// RotateKeypairs replaces the current user's signing and encryption keypairs
+// for the given org with newly generated ones. Each of the user's keyring
+// memberships is shared again with the new encryption keypair, and then the
+// old keypairs are revoked.
+//
+// Every step is stored in the registry before the next begins, so an
+// interrupted rotation is resumed by calling RotateKeypairs again.
+func (e *Engine) RotateKeypairs(ctx context.Context, notifier *observer.Notifier,
+	orgID *identity.ID) error {
+
+	rot, err := e.keypairRotation(ctx, orgID)
+	if err != nil {
+		return err
+	}
+
+	if rot.newSig == nil || rot.newEnc == nil {
+		n := notifier.Notifier(4)
+
+		kp, err := e.crypto.GenerateKeyPairs(ctx)
 		if err != nil {
+			log.Printf("Error generating keypairs: %s", err)
 			return err
 		}
 
-		encBody := primitive.NewClaim(orgID, e.session.AuthID(), prevEncClaim.ID,
-			encID, primitive.RevocationClaimType)
-		encclaim, err := e.crypto.SignedClaim(ctx, encBody, sigID, &kp.Signature)
+		n.Notify(observer.Progress, "Keypairs generated", true)
+
+		var pubsig *envelope.PublicKey
+		if rot.newSig != nil {
+			// An earlier rotation was interrupted before uploading its
+			// encryption keypair. Keep the signing keypair it uploaded.
+			kp.Signature = bundleKeypairs(rot.newSig, nil).Signature
+			pubsig = rot.newSig.PublicKey
+		} else {
+			pubsig, err = e.uploadSigningKeypair(ctx, n, orgID, kp)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = e.uploadEncryptionKeypair(ctx, n, orgID, kp, pubsig)
 		if err != nil {
-			log.Printf("Error creating revocation claim for encryption key: %s", err)
 			return err
 		}
 
-		n.Notify(observer.Progress, "Encryption keys revoked", true)
+		rot, err = e.keypairRotation(ctx, orgID)
+		if err != nil {
+			return err
+		}
+		if rot.newSig == nil || rot.newEnc == nil {
+			log.Printf("Could not find generated keypairs for org[%s]", orgID)
+			return registry.ErrMissingValidKeypair
+		}
+	}
 
-		_, err = e.client.Claims.Create(ctx, encclaim)
+	oldKP := bundleKeypairs(rot.oldSig, rot.oldEnc)
+	newKP := bundleKeypairs(rot.newSig, rot.newEnc)
+
+	// Once the old encryption keypair is revoked, every membership has
+	// already been shared.
+	if rot.oldEnc != nil {
+		err = e.rotateMemberships(ctx, notifier, orgID, rot, oldKP, newKP)
 		if err != nil {
-			log.Printf("Error uploading encryption keypair revocation: %s", err)
 			return err
 		}
+	}
+
+	n := notifier.Notifier(2)
 
-		n.Notify(observer.Progress, "Encryption key revocation uploaded", true)
+	oldSigID := rot.oldSig.PublicKey.ID
+	if rot.oldEnc != nil {
+		err = e.revokeKeypair(ctx, orgID, rot.oldEnc, oldSigID, &oldKP.Signature)
+		if err != nil {
+			return err
+		}
 	}
 
-	prevSigClaim, err := sigKP.HeadClaim()
+	n.Notify(observer.Progress, "Old encryption keys revoked", true)
+
+	err = e.revokeKeypair(ctx, orgID, rot.oldSig, oldSigID, &oldKP.Signature)
 	if err != nil {
 		return err
 	}
 
-	sigBody := primitive.NewClaim(orgID, e.session.AuthID(), prevSigClaim.ID,
-		sigID, primitive.RevocationClaimType)
-	sigclaim, err := e.crypto.SignedClaim(ctx, sigBody, sigID, &kp.Signature)
+	n.Notify(observer.Progress, "Old signing keys revoked", true)
+
+	return nil
+}
+
+func (e *Engine) keypairRotation(ctx context.Context, orgID *identity.ID) (*keypairRotation, error) {
+	keypairs, err := e.client.KeyPairs.List(ctx, orgID)
 	if err != nil {
-		log.Printf("Error creating revocation claim for signing key: %s", err)
-		return err
+		log.Printf("Error retrieving keypairs: %s", err)
+		return nil, err
 	}
 
-	n.Notify(observer.Progress, "Signing keys revoked", true)
+	rot, err := findKeypairRotation(keypairs.All(), orgID)
+	if err != nil {
+		log.Printf("Could not find keypairs to rotate: %s", err)
+		return nil, err
+	}
+
+	return rot, nil
+}
+
+// rotateMemberships shares each keyring the user can decrypt with their old
+// encryption keypair with their new one. Keyrings already shared with the new
+// keypair are skipped.
+func (e *Engine) rotateMemberships(ctx context.Context, notifier *observer.Notifier,
+	orgID *identity.ID, rot *keypairRotation, oldKP, newKP *crypto.KeyPairs) error {
+
+	graphs, err := orgCredentialGraphs(ctx, e.client, e.session, orgID)
+	if err != nil {
+		return err
+	}
 
-	_, err = e.client.Claims.Create(ctx, sigclaim)
+	claimtree, err := e.client.ClaimTree.Get(ctx, orgID, nil)
 	if err != nil {
-		log.Printf("Error uploading signature keypair revocation: %s", err)
+		log.Printf("Error fetching claimtree for org[%s]: %s", orgID, err)
 		return err
 	}
 
-	n.Notify(observer.Progress, "Signing key revocation uploaded", true)
+	keyrings := make(map[identity.ID]registry.CredentialGraph)
+	for _, graph := range graphs {
+		keyrings[*graph.GetKeyring().GetID()] = graph
+	}
+
+	n := notifier.Notifier(uint(len(keyrings)))
+
+	oldEncID := rot.oldEnc.PublicKey.ID
+	newEncID := rot.newEnc.PublicKey.ID
+	sigID := rot.newSig.PublicKey.ID
+	for _, graph := range keyrings {
+		if _, _, err := graph.FindMemberByPublicKeyID(newEncID); err == nil {
+			n.Notify(observer.Progress, "Keyring membership already shared", true)
+			continue
+		}
+
+		krm, mekshare, err := graph.FindMemberByPublicKeyID(oldEncID)
+		if err == registry.ErrMemberNotFound || (err == nil && mekshare == nil) {
+			n.Notify(observer.Progress, "Keyring membership skipped", true)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		encPubKeySegment, err := claimtree.Find(krm.EncryptingKeyID, false)
+		if err != nil {
+			log.Printf("could not find encrypting public key for membership: %s", err)
+			return err
+		}
+		encPubKey := encPubKeySegment.PublicKey
+
+		mek, err := e.crypto.Unbox(ctx, *mekshare.Key.Value, *mekshare.Key.Nonce,
+			&oldKP.Encryption, *encPubKey.Body.Key.Value)
+		if err != nil {
+			log.Printf("could not decrypt keyring membership: %s", err)
+			return err
+		}
+
+		// The new share is encrypted by the new keypair for itself, so it
+		// can't be opened with the old private key.
+		encMek, nonce, err := e.crypto.Box(ctx, mek, &newKP.Encryption,
+			newKP.Encryption.Public[:])
+		mek.Destroy()
+		if err != nil {
+			log.Printf("could not encrypt keyring membership: %s", err)
+			return err
+		}
+
+		key := &primitive.KeyringMemberKey{
+			Algorithm: crypto.EasyBox,
+			Nonce:     base64.New(nonce),
+			Value:     base64.New(encMek),
+		}
+
+		switch k := graph.GetKeyring().(type) {
+		case *envelope.KeyringV1:
+			member, err := newV1KeyringMember(ctx, e.crypto, krm.OrgID, k.Body.ProjectID,
+				krm.KeyringID, e.session.AuthID(), newEncID, newEncID, sigID, key, newKP)
+			if err != nil {
+				return err
+			}
+
+			_, err = e.client.KeyringMember.Post(ctx, []envelope.KeyringMemberV1{*member})
+			if err != nil {
+				log.Printf("error uploading membership: %s", err)
+				return err
+			}
+		case *envelope.Keyring:
+			member, err := newV2KeyringMember(ctx, e.crypto, krm.OrgID, krm.KeyringID,
+				e.session.AuthID(), newEncID, newEncID, sigID, key, newKP)
+			if err != nil {
+				return err
+			}
+
+			err = e.client.Keyring.Members.Post(ctx, *member)
+			if err != nil {
+				log.Printf("error uploading membership: %s", err)
+				return err
+			}
+		default:
+			return &apitypes.Error{
+				Type: apitypes.InternalServerError,
+				Err:  []string{"Unknown keyring schema version"},
+			}
+		}
+
+		n.Notify(observer.Progress, "Keyring membership shared", true)
+	}
 
 	return nil
 }
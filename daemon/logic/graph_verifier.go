@@ -0,0 +1,874 @@
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/primitive"
+	"github.com/manifoldco/torus-cli/registry"
+
+	"github.com/manifoldco/torus-cli/daemon/crypto"
+)
+
+// The types of object reported by the graphVerifier.
+const (
+	publicKeyObject          = "public-key"
+	claimObject              = "claim"
+	keyringObject            = "keyring"
+	keyringMemberObject      = "keyring-member"
+	mekshareObject           = "mekshare"
+	keyringMemberClaimObject = "keyring-member-claim"
+	credentialObject         = "credential"
+)
+
+// graphVerifier checks the signatures of the objects making up an org's
+// credential graph, and that they link back to the org through the public
+// keys and claims of its claimtree, rather than trusting the registry to have
+// done so.
+//
+// Objects signed without a public key id are verified with the registry's
+// public key, if one is given.
+type graphVerifier struct {
+	orgID       *identity.ID
+	registryKey ed25519.PublicKey
+
+	keys   map[identity.ID]*apitypes.PublicKeySegment
+	order  []identity.ID
+	checks map[identity.ID]*keyCheck
+
+	keyrings    map[identity.ID]*verifiedKeyring
+	credentials map[identity.ID]*verifiedCredential
+
+	// Keyrings and credentials in the order they were verified, so their
+	// chains are checked in a consistent order.
+	keyringOrder    []*verifiedKeyring
+	credentialOrder []*verifiedCredential
+
+	verified int
+	issues   []apitypes.GraphIssue
+}
+
+// keyCheck is the result of verifying a public key and its claims.
+type keyCheck struct {
+	problem apitypes.GraphProblem
+	detail  string
+	claims  []apitypes.GraphIssue
+	revoked *time.Time
+}
+
+// trusted returns whether the key may be trusted as a signer.
+func (k *keyCheck) trusted() bool {
+	return k.problem == "" && len(k.claims) == 0
+}
+
+type verifiedKeyring struct {
+	id       *identity.ID
+	previous *identity.ID
+	pathExp  *pathexp.PathExp
+	version  int
+	created  time.Time
+}
+
+type verifiedCredential struct {
+	id        *identity.ID
+	previous  *identity.ID
+	keyringID *identity.ID
+	pathExp   *pathexp.PathExp
+	name      string
+	version   int
+
+	// The key which signed the credential, if it has been revoked.
+	signerID *identity.ID
+	revoked  *time.Time
+}
+
+// checkInProgress marks a key whose check has begun, to detect keys whose
+// signatures depend on themselves.
+var checkInProgress = &keyCheck{
+	problem: apitypes.InvalidChainProblem,
+	detail:  "key is part of a circular signature chain",
+}
+
+func newGraphVerifier(ct *registry.ClaimTree, registryKey []byte) *graphVerifier {
+	v := &graphVerifier{
+		keys:        make(map[identity.ID]*apitypes.PublicKeySegment),
+		checks:      make(map[identity.ID]*keyCheck),
+		keyrings:    make(map[identity.ID]*verifiedKeyring),
+		credentials: make(map[identity.ID]*verifiedCredential),
+	}
+
+	if ct.Org != nil {
+		v.orgID = ct.Org.ID
+	}
+
+	if len(registryKey) == ed25519.PublicKeySize {
+		v.registryKey = ed25519.PublicKey(registryKey)
+	}
+
+	for i, pks := range ct.PublicKeys {
+		if pks.PublicKey == nil || pks.PublicKey.ID == nil || pks.PublicKey.Body == nil {
+			continue
+		}
+
+		id := *pks.PublicKey.ID
+		if _, ok := v.keys[id]; ok {
+			continue
+		}
+
+		v.keys[id] = &ct.PublicKeys[i]
+		v.order = append(v.order, id)
+	}
+
+	return v
+}
+
+// VerifyClaimTree verifies every public key in the claimtree, and its claims.
+func (v *graphVerifier) VerifyClaimTree() {
+	for _, id := range v.order {
+		pks := v.keys[id]
+		check := v.checkKey(&id)
+
+		v.verified += 1 + len(pks.Claims)
+		if check.problem != "" {
+			v.report(pks.PublicKey.ID, publicKeyObject, "", check.problem, check.detail)
+		}
+		v.issues = append(v.issues, check.claims...)
+	}
+}
+
+// VerifyKeyrings verifies the keyrings and keyring memberships of the given
+// sections. Keyrings that have already been verified are skipped.
+func (v *graphVerifier) VerifyKeyrings(sections ...registry.KeyringSection) {
+	for _, s := range sections {
+		switch ks := s.(type) {
+		case *registry.KeyringSectionV1:
+			v.verifyKeyringV1(ks)
+		case *registry.KeyringSectionV2:
+			v.verifyKeyringV2(ks)
+		case *registry.CredentialGraphV1:
+			v.verifyKeyringV1(&ks.KeyringSectionV1)
+		case *registry.CredentialGraphV2:
+			v.verifyKeyringV2(&ks.KeyringSectionV2)
+		}
+	}
+}
+
+// VerifyCredentialGraphs verifies the keyrings, keyring memberships, and
+// credentials of the given graphs.
+func (v *graphVerifier) VerifyCredentialGraphs(graphs ...registry.CredentialGraph) {
+	for _, g := range graphs {
+		v.VerifyKeyrings(g)
+
+		keyring := g.GetKeyring()
+		for _, c := range g.GetCredentials() {
+			v.verifyCredential(keyring, c)
+		}
+	}
+}
+
+// Result checks the previous links of the keyrings and credentials verified,
+// and returns the outcome of verification.
+func (v *graphVerifier) Result() *apitypes.GraphVerifyResult {
+	v.checkKeyringChains()
+	v.checkCredentialChains()
+	v.checkCredentialSigners()
+
+	issues := v.issues
+	if issues == nil {
+		issues = []apitypes.GraphIssue{}
+	}
+
+	return &apitypes.GraphVerifyResult{
+		Verified: v.verified,
+		Issues:   issues,
+	}
+}
+
+func (v *graphVerifier) report(id *identity.ID, objType, path string,
+	problem apitypes.GraphProblem, detail string) {
+
+	v.issues = append(v.issues, apitypes.GraphIssue{
+		ID:      id,
+		Type:    objType,
+		Path:    path,
+		Problem: problem,
+		Detail:  detail,
+	})
+}
+
+// warn reports an object which couldn't be fully verified, without failing
+// verification.
+func (v *graphVerifier) warn(id *identity.ID, objType, path string,
+	problem apitypes.GraphProblem, detail string) {
+
+	v.report(id, objType, path, problem, detail)
+	v.issues[len(v.issues)-1].Warning = true
+}
+
+// checkKey verifies the public key with the given id, its signer, and its
+// claims. The result is cached.
+func (v *graphVerifier) checkKey(id *identity.ID) *keyCheck {
+	if check, ok := v.checks[*id]; ok {
+		return check
+	}
+
+	v.checks[*id] = checkInProgress
+	check := v.verifyKey(v.keys[*id])
+	v.checks[*id] = check
+
+	return check
+}
+
+func (v *graphVerifier) verifyKey(pks *apitypes.PublicKeySegment) *keyCheck {
+	pubkey := pks.PublicKey
+	body := pubkey.Body
+	check := &keyCheck{}
+
+	if !sameID(body.OrgID, v.orgID) {
+		check.problem = apitypes.InvalidChainProblem
+		check.detail = "key belongs to another org"
+		return check
+	}
+
+	var signerKey ed25519.PublicKey
+	sigKeyID := pubkey.Signature.PublicKeyID
+	if sigKeyID == nil || *sigKeyID == *pubkey.ID {
+		if body.KeyType != primitive.SigningKeyType {
+			check.problem = apitypes.InvalidChainProblem
+			check.detail = fmt.Sprintf("%s key is self-signed", body.KeyType)
+			return check
+		}
+
+		signerKey = keyValue(pubkey)
+	} else {
+		signer, ok := v.keys[*sigKeyID]
+		if !ok {
+			check.problem = apitypes.UnknownSignerProblem
+			check.detail = fmt.Sprintf("signed by unknown key %s", sigKeyID)
+			return check
+		}
+
+		if signer.PublicKey.Body.KeyType != primitive.SigningKeyType {
+			check.problem = apitypes.InvalidChainProblem
+			check.detail = fmt.Sprintf("signed by %s key %s", signer.PublicKey.Body.KeyType, sigKeyID)
+			return check
+		}
+
+		if !sameID(signer.PublicKey.Body.OwnerID, body.OwnerID) {
+			check.problem = apitypes.InvalidChainProblem
+			check.detail = fmt.Sprintf("signed by key %s of another owner", sigKeyID)
+			return check
+		}
+
+		if !v.checkKey(sigKeyID).trusted() {
+			check.problem = apitypes.InvalidChainProblem
+			check.detail = fmt.Sprintf("signing key %s failed verification", sigKeyID)
+			return check
+		}
+
+		signerKey = keyValue(signer.PublicKey)
+	}
+
+	check.problem, check.detail = checkSignature(body, pubkey.ID, pubkey.Signature, signerKey)
+	if check.problem != "" {
+		return check
+	}
+
+	v.verifyClaims(pks, check)
+	return check
+}
+
+// verifyClaims verifies the signatures of the key's claims, and that they form
+// a single chain of previous links from the key, beginning with a signature
+// claim made by the key's owner.
+func (v *graphVerifier) verifyClaims(pks *apitypes.PublicKeySegment, check *keyCheck) {
+	pubkey := pks.PublicKey
+
+	var valid []*envelope.Claim
+	byPrevious := make(map[identity.ID][]*envelope.Claim)
+	for i := range pks.Claims {
+		claim := &pks.Claims[i]
+		if claim.ID == nil || claim.Body == nil {
+			continue
+		}
+
+		claimIssue := func(problem apitypes.GraphProblem, detail string) {
+			check.claims = append(check.claims, apitypes.GraphIssue{
+				ID:      claim.ID,
+				Type:    claimObject,
+				Problem: problem,
+				Detail:  detail,
+			})
+		}
+
+		if !sameID(claim.Body.OrgID, v.orgID) {
+			claimIssue(apitypes.InvalidChainProblem, "claim belongs to another org")
+			continue
+		}
+
+		if !sameID(claim.Body.PublicKeyID, pubkey.ID) {
+			claimIssue(apitypes.InvalidChainProblem, "claim is for another key")
+			continue
+		}
+
+		// Signature claims must be made by the key's owner. The key's own
+		// claims are verified with it directly, as it isn't yet trusted.
+		var signerKey ed25519.PublicKey
+		sigKeyID := claim.Signature.PublicKeyID
+		if sigKeyID != nil && *sigKeyID == *pubkey.ID {
+			if pubkey.Body.KeyType != primitive.SigningKeyType {
+				claimIssue(apitypes.InvalidChainProblem, "claim is signed by an encryption key")
+				continue
+			}
+			signerKey = keyValue(pubkey)
+		} else {
+			var problem apitypes.GraphProblem
+			var detail string
+			signerKey, problem, detail = v.signer(claim.Signature, &claim.Body.Created)
+			if problem != "" {
+				claimIssue(problem, detail)
+				continue
+			}
+
+			if claim.Body.ClaimType == primitive.SignatureClaimType &&
+				(sigKeyID == nil || !sameID(v.keys[*sigKeyID].PublicKey.Body.OwnerID, pubkey.Body.OwnerID)) {
+				claimIssue(apitypes.InvalidChainProblem, "signature claim is signed by another owner")
+				continue
+			}
+		}
+
+		problem, detail := checkSignature(claim.Body, claim.ID, claim.Signature, signerKey)
+		if problem != "" {
+			claimIssue(problem, detail)
+			continue
+		}
+
+		if claim.Body.Previous == nil {
+			claimIssue(apitypes.InvalidChainProblem, "claim has no previous claim")
+			continue
+		}
+
+		valid = append(valid, claim)
+		byPrevious[*claim.Body.Previous] = append(byPrevious[*claim.Body.Previous], claim)
+	}
+
+	// Walk the chain of claims from the key.
+	visited := make(map[identity.ID]bool)
+	previous := *pubkey.ID
+	first := true
+	for {
+		next := byPrevious[previous]
+		if len(next) == 0 {
+			break
+		}
+
+		for _, c := range next[1:] {
+			visited[*c.ID] = true
+			check.claims = append(check.claims, apitypes.GraphIssue{
+				ID:      c.ID,
+				Type:    claimObject,
+				Problem: apitypes.InvalidChainProblem,
+				Detail:  fmt.Sprintf("claim shares its previous claim %s with claim %s", &previous, next[0].ID),
+			})
+		}
+
+		claim := next[0]
+		if visited[*claim.ID] {
+			break
+		}
+		visited[*claim.ID] = true
+
+		switch {
+		case first && claim.Body.ClaimType != primitive.SignatureClaimType:
+			check.claims = append(check.claims, apitypes.GraphIssue{
+				ID:      claim.ID,
+				Type:    claimObject,
+				Problem: apitypes.InvalidChainProblem,
+				Detail:  fmt.Sprintf("first claim is a %s claim", claim.Body.ClaimType),
+			})
+		case check.revoked != nil:
+			check.claims = append(check.claims, apitypes.GraphIssue{
+				ID:      claim.ID,
+				Type:    claimObject,
+				Problem: apitypes.InvalidChainProblem,
+				Detail:  "claim follows a revocation",
+			})
+		case claim.Body.ClaimType == primitive.RevocationClaimType:
+			revoked := claim.Body.Created
+			check.revoked = &revoked
+		}
+
+		first = false
+		previous = *claim.ID
+	}
+
+	if first && check.problem == "" && len(check.claims) == 0 {
+		check.problem = apitypes.InvalidChainProblem
+		check.detail = "key has no signature claim"
+	}
+
+	for _, c := range valid {
+		if visited[*c.ID] {
+			continue
+		}
+
+		check.claims = append(check.claims, apitypes.GraphIssue{
+			ID:      c.ID,
+			Type:    claimObject,
+			Problem: apitypes.InvalidChainProblem,
+			Detail:  fmt.Sprintf("previous claim %s is not part of the key's claims", c.Body.Previous),
+		})
+	}
+}
+
+// signer returns the public key to verify an object's signature with. The
+// signing key must be trusted, and must not have been revoked before the
+// object was created. If created is nil, the caller must check whether the
+// key was revoked.
+func (v *graphVerifier) signer(sig primitive.Signature, created *time.Time) (ed25519.PublicKey, apitypes.GraphProblem, string) {
+	if sig.PublicKeyID == nil {
+		if v.registryKey == nil {
+			return nil, apitypes.UnknownSignerProblem, "signed without a public key"
+		}
+
+		return v.registryKey, "", ""
+	}
+
+	pks, ok := v.keys[*sig.PublicKeyID]
+	if !ok {
+		return nil, apitypes.UnknownSignerProblem, fmt.Sprintf("signed by unknown key %s", sig.PublicKeyID)
+	}
+
+	if pks.PublicKey.Body.KeyType != primitive.SigningKeyType {
+		return nil, apitypes.InvalidChainProblem,
+			fmt.Sprintf("signed by %s key %s", pks.PublicKey.Body.KeyType, sig.PublicKeyID)
+	}
+
+	check := v.checkKey(sig.PublicKeyID)
+	if !check.trusted() {
+		return nil, apitypes.InvalidChainProblem,
+			fmt.Sprintf("signing key %s failed verification", sig.PublicKeyID)
+	}
+
+	if check.revoked != nil && created != nil && created.After(*check.revoked) {
+		return nil, apitypes.RevokedSignerProblem,
+			fmt.Sprintf("signed by key %s, revoked at %s", sig.PublicKeyID,
+				check.revoked.Format(time.RFC3339))
+	}
+
+	return keyValue(pks.PublicKey), "", ""
+}
+
+// verifyObject checks the signature of an object created at the given time,
+// reporting it if it fails.
+func (v *graphVerifier) verifyObject(body identity.Immutable, id *identity.ID,
+	sig primitive.Signature, created *time.Time, objType, path string) bool {
+
+	v.verified++
+
+	key, problem, detail := v.signer(sig, created)
+	if problem == "" {
+		problem, detail = checkSignature(body, id, sig, key)
+	}
+
+	if problem != "" {
+		v.report(id, objType, path, problem, detail)
+		return false
+	}
+
+	return true
+}
+
+// verifyInOrg reports an object if it belongs to another org.
+func (v *graphVerifier) verifyInOrg(orgID, id *identity.ID, objType, path string) bool {
+	if sameID(orgID, v.orgID) {
+		return true
+	}
+
+	v.report(id, objType, path, apitypes.InvalidChainProblem, "belongs to another org")
+	return false
+}
+
+// verifyKeyringRef reports an object if it belongs to a keyring other than the
+// one holding it.
+func (v *graphVerifier) verifyKeyringRef(keyringID, expected, id *identity.ID, objType, path string) bool {
+	if sameID(keyringID, expected) {
+		return true
+	}
+
+	v.report(id, objType, path, apitypes.InvalidChainProblem,
+		fmt.Sprintf("belongs to keyring %s, not %s", keyringID, expected))
+	return false
+}
+
+// verifyKeyring verifies a keyring, returning false if it has already been
+// verified.
+func (v *graphVerifier) verifyKeyring(body identity.Immutable, base *primitive.BaseKeyring,
+	id *identity.ID, sig primitive.Signature) bool {
+
+	if _, ok := v.keyrings[*id]; ok {
+		return false
+	}
+
+	kr := &verifiedKeyring{
+		id:       id,
+		previous: base.Previous,
+		pathExp:  base.PathExp,
+		version:  base.KeyringVersion,
+		created:  base.Created,
+	}
+	v.keyrings[*id] = kr
+	v.keyringOrder = append(v.keyringOrder, kr)
+
+	path := pathExpString(base.PathExp)
+	if v.verifyInOrg(base.OrgID, id, keyringObject, path) {
+		v.verifyObject(body, id, sig, &base.Created, keyringObject, path)
+	}
+
+	return true
+}
+
+func (v *graphVerifier) verifyKeyringV1(ks *registry.KeyringSectionV1) {
+	kr := ks.Keyring
+	if kr == nil || kr.ID == nil || kr.Body == nil {
+		return
+	}
+
+	if !v.verifyKeyring(kr.Body, &kr.Body.BaseKeyring, kr.ID, kr.Signature) {
+		return
+	}
+
+	path := pathExpString(kr.Body.PathExp)
+	for _, m := range ks.Members {
+		if m.ID == nil || m.Body == nil {
+			continue
+		}
+
+		v.verifyMember(m.Body, m.ID, m.Signature, &m.Body.Created, m.Body.OrgID,
+			m.Body.KeyringID, m.Body.PublicKeyID, kr.ID, path)
+	}
+}
+
+func (v *graphVerifier) verifyKeyringV2(ks *registry.KeyringSectionV2) {
+	kr := ks.Keyring
+	if kr == nil || kr.ID == nil || kr.Body == nil {
+		return
+	}
+
+	if !v.verifyKeyring(kr.Body, &kr.Body.BaseKeyring, kr.ID, kr.Signature) {
+		return
+	}
+
+	path := pathExpString(kr.Body.PathExp)
+	ids := make(map[identity.ID]bool)
+	for _, m := range ks.Members {
+		member := m.Member
+		if member == nil || member.ID == nil || member.Body == nil {
+			continue
+		}
+		ids[*member.ID] = true
+
+		v.verifyMember(member.Body, member.ID, member.Signature, &member.Body.Created,
+			member.Body.OrgID, member.Body.KeyringID, member.Body.PublicKeyID, kr.ID, path)
+
+		share := m.MEKShare
+		if share == nil || share.ID == nil || share.Body == nil {
+			continue
+		}
+
+		if !v.verifyInOrg(share.Body.OrgID, share.ID, mekshareObject, path) ||
+			!v.verifyKeyringRef(share.Body.KeyringID, kr.ID, share.ID, mekshareObject, path) {
+			continue
+		}
+
+		if !sameID(share.Body.KeyringMemberID, member.ID) {
+			v.report(share.ID, mekshareObject, path, apitypes.InvalidChainProblem,
+				fmt.Sprintf("belongs to keyring member %s, not %s", share.Body.KeyringMemberID, member.ID))
+			continue
+		}
+
+		v.verifyObject(share.Body, share.ID, share.Signature, &share.Body.Created, mekshareObject, path)
+	}
+
+	for _, c := range ks.Claims {
+		if c.ID == nil || c.Body == nil {
+			continue
+		}
+		ids[*c.ID] = true
+	}
+
+	for _, c := range ks.Claims {
+		if c.ID == nil || c.Body == nil {
+			continue
+		}
+
+		if !v.verifyInOrg(c.Body.OrgID, c.ID, keyringMemberClaimObject, path) ||
+			!v.verifyKeyringRef(c.Body.KeyringID, kr.ID, c.ID, keyringMemberClaimObject, path) {
+			continue
+		}
+
+		// A member's claims follow the member, or one of its earlier claims.
+		if c.Body.Previous != nil && !ids[*c.Body.Previous] {
+			v.report(c.ID, keyringMemberClaimObject, path, apitypes.InvalidChainProblem,
+				fmt.Sprintf("previous object %s is not part of the keyring", c.Body.Previous))
+			continue
+		}
+
+		v.verifyObject(c.Body, c.ID, c.Signature, &c.Body.Created, keyringMemberClaimObject, path)
+	}
+}
+
+func (v *graphVerifier) verifyMember(body identity.Immutable, id *identity.ID,
+	sig primitive.Signature, created *time.Time, orgID, keyringID, publicKeyID,
+	expectedKeyringID *identity.ID, path string) {
+
+	if !v.verifyInOrg(orgID, id, keyringMemberObject, path) ||
+		!v.verifyKeyringRef(keyringID, expectedKeyringID, id, keyringMemberObject, path) {
+		return
+	}
+
+	if publicKeyID == nil {
+		v.report(id, keyringMemberObject, path, apitypes.InvalidChainProblem,
+			"shared without a public key")
+		return
+	}
+
+	if _, ok := v.keys[*publicKeyID]; !ok {
+		v.report(id, keyringMemberObject, path, apitypes.InvalidChainProblem,
+			fmt.Sprintf("shared with unknown key %s", publicKeyID))
+		return
+	}
+
+	v.verifyObject(body, id, sig, created, keyringMemberObject, path)
+}
+
+func (v *graphVerifier) verifyCredential(keyring envelope.KeyringInf, c envelope.CredentialInf) {
+	var body identity.Immutable
+	var base *primitive.BaseCredential
+	var sig primitive.Signature
+
+	switch cred := c.(type) {
+	case *envelope.CredentialV1:
+		body, base, sig = cred.Body, &cred.Body.BaseCredential, cred.Signature
+	case *envelope.Credential:
+		body, base, sig = cred.Body, &cred.Body.BaseCredential, cred.Signature
+	default:
+		return
+	}
+
+	id := c.GetID()
+	if id == nil {
+		return
+	}
+
+	if _, ok := v.credentials[*id]; ok {
+		return
+	}
+
+	vc := &verifiedCredential{
+		id:        id,
+		previous:  base.Previous,
+		keyringID: base.KeyringID,
+		pathExp:   base.PathExp,
+		name:      base.Name,
+		version:   base.CredentialVersion,
+	}
+	v.credentials[*id] = vc
+	v.credentialOrder = append(v.credentialOrder, vc)
+
+	path := pathExpString(base.PathExp) + "/" + base.Name
+	if !v.verifyInOrg(base.OrgID, id, credentialObject, path) ||
+		!v.verifyKeyringRef(base.KeyringID, keyring.GetID(), id, credentialObject, path) {
+		return
+	}
+
+	// Credentials don't record when they were created, so whether their
+	// signing key was revoked is checked once every keyring is known.
+	if v.verifyObject(body, id, sig, nil, credentialObject, path) && sig.PublicKeyID != nil {
+		vc.signerID = sig.PublicKeyID
+		vc.revoked = v.checkKey(sig.PublicKeyID).revoked
+	}
+}
+
+// checkKeyringChains checks that each keyring's previous link refers to the
+// version before it at the same path. Links to keyrings which weren't
+// verified can't be checked.
+func (v *graphVerifier) checkKeyringChains() {
+	seen := make(map[identity.ID]*identity.ID)
+	for _, kr := range v.keyringOrder {
+		path := pathExpString(kr.pathExp)
+
+		if kr.previous == nil {
+			if kr.version != 1 {
+				v.report(kr.id, keyringObject, path, apitypes.InvalidChainProblem,
+					fmt.Sprintf("version %d has no previous keyring", kr.version))
+			}
+			continue
+		}
+
+		if other, ok := seen[*kr.previous]; ok {
+			v.report(kr.id, keyringObject, path, apitypes.InvalidChainProblem,
+				fmt.Sprintf("shares its previous keyring %s with keyring %s", kr.previous, other))
+			continue
+		}
+		seen[*kr.previous] = kr.id
+
+		prev, ok := v.keyrings[*kr.previous]
+		if !ok {
+			continue
+		}
+
+		if prev.version+1 != kr.version || !samePathExp(prev.pathExp, kr.pathExp) {
+			v.report(kr.id, keyringObject, path, apitypes.InvalidChainProblem,
+				fmt.Sprintf("version %d does not follow previous keyring %s (version %d at %s)",
+					kr.version, prev.id, prev.version, pathExpString(prev.pathExp)))
+		}
+	}
+}
+
+// checkCredentialChains checks that each credential's previous link refers to
+// the version before it of the same credential. Links to credentials which
+// weren't verified can't be checked.
+func (v *graphVerifier) checkCredentialChains() {
+	seen := make(map[identity.ID]*identity.ID)
+	for _, c := range v.credentialOrder {
+		path := pathExpString(c.pathExp) + "/" + c.name
+
+		if c.previous == nil {
+			if c.version != 1 {
+				v.report(c.id, credentialObject, path, apitypes.InvalidChainProblem,
+					fmt.Sprintf("version %d has no previous credential", c.version))
+			}
+			continue
+		}
+
+		if other, ok := seen[*c.previous]; ok {
+			v.report(c.id, credentialObject, path, apitypes.InvalidChainProblem,
+				fmt.Sprintf("shares its previous credential %s with credential %s", c.previous, other))
+			continue
+		}
+		seen[*c.previous] = c.id
+
+		prev, ok := v.credentials[*c.previous]
+		if !ok {
+			continue
+		}
+
+		if prev.version+1 != c.version || !strings.EqualFold(prev.name, c.name) ||
+			!samePathExp(prev.pathExp, c.pathExp) {
+
+			v.report(c.id, credentialObject, path, apitypes.InvalidChainProblem,
+				fmt.Sprintf("version %d does not follow previous credential %s (version %d at %s/%s)",
+					c.version, prev.id, prev.version, pathExpString(prev.pathExp), prev.name))
+		}
+	}
+}
+
+// checkCredentialSigners reports credentials signed by a key that has been
+// revoked. A credential was created after its keyring, and before the next
+// version of its keyring, as new credentials are only set in the latest
+// version. Credentials in a keyring created after the revocation are reported
+// as signed by a revoked key. Those which can't be shown to have been created
+// before the revocation, as their keyring has no later version created before
+// it, are reported as warnings.
+func (v *graphVerifier) checkCredentialSigners() {
+	next := make(map[identity.ID]*verifiedKeyring)
+	for _, kr := range v.keyringOrder {
+		if kr.previous != nil {
+			next[*kr.previous] = kr
+		}
+	}
+
+	for _, c := range v.credentialOrder {
+		if c.revoked == nil {
+			continue
+		}
+
+		path := pathExpString(c.pathExp) + "/" + c.name
+		detail := fmt.Sprintf("signed by key %s, revoked at %s", c.signerID,
+			c.revoked.Format(time.RFC3339))
+
+		var kr *verifiedKeyring
+		if c.keyringID != nil {
+			kr = v.keyrings[*c.keyringID]
+		}
+
+		switch {
+		case kr != nil && kr.created.After(*c.revoked):
+			v.report(c.id, credentialObject, path, apitypes.RevokedSignerProblem, detail)
+		case kr != nil && next[*kr.id] != nil && !next[*kr.id].created.After(*c.revoked):
+			// Set before its keyring was replaced, before the revocation.
+		default:
+			v.warn(c.id, credentialObject, path, apitypes.RevokedSignerProblem,
+				detail+", and may have been set before then")
+		}
+	}
+}
+
+// checkSignature verifies that sig is a valid signature of body by key, and
+// that id was derived from them.
+func checkSignature(body identity.Immutable, id *identity.ID, sig primitive.Signature,
+	key ed25519.PublicKey) (apitypes.GraphProblem, string) {
+
+	if sig.Algorithm != crypto.EdDSA {
+		return apitypes.InvalidSignatureProblem, fmt.Sprintf("unsupported signature algorithm %q", sig.Algorithm)
+	}
+
+	if len(key) != ed25519.PublicKeySize {
+		return apitypes.InvalidSignatureProblem, "signing key is malformed"
+	}
+
+	if sig.Value == nil {
+		return apitypes.InvalidSignatureProblem, "signature is missing"
+	}
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		return apitypes.InvalidSignatureProblem, fmt.Sprintf("could not encode object: %s", err)
+	}
+
+	msg := append([]byte(strconv.Itoa(body.Version())), b...)
+	if !ed25519.Verify(key, msg, *sig.Value) {
+		return apitypes.InvalidSignatureProblem, "signature does not match"
+	}
+
+	expected, err := identity.NewImmutable(body, &sig)
+	if err != nil || id == nil || expected != *id {
+		return apitypes.InvalidIDProblem, "id was not derived from the object and its signature"
+	}
+
+	return "", ""
+}
+
+func keyValue(pubkey *envelope.PublicKey) ed25519.PublicKey {
+	if pubkey.Body.Key.Value == nil {
+		return nil
+	}
+
+	return ed25519.PublicKey(*pubkey.Body.Key.Value)
+}
+
+func sameID(a, b *identity.ID) bool {
+	return a != nil && b != nil && *a == *b
+}
+
+func samePathExp(a, b *pathexp.PathExp) bool {
+	return a != nil && b != nil && a.Equal(b)
+}
+
+func pathExpString(pe *pathexp.PathExp) string {
+	if pe == nil {
+		return ""
+	}
+
+	return pe.String()
+}
@@ -0,0 +1,451 @@
+package logic
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	gm "github.com/onsi/gomega"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/manifoldco/go-base64"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+	"github.com/manifoldco/torus-cli/registry"
+
+	"github.com/manifoldco/torus-cli/daemon/crypto"
+)
+
+// testSigner signs objects as crypto.Engine does, without needing a session.
+type testSigner struct {
+	id   *identity.ID
+	priv ed25519.PrivateKey
+}
+
+func (s *testSigner) sign(body identity.Immutable) (*identity.ID, primitive.Signature) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		panic(err)
+	}
+
+	var sigID *identity.ID
+	if s != nil && s.id != nil {
+		sigID = s.id
+	}
+
+	sig := primitive.Signature{
+		Algorithm:   crypto.EdDSA,
+		PublicKeyID: sigID,
+		Value:       base64.New(ed25519.Sign(s.priv, append([]byte(strconv.Itoa(body.Version())), b...))),
+	}
+
+	id, err := identity.NewImmutable(body, &sig)
+	if err != nil {
+		panic(err)
+	}
+
+	return &id, sig
+}
+
+type graphFixture struct {
+	orgID     *identity.ID
+	ownerID   *identity.ID
+	signer    *testSigner
+	sigKey    *apitypes.PublicKeySegment
+	claimtree *registry.ClaimTree
+	created   time.Time
+}
+
+func newGraphFixture() *graphFixture {
+	orgID, err := identity.NewMutable(&primitive.Org{Name: "myorg"})
+	if err != nil {
+		panic(err)
+	}
+
+	ownerID, err := identity.NewMutable(&primitive.User{})
+	if err != nil {
+		panic(err)
+	}
+
+	f := &graphFixture{
+		orgID:   &orgID,
+		ownerID: &ownerID,
+		created: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+
+	// Signing keys are self-signed, without a public key id.
+	self := &testSigner{priv: priv}
+	sigBody := &primitive.PublicKey{
+		Algorithm: crypto.EdDSA,
+		Created:   f.created,
+		Key:       primitive.PublicKeyValue{Value: base64.New(pub)},
+		OrgID:     f.orgID,
+		OwnerID:   f.ownerID,
+		KeyType:   primitive.SigningKeyType,
+	}
+	sigID, sig := self.sign(sigBody)
+	f.signer = &testSigner{id: sigID, priv: priv}
+
+	sigKey := apitypes.PublicKeySegment{
+		PublicKey: &envelope.PublicKey{ID: sigID, Version: 1, Body: sigBody, Signature: sig},
+	}
+	sigKey.Claims = []envelope.Claim{f.claim(sigID, sigID, primitive.SignatureClaimType, f.created)}
+
+	encPub := make([]byte, 32)
+	if _, err := rand.Read(encPub); err != nil {
+		panic(err)
+	}
+
+	encBody := &primitive.PublicKey{
+		Algorithm: crypto.Curve25519,
+		Created:   f.created,
+		Key:       primitive.PublicKeyValue{Value: base64.New(encPub)},
+		OrgID:     f.orgID,
+		OwnerID:   f.ownerID,
+		KeyType:   primitive.EncryptionKeyType,
+	}
+	encID, encSig := f.signer.sign(encBody)
+
+	encKey := apitypes.PublicKeySegment{
+		PublicKey: &envelope.PublicKey{ID: encID, Version: 1, Body: encBody, Signature: encSig},
+	}
+	encKey.Claims = []envelope.Claim{f.claim(encID, encID, primitive.SignatureClaimType, f.created)}
+
+	f.claimtree = &registry.ClaimTree{
+		Org:        &envelope.Org{ID: f.orgID},
+		PublicKeys: []apitypes.PublicKeySegment{sigKey, encKey},
+	}
+	f.sigKey = &f.claimtree.PublicKeys[0]
+
+	return f
+}
+
+func (f *graphFixture) claim(keyID, previous *identity.ID, t primitive.ClaimType,
+	created time.Time) envelope.Claim {
+
+	body := &primitive.Claim{
+		Created:     created,
+		OrgID:       f.orgID,
+		OwnerID:     f.ownerID,
+		Previous:    previous,
+		PublicKeyID: keyID,
+		ClaimType:   t,
+	}
+
+	id, sig := f.signer.sign(body)
+	return envelope.Claim{ID: id, Version: 1, Body: body, Signature: sig}
+}
+
+func (f *graphFixture) encKeyID() *identity.ID {
+	return f.claimtree.PublicKeys[1].PublicKey.ID
+}
+
+// revoke revokes the signing key at the given time.
+func (f *graphFixture) revoke(at time.Time) {
+	head := f.sigKey.Claims[len(f.sigKey.Claims)-1]
+	f.sigKey.Claims = append(f.sigKey.Claims,
+		f.claim(f.sigKey.PublicKey.ID, head.ID, primitive.RevocationClaimType, at))
+}
+
+func (f *graphFixture) keyring(previous *envelope.Keyring, created time.Time) *envelope.Keyring {
+	body := primitive.NewKeyring(f.orgID, f.orgID, mustPathExp("/myorg/api/production/*/*/*"))
+	body.Created = created
+	if previous != nil {
+		body.Previous = previous.ID
+		body.KeyringVersion = previous.Body.KeyringVersion + 1
+	}
+
+	id, sig := f.signer.sign(body)
+	return &envelope.Keyring{ID: id, Version: 2, Body: body, Signature: sig}
+}
+
+func (f *graphFixture) credential(keyring *envelope.Keyring, name string,
+	previous *envelope.Credential) *envelope.Credential {
+
+	state := "set"
+	body := &primitive.Credential{
+		State: &state,
+		BaseCredential: primitive.BaseCredential{
+			Credential: &primitive.CredentialValue{
+				Algorithm: crypto.SecretBox,
+				Nonce:     base64.New([]byte("nonce")),
+				Value:     base64.New([]byte("value")),
+			},
+			KeyringID:         keyring.ID,
+			Name:              name,
+			Nonce:             base64.New([]byte("nonce")),
+			OrgID:             f.orgID,
+			PathExp:           mustPathExp("/myorg/api/production/default/*/*"),
+			ProjectID:         f.orgID,
+			CredentialVersion: 1,
+		},
+	}
+	if previous != nil {
+		body.Previous = previous.ID
+		body.CredentialVersion = previous.Body.CredentialVersion + 1
+	}
+
+	id, sig := f.signer.sign(body)
+	return &envelope.Credential{ID: id, Version: 2, Body: body, Signature: sig}
+}
+
+func (f *graphFixture) graph(keyring *envelope.Keyring, creds ...*envelope.Credential) registry.CredentialGraph {
+	memberBody := &primitive.KeyringMember{
+		Created:         keyring.Body.Created,
+		EncryptingKeyID: f.encKeyID(),
+		KeyringID:       keyring.ID,
+		OrgID:           f.orgID,
+		OwnerID:         f.ownerID,
+		PublicKeyID:     f.encKeyID(),
+	}
+	memberID, memberSig := f.signer.sign(memberBody)
+
+	shareBody := &primitive.MEKShare{
+		Created:         keyring.Body.Created,
+		OrgID:           f.orgID,
+		OwnerID:         f.ownerID,
+		KeyringID:       keyring.ID,
+		KeyringMemberID: memberID,
+		Key: &primitive.KeyringMemberKey{
+			Algorithm: crypto.EasyBox,
+			Nonce:     base64.New([]byte("nonce")),
+			Value:     base64.New([]byte("value")),
+		},
+	}
+	shareID, shareSig := f.signer.sign(shareBody)
+
+	cg := &registry.CredentialGraphV2{
+		KeyringSectionV2: registry.KeyringSectionV2{
+			Keyring: keyring,
+			Members: []registry.KeyringMember{{
+				Member:   &envelope.KeyringMember{ID: memberID, Version: 2, Body: memberBody, Signature: memberSig},
+				MEKShare: &envelope.MEKShare{ID: shareID, Version: 1, Body: shareBody, Signature: shareSig},
+			}},
+		},
+	}
+
+	for _, c := range creds {
+		cg.Credentials = append(cg.Credentials, c)
+	}
+
+	return cg
+}
+
+func (f *graphFixture) verify(registryKey []byte, graphs ...registry.CredentialGraph) *apitypes.GraphVerifyResult {
+	v := newGraphVerifier(f.claimtree, registryKey)
+	v.VerifyClaimTree()
+	v.VerifyCredentialGraphs(graphs...)
+	return v.Result()
+}
+
+func problems(result *apitypes.GraphVerifyResult) map[string]apitypes.GraphProblem {
+	out := make(map[string]apitypes.GraphProblem)
+	for _, issue := range result.Issues {
+		out[issue.Type+" "+issue.ID.String()] = issue.Problem
+	}
+	return out
+}
+
+func TestGraphVerifier(t *testing.T) {
+	t.Run("valid graph", func(t *testing.T) {
+		gm.RegisterTestingT(t)
+
+		f := newGraphFixture()
+		kr1 := f.keyring(nil, f.created)
+		kr2 := f.keyring(kr1, f.created.Add(time.Hour))
+		c1 := f.credential(kr1, "password", nil)
+		c2 := f.credential(kr2, "password", c1)
+
+		result := f.verify(nil, f.graph(kr1, c1), f.graph(kr2, c2))
+		gm.Expect(result.Issues).To(gm.BeEmpty())
+
+		// 2 keys and their claims, then 2 keyrings, each with a member,
+		// mekshare, and credential.
+		gm.Expect(result.Verified).To(gm.Equal(12))
+	})
+
+	t.Run("tampered credential", func(t *testing.T) {
+		gm.RegisterTestingT(t)
+
+		f := newGraphFixture()
+		kr := f.keyring(nil, f.created)
+		c := f.credential(kr, "password", nil)
+		c.Body.Name = "token"
+
+		result := f.verify(nil, f.graph(kr, c))
+		gm.Expect(problems(result)).To(gm.Equal(map[string]apitypes.GraphProblem{
+			"credential " + c.ID.String(): apitypes.InvalidSignatureProblem,
+		}))
+	})
+
+	t.Run("substituted id", func(t *testing.T) {
+		gm.RegisterTestingT(t)
+
+		f := newGraphFixture()
+		kr := f.keyring(nil, f.created)
+		c := f.credential(kr, "password", nil)
+		c.ID = mustID("0b100000000000000000000000001")
+
+		result := f.verify(nil, f.graph(kr, c))
+		gm.Expect(problems(result)).To(gm.Equal(map[string]apitypes.GraphProblem{
+			"credential " + c.ID.String(): apitypes.InvalidIDProblem,
+		}))
+	})
+
+	t.Run("unknown signer", func(t *testing.T) {
+		gm.RegisterTestingT(t)
+
+		f := newGraphFixture()
+		kr := f.keyring(nil, f.created)
+
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		gm.Expect(err).To(gm.BeNil())
+
+		f.signer = &testSigner{id: mustID("06100000000000000000000000001"), priv: priv}
+		c := f.credential(kr, "password", nil)
+
+		result := f.verify(nil, f.graph(kr, c))
+		p := problems(result)
+		gm.Expect(p).To(gm.HaveKeyWithValue("credential "+c.ID.String(), apitypes.UnknownSignerProblem))
+	})
+
+	t.Run("registry signed", func(t *testing.T) {
+		gm.RegisterTestingT(t)
+
+		f := newGraphFixture()
+		kr := f.keyring(nil, f.created)
+		g := f.graph(kr)
+
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		gm.Expect(err).To(gm.BeNil())
+
+		registrySigner := &testSigner{priv: priv}
+		body := &primitive.KeyringMemberClaim{
+			OrgID:           f.orgID,
+			KeyringID:       kr.ID,
+			KeyringMemberID: g.(*registry.CredentialGraphV2).Members[0].Member.ID,
+			OwnerID:         f.ownerID,
+			Previous:        g.(*registry.CredentialGraphV2).Members[0].Member.ID,
+			ClaimType:       primitive.RevocationClaimType,
+			Created:         f.created,
+		}
+		id, sig := registrySigner.sign(body)
+		g.(*registry.CredentialGraphV2).Claims = []envelope.KeyringMemberClaim{
+			{ID: id, Version: 1, Body: body, Signature: sig},
+		}
+
+		result := f.verify(pub, g)
+		gm.Expect(result.Issues).To(gm.BeEmpty())
+
+		result = f.verify(nil, g)
+		gm.Expect(problems(result)).To(gm.Equal(map[string]apitypes.GraphProblem{
+			"keyring-member-claim " + id.String(): apitypes.UnknownSignerProblem,
+		}))
+	})
+
+	t.Run("revoked signer", func(t *testing.T) {
+		gm.RegisterTestingT(t)
+
+		f := newGraphFixture()
+		before := f.keyring(nil, f.created)
+		f.revoke(f.created.Add(time.Hour))
+		after := f.keyring(before, f.created.Add(2*time.Hour))
+
+		result := f.verify(nil, f.graph(before), f.graph(after))
+		p := problems(result)
+		gm.Expect(p).To(gm.HaveKeyWithValue("keyring "+after.ID.String(), apitypes.RevokedSignerProblem))
+		gm.Expect(p).ToNot(gm.HaveKey("keyring " + before.ID.String()))
+	})
+
+	t.Run("revoked credential signer", func(t *testing.T) {
+		gm.RegisterTestingT(t)
+
+		f := newGraphFixture()
+		old := f.keyring(nil, f.created)
+		oldCred := f.credential(old, "old", nil)
+		head := f.keyring(old, f.created.Add(time.Hour))
+		headCred := f.credential(head, "head", nil)
+		f.revoke(f.created.Add(2 * time.Hour))
+		after := f.keyring(head, f.created.Add(3*time.Hour))
+		afterCred := f.credential(after, "after", nil)
+
+		// Only the keyring created after the revocation is known to be
+		// too late; the head keyring's credentials may have been set
+		// before or after it.
+		result := f.verify(nil, f.graph(old, oldCred), f.graph(head, headCred))
+		gm.Expect(result.Issues).To(gm.HaveLen(1))
+		gm.Expect(result.Issues[0].ID).To(gm.Equal(headCred.ID))
+		gm.Expect(result.Issues[0].Problem).To(gm.Equal(apitypes.RevokedSignerProblem))
+		gm.Expect(result.Issues[0].Warning).To(gm.BeTrue())
+		gm.Expect(result.Failed()).To(gm.BeFalse())
+
+		result = f.verify(nil, f.graph(old, oldCred), f.graph(head, headCred),
+			f.graph(after, afterCred))
+		p := problems(result)
+		gm.Expect(p).To(gm.HaveKeyWithValue("credential "+afterCred.ID.String(), apitypes.RevokedSignerProblem))
+		gm.Expect(p).ToNot(gm.HaveKey("credential " + oldCred.ID.String()))
+		gm.Expect(result.Failed()).To(gm.BeTrue())
+		for _, issue := range result.Issues {
+			gm.Expect(issue.Warning).To(gm.Equal(*issue.ID == *headCred.ID))
+		}
+	})
+
+	t.Run("broken claim chain", func(t *testing.T) {
+		gm.RegisterTestingT(t)
+
+		f := newGraphFixture()
+		f.sigKey.Claims = append(f.sigKey.Claims, f.claim(f.sigKey.PublicKey.ID,
+			f.encKeyID(), primitive.RevocationClaimType, f.created))
+		orphan := f.sigKey.Claims[1]
+
+		kr := f.keyring(nil, f.created)
+		result := f.verify(nil, f.graph(kr))
+		p := problems(result)
+		gm.Expect(p).To(gm.HaveKeyWithValue("claim "+orphan.ID.String(), apitypes.InvalidChainProblem))
+
+		// The signing key can no longer be trusted.
+		gm.Expect(p).To(gm.HaveKeyWithValue("keyring "+kr.ID.String(), apitypes.InvalidChainProblem))
+	})
+
+	t.Run("self-signed encryption key", func(t *testing.T) {
+		gm.RegisterTestingT(t)
+
+		f := newGraphFixture()
+		enc := &f.claimtree.PublicKeys[1]
+		enc.PublicKey.Signature.PublicKeyID = nil
+
+		result := f.verify(nil)
+		gm.Expect(problems(result)).To(gm.HaveKeyWithValue(
+			"public-key "+enc.PublicKey.ID.String(), apitypes.InvalidChainProblem))
+	})
+
+	t.Run("inconsistent previous links", func(t *testing.T) {
+		gm.RegisterTestingT(t)
+
+		f := newGraphFixture()
+		kr1 := f.keyring(nil, f.created)
+		kr2 := f.keyring(kr1, f.created.Add(time.Hour))
+		c1 := f.credential(kr1, "password", nil)
+		c2 := f.credential(kr1, "password", c1)
+
+		// A third version which skips the second, and a fork of the first.
+		c3 := f.credential(kr2, "password", c1)
+		c3.Body.CredentialVersion = 3
+		c3.ID, c3.Signature = f.signer.sign(c3.Body)
+
+		result := f.verify(nil, f.graph(kr1, c1, c2), f.graph(kr2, c3))
+		gm.Expect(problems(result)).To(gm.Equal(map[string]apitypes.GraphProblem{
+			"credential " + c3.ID.String(): apitypes.InvalidChainProblem,
+		}))
+	})
+}
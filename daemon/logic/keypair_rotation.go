@@ -0,0 +1,83 @@
+package logic
+
+import (
+	"sort"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+	"github.com/manifoldco/torus-cli/registry"
+)
+
+var errTooManyKeypairs = &apitypes.Error{
+	Type: apitypes.BadRequestError,
+	Err:  []string{"More than two valid signing keypairs exist for this org; cannot rotate."},
+}
+
+// keypairRotation holds the keypairs involved in rotating the user's keypairs
+// for an org.
+//
+// A rotation is in progress while two signing keypairs are valid. The new
+// keypairs are nil until they've been uploaded, and the old encryption
+// keypair is nil once it has been revoked.
+type keypairRotation struct {
+	oldSig *registry.ClaimedKeyPair
+	oldEnc *registry.ClaimedKeyPair
+	newSig *registry.ClaimedKeyPair
+	newEnc *registry.ClaimedKeyPair
+}
+
+// findKeypairRotation finds the state of a keypair rotation for the given org
+// from the user's keypairs.
+//
+// The oldest valid signing keypair is being rotated out, and the newest (if
+// any) is replacing it. Encryption keypairs are matched to the signing keypair
+// which signed them.
+func findKeypairRotation(keypairs []registry.ClaimedKeyPair, orgID *identity.ID) (*keypairRotation, error) {
+	var sigs, encs []registry.ClaimedKeyPair
+	for _, k := range keypairs {
+		if *k.PublicKey.Body.OrgID != *orgID || k.Revoked() {
+			continue
+		}
+
+		switch k.PublicKey.Body.KeyType {
+		case primitive.SigningKeyType:
+			sigs = append(sigs, k)
+		case primitive.EncryptionKeyType:
+			encs = append(encs, k)
+		}
+	}
+
+	switch len(sigs) {
+	case 0:
+		return nil, registry.ErrMissingValidKeypair
+	case 1, 2:
+	default:
+		return nil, errTooManyKeypairs
+	}
+
+	sort.Slice(sigs, func(i, j int) bool {
+		return sigs[i].PublicKey.Body.Created.Before(sigs[j].PublicKey.Body.Created)
+	})
+
+	r := &keypairRotation{oldSig: &sigs[0]}
+	if len(sigs) == 2 {
+		r.newSig = &sigs[1]
+	}
+
+	for i, k := range encs {
+		signer := k.PublicKey.Signature.PublicKeyID
+		if signer == nil {
+			continue
+		}
+
+		switch {
+		case *signer == *r.oldSig.PublicKey.ID:
+			r.oldEnc = &encs[i]
+		case r.newSig != nil && *signer == *r.newSig.PublicKey.ID:
+			r.newEnc = &encs[i]
+		}
+	}
+
+	return r, nil
+}
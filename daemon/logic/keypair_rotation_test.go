@@ -0,0 +1,134 @@
+package logic
+
+import (
+	"testing"
+	"time"
+
+	gm "github.com/onsi/gomega"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+	"github.com/manifoldco/torus-cli/registry"
+)
+
+func buildKeypair(orgID *identity.ID, t primitive.KeyType, created time.Time,
+	signer *registry.ClaimedKeyPair, revoked bool) registry.ClaimedKeyPair {
+
+	pubk := &envelope.PublicKey{
+		Version: 1,
+		Body: &primitive.PublicKey{
+			OrgID:   orgID,
+			KeyType: t,
+			Created: created,
+		},
+	}
+
+	pubkID, err := identity.NewImmutable(pubk.Body, "haha")
+	if err != nil {
+		panic(err)
+	}
+	pubk.ID = &pubkID
+
+	pubk.Signature.PublicKeyID = pubk.ID
+	if signer != nil {
+		pubk.Signature.PublicKeyID = signer.PublicKey.ID
+	}
+
+	ckp := registry.ClaimedKeyPair{
+		PublicKeySegment: apitypes.PublicKeySegment{PublicKey: pubk},
+	}
+
+	if revoked {
+		ckp.Claims = append(ckp.Claims, envelope.Claim{
+			Version: 1,
+			Body: &primitive.Claim{
+				OrgID:       orgID,
+				PublicKeyID: pubk.ID,
+				ClaimType:   primitive.RevocationClaimType,
+			},
+		})
+	}
+
+	return ckp
+}
+
+func TestFindKeypairRotation(t *testing.T) {
+	orgID, err := identity.NewMutable(&primitive.Org{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	then := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := then.Add(24 * time.Hour)
+
+	oldSig := buildKeypair(&orgID, primitive.SigningKeyType, then, nil, false)
+	oldEnc := buildKeypair(&orgID, primitive.EncryptionKeyType, then, &oldSig, false)
+	newSig := buildKeypair(&orgID, primitive.SigningKeyType, now, nil, false)
+	newEnc := buildKeypair(&orgID, primitive.EncryptionKeyType, now, &newSig, false)
+
+	t.Run("not started", func(t *testing.T) {
+		gm.RegisterTestingT(t)
+
+		rot, err := findKeypairRotation([]registry.ClaimedKeyPair{oldEnc, oldSig}, &orgID)
+		gm.Expect(err).To(gm.BeNil())
+		gm.Expect(rot.oldSig.PublicKey.ID).To(gm.Equal(oldSig.PublicKey.ID))
+		gm.Expect(rot.oldEnc.PublicKey.ID).To(gm.Equal(oldEnc.PublicKey.ID))
+		gm.Expect(rot.newSig).To(gm.BeNil())
+		gm.Expect(rot.newEnc).To(gm.BeNil())
+	})
+
+	t.Run("interrupted before encryption key upload", func(t *testing.T) {
+		gm.RegisterTestingT(t)
+
+		rot, err := findKeypairRotation([]registry.ClaimedKeyPair{newSig, oldEnc, oldSig}, &orgID)
+		gm.Expect(err).To(gm.BeNil())
+		gm.Expect(rot.oldSig.PublicKey.ID).To(gm.Equal(oldSig.PublicKey.ID))
+		gm.Expect(rot.oldEnc.PublicKey.ID).To(gm.Equal(oldEnc.PublicKey.ID))
+		gm.Expect(rot.newSig.PublicKey.ID).To(gm.Equal(newSig.PublicKey.ID))
+		gm.Expect(rot.newEnc).To(gm.BeNil())
+	})
+
+	t.Run("interrupted after encryption key revocation", func(t *testing.T) {
+		gm.RegisterTestingT(t)
+
+		revokedEnc := buildKeypair(&orgID, primitive.EncryptionKeyType, then, &oldSig, true)
+		rot, err := findKeypairRotation([]registry.ClaimedKeyPair{newEnc, revokedEnc, newSig, oldSig}, &orgID)
+		gm.Expect(err).To(gm.BeNil())
+		gm.Expect(rot.oldSig.PublicKey.ID).To(gm.Equal(oldSig.PublicKey.ID))
+		gm.Expect(rot.oldEnc).To(gm.BeNil())
+		gm.Expect(rot.newSig.PublicKey.ID).To(gm.Equal(newSig.PublicKey.ID))
+		gm.Expect(rot.newEnc.PublicKey.ID).To(gm.Equal(newEnc.PublicKey.ID))
+	})
+
+	t.Run("ignores revoked and other org keys", func(t *testing.T) {
+		gm.RegisterTestingT(t)
+
+		otherOrgID, err := identity.NewMutable(&primitive.Org{})
+		gm.Expect(err).To(gm.BeNil())
+
+		revokedSig := buildKeypair(&orgID, primitive.SigningKeyType, then.Add(-time.Hour), nil, true)
+		otherSig := buildKeypair(&otherOrgID, primitive.SigningKeyType, now, nil, false)
+
+		rot, err := findKeypairRotation([]registry.ClaimedKeyPair{revokedSig, otherSig, oldSig}, &orgID)
+		gm.Expect(err).To(gm.BeNil())
+		gm.Expect(rot.oldSig.PublicKey.ID).To(gm.Equal(oldSig.PublicKey.ID))
+		gm.Expect(rot.newSig).To(gm.BeNil())
+	})
+
+	t.Run("errors without a valid signing key", func(t *testing.T) {
+		gm.RegisterTestingT(t)
+
+		_, err := findKeypairRotation([]registry.ClaimedKeyPair{oldEnc}, &orgID)
+		gm.Expect(err).To(gm.Equal(registry.ErrMissingValidKeypair))
+	})
+
+	t.Run("errors with too many signing keys", func(t *testing.T) {
+		gm.RegisterTestingT(t)
+
+		extraSig := buildKeypair(&orgID, primitive.SigningKeyType, now.Add(time.Hour), nil, false)
+		_, err := findKeypairRotation([]registry.ClaimedKeyPair{oldSig, newSig, extraSig}, &orgID)
+		gm.Expect(err).To(gm.Equal(errTooManyKeypairs))
+	})
+}
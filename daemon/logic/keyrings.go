@@ -0,0 +1,157 @@
+package logic
+
+import (
+	"context"
+	"log"
+	"sort"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/registry"
+
+	"github.com/manifoldco/torus-cli/daemon/observer"
+)
+
+// RekeyKeyrings creates a new version of each keyring matching the given
+// PathExp, with new key material shared only with its current members, and
+// re-encrypts the keyring's active credentials into it. Their values are left
+// unchanged.
+//
+// Only keyrings whose active credentials could be decrypted by a member whose
+// access has since been revoked are rekeyed, unless force is true.
+func (e *Engine) RekeyKeyrings(ctx context.Context, notifier *observer.Notifier,
+	pe *pathexp.PathExp, force bool) ([]apitypes.RekeyedKeyring, error) {
+
+	graphs, err := e.client.CredentialGraph.Search(ctx, pe.String(), e.session.AuthID(), nil)
+	if err != nil {
+		log.Printf("error retrieving credential graphs: %s", err)
+		return nil, err
+	}
+
+	cgs := newCredentialGraphSet()
+	err = cgs.Add(graphs...)
+	if err != nil {
+		return nil, err
+	}
+
+	heads, err := cgs.NeedRekey(force)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(heads) == 0 {
+		return nil, nil
+	}
+
+	n := notifier.Notifier(2 + uint(len(heads)))
+
+	raw := pe.String()
+	plaintext, err := e.retrieveCredentials(ctx, n, nil, &raw, nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	n.Notify(observer.Progress, "Credentials decrypted", true)
+
+	// Group the active credentials by the keyring holding them.
+	byKeyring := make(map[string][]*PlaintextCredentialEnvelope)
+	for i := range plaintext {
+		c := &plaintext[i]
+		head, err := cgs.Head(c.Body.PathExp)
+		if err != nil {
+			return nil, err
+		}
+		if head == nil {
+			continue
+		}
+
+		key := head.GetKeyring().PathExp().String()
+		byKeyring[key] = append(byKeyring[key], c)
+	}
+
+	orgID := heads[0].GetKeyring().OrgID()
+
+	keypairs, err := e.client.KeyPairs.List(ctx, orgID)
+	if err != nil {
+		log.Printf("Error fetching keypairs: %s", err)
+		return nil, err
+	}
+
+	sigID, encID, kp, err := fetchKeyPairs(keypairs, orgID)
+	if err != nil {
+		log.Printf("Error fetching keypairs: %s", err)
+		return nil, err
+	}
+
+	claimtree, err := e.client.ClaimTree.Get(ctx, orgID, nil)
+	if err != nil {
+		log.Printf("Error fetching claimtree for org[%s]: %s", orgID, err)
+		return nil, err
+	}
+
+	n.Notify(observer.Progress, "Keypairs retrieved", true)
+
+	// Always rekey the keyrings in a consistent order.
+	sort.Slice(heads, func(i, j int) bool {
+		return heads[i].GetKeyring().PathExp().String() < heads[j].GetKeyring().PathExp().String()
+	})
+
+	var rekeyed []apitypes.RekeyedKeyring
+	for _, head := range heads {
+		creds := byKeyring[head.GetKeyring().PathExp().String()]
+		if len(creds) == 0 {
+			n.Notify(observer.Progress, "Keyring skipped", true)
+			continue
+		}
+
+		newGraph, err := createCredentialGraph(ctx, creds[0].Body, head,
+			sigID, encID, kp, claimtree, e.client, e.crypto, e.guard)
+		if err != nil {
+			log.Printf("error creating credential graph: %s", err)
+			return rekeyed, err
+		}
+
+		krm, mekshare, err := newGraph.FindMember(e.session.AuthID())
+		if err != nil {
+			log.Printf("Error finding keyring membership: %s", err)
+			return rekeyed, err
+		}
+
+		encKeySegment, err := claimtree.Find(krm.EncryptingKeyID, true)
+		if err != nil {
+			log.Printf("Error finding encrypting key[%s]: %s", krm.EncryptingKeyID, err)
+			return rekeyed, err
+		}
+		encryptingKey := encKeySegment.PublicKey.Body
+
+		toCreate := make([]envelope.CredentialInf, 0, len(creds))
+		for _, c := range creds {
+			// The value is re-encrypted as-is, keeping the time it was set.
+			signed, err := e.boxCredential(ctx, cgs, newGraph, c, c.Body.Value,
+				mekshare, kp, encryptingKey, sigID)
+			if err != nil {
+				return rekeyed, err
+			}
+
+			toCreate = append(toCreate, signed)
+		}
+
+		newGraph.Credentials = toCreate
+		var graph registry.CredentialGraph = newGraph
+		_, err = e.client.CredentialGraph.Post(ctx, &graph)
+		if err != nil {
+			log.Printf("error creating credential graph: %s", err)
+			return rekeyed, err
+		}
+
+		rekeyed = append(rekeyed, apitypes.RekeyedKeyring{
+			PathExp:        newGraph.GetKeyring().PathExp(),
+			KeyringVersion: newGraph.KeyringVersion(),
+			Credentials:    len(toCreate),
+		})
+		n.Notify(observer.Progress, "Keyring rekeyed", true)
+	}
+
+	return rekeyed, nil
+}
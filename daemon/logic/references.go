@@ -0,0 +1,207 @@
+package logic
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/pathexp"
+)
+
+// referenceFetcher retrieves the decrypted, but unresolved, credentials at
+// the given PathExp.
+type referenceFetcher func(pe *pathexp.PathExp) ([]PlaintextCredentialEnvelope, error)
+
+// referenceScope holds the credentials visible to a reference, by name. The
+// id distinguishes the scope of the path being read from those of referenced
+// paths.
+type referenceScope struct {
+	id    string
+	creds map[string]*PlaintextCredentialEnvelope
+}
+
+// newReferenceScope returns the scope for the given credentials. Where there
+// are many credentials of the same name, the most specific is used.
+func newReferenceScope(id string, creds []PlaintextCredentialEnvelope) *referenceScope {
+	scope := &referenceScope{id: id, creds: make(map[string]*PlaintextCredentialEnvelope)}
+	for i, cred := range creds {
+		name := cred.Body.Name
+		if existing, ok := scope.creds[name]; ok {
+			if cred.Body.PathExp.CompareSpecificity(existing.Body.PathExp) != 1 {
+				continue
+			}
+		}
+
+		scope.creds[name] = &creds[i]
+	}
+
+	return scope
+}
+
+// referenceResolver interpolates the values of reference credentials.
+//
+// References without a path are looked up in the scope of the credential
+// being resolved. References with a path are fetched on demand, using the
+// current user's access, and must match the referenced path exactly.
+type referenceResolver struct {
+	fetch    referenceFetcher
+	readPath bool
+	scopes   map[string]*referenceScope
+	resolved map[string]string
+	visiting map[string]bool
+}
+
+// newReferenceResolver returns a resolver for credentials read from a single
+// path if readPath is true, or found by searching a PathExp otherwise.
+func newReferenceResolver(fetch referenceFetcher, readPath bool) *referenceResolver {
+	return &referenceResolver{
+		fetch:    fetch,
+		readPath: readPath,
+		scopes:   make(map[string]*referenceScope),
+		resolved: make(map[string]string),
+		visiting: make(map[string]bool),
+	}
+}
+
+// ResolveAll replaces the value of every reference credential in creds with
+// its resolved value, keeping the unresolved value in the Reference field.
+//
+// A credential whose references can't be resolved keeps its unresolved value,
+// with the reason recorded in the Unresolved field, rather than failing the
+// others.
+func (r *referenceResolver) ResolveAll(creds []PlaintextCredentialEnvelope) error {
+	for i, cred := range creds {
+		cv, err := extractCredentialValue([]byte(cred.Body.Value))
+		if err != nil {
+			return err
+		}
+		if !cv.IsReference() {
+			continue
+		}
+
+		v, err := r.resolve(r.localScope(creds, cred.Body.PathExp), &creds[i])
+		if err != nil {
+			log.Printf("error resolving credential reference: %s", err)
+			creds[i].Body.Unresolved = err.Error()
+			continue
+		}
+
+		resolved := apitypes.NewStringCredentialValue(v).WithMetadata(cv.Metadata()).WithSetAt(cv.SetAt())
+		b, err := resolved.MarshalJSON()
+		if err != nil {
+			return err
+		}
+
+		value, err := strconv.Unquote(string(b))
+		if err != nil {
+			return err
+		}
+
+		creds[i].Body.Reference = cv.String()
+		creds[i].Body.Value = value
+	}
+
+	return nil
+}
+
+// resolve returns the plain value of the given credential, interpolating it if
+// it is a reference.
+func (r *referenceResolver) resolve(scope *referenceScope, cred *PlaintextCredentialEnvelope) (string, error) {
+	path := cred.Body.PathExp.String() + "/" + cred.Body.Name
+	key := scope.id + "|" + path
+	if v, ok := r.resolved[key]; ok {
+		return v, nil
+	}
+
+	cv, err := extractCredentialValue([]byte(cred.Body.Value))
+	if err != nil {
+		return "", err
+	}
+	if !cv.IsReference() {
+		return cv.String(), nil
+	}
+
+	if r.visiting[key] {
+		return "", fmt.Errorf("Reference cycle detected at %s", path)
+	}
+	r.visiting[key] = true
+	defer delete(r.visiting, key)
+
+	v, err := cv.Interpolate(func(ref *apitypes.CredentialReference) (string, error) {
+		refScope := scope
+		if ref.PathExp != nil {
+			s, err := r.scope(ref.PathExp)
+			if err != nil {
+				return "", fmt.Errorf("Could not resolve %s in %s: %s", ref, path, err)
+			}
+			refScope = s
+		}
+
+		target, ok := refScope.creds[ref.Name]
+		if !ok {
+			return "", fmt.Errorf("Could not resolve %s in %s: credential not found, or you do not have access to it", ref, path)
+		}
+
+		return r.resolve(refScope, target)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	r.resolved[key] = v
+	return v, nil
+}
+
+// localScope returns the credentials visible to references without a path in
+// a credential at the given PathExp.
+//
+// Every credential read from a single path applies to it, so they share one
+// scope. Credentials found by a search may apply to different paths, so only
+// those which apply wherever the referencing credential does are visible.
+func (r *referenceResolver) localScope(creds []PlaintextCredentialEnvelope, pe *pathexp.PathExp) *referenceScope {
+	id := "|"
+	if !r.readPath {
+		id += pe.String()
+	}
+	if scope, ok := r.scopes[id]; ok {
+		return scope
+	}
+
+	visible := creds
+	if !r.readPath {
+		visible = nil
+		for _, cred := range creds {
+			if cred.Body.PathExp.Contains(pe) {
+				visible = append(visible, cred)
+			}
+		}
+	}
+
+	scope := newReferenceScope(id, visible)
+	r.scopes[id] = scope
+	return scope
+}
+
+// scope returns the credentials set exactly at the given PathExp.
+func (r *referenceResolver) scope(pe *pathexp.PathExp) (*referenceScope, error) {
+	if scope, ok := r.scopes[pe.String()]; ok {
+		return scope, nil
+	}
+
+	creds, err := r.fetch(pe)
+	if err != nil {
+		return nil, err
+	}
+
+	var exact []PlaintextCredentialEnvelope
+	for _, cred := range creds {
+		if cred.Body.PathExp.Equal(pe) {
+			exact = append(exact, cred)
+		}
+	}
+
+	scope := newReferenceScope(pe.String(), exact)
+	r.scopes[pe.String()] = scope
+	return scope, nil
+}
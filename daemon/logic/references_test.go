@@ -0,0 +1,183 @@
+package logic
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/pathexp"
+)
+
+func plaintextCred(t *testing.T, pe, name string, cv *apitypes.CredentialValue) PlaintextCredentialEnvelope {
+	p, err := pathexp.Parse(pe)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(cv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := strconv.Unquote(string(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return PlaintextCredentialEnvelope{
+		Body: &PlaintextCredential{Name: name, PathExp: p, Value: v},
+	}
+}
+
+func credValue(t *testing.T, cred PlaintextCredentialEnvelope) string {
+	cv, err := extractCredentialValue([]byte(cred.Body.Value))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cv.String()
+}
+
+func TestReferenceResolver(t *testing.T) {
+	shared := "/o/shared/prod/*/*/*"
+	fetch := func(pe *pathexp.PathExp) ([]PlaintextCredentialEnvelope, error) {
+		if pe.String() != shared {
+			return nil, errors.New("unexpected fetch of " + pe.String())
+		}
+
+		return []PlaintextCredentialEnvelope{
+			plaintextCred(t, shared, "db_host", apitypes.NewStringCredentialValue("db.internal")),
+			plaintextCred(t, "/o/shared/prod/api/*/*", "db_host", apitypes.NewStringCredentialValue("wrong")),
+		}, nil
+	}
+
+	t.Run("resolves local and cross-path references", func(t *testing.T) {
+		creds := []PlaintextCredentialEnvelope{
+			plaintextCred(t, "/o/p/*/*/*/*", "db_user", apitypes.NewStringCredentialValue("default")),
+			plaintextCred(t, "/o/p/prod/*/*/*", "db_user", apitypes.NewStringCredentialValue("admin")),
+			plaintextCred(t, "/o/p/*/*/*/*", "db_port", apitypes.NewIntCredentialValue(5432)),
+			plaintextCred(t, "/o/p/*/*/*/*", "database_url", apitypes.NewReferenceCredentialValue(
+				"postgres://${DB_USER}@${"+shared+"/DB_HOST}:${db_port}/app")),
+		}
+
+		err := newReferenceResolver(fetch, true).ResolveAll(creds)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		expected := "postgres://admin@db.internal:5432/app"
+		if v := credValue(t, creds[3]); v != expected {
+			t.Errorf("expected %q, got %q", expected, v)
+		}
+
+		if !strings.HasPrefix(creds[3].Body.Reference, "postgres://${DB_USER}") {
+			t.Errorf("expected unresolved reference to be kept, got %q", creds[3].Body.Reference)
+		}
+
+		if creds[0].Body.Reference != "" {
+			t.Errorf("expected no reference for a plain credential")
+		}
+	})
+
+	t.Run("resolves chained references", func(t *testing.T) {
+		creds := []PlaintextCredentialEnvelope{
+			plaintextCred(t, "/o/p/*/*/*/*", "a", apitypes.NewReferenceCredentialValue("${b}!")),
+			plaintextCred(t, "/o/p/*/*/*/*", "b", apitypes.NewReferenceCredentialValue("${c}${c}")),
+			plaintextCred(t, "/o/p/*/*/*/*", "c", apitypes.NewStringCredentialValue("x")),
+		}
+
+		err := newReferenceResolver(fetch, true).ResolveAll(creds)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if v := credValue(t, creds[0]); v != "xx!" {
+			t.Errorf("expected %q, got %q", "xx!", v)
+		}
+	})
+
+	t.Run("scopes searched credentials by their paths", func(t *testing.T) {
+		creds := []PlaintextCredentialEnvelope{
+			plaintextCred(t, "/o/p/*/*/*/*", "db_user", apitypes.NewStringCredentialValue("default")),
+			plaintextCred(t, "/o/p/prod/*/*/*", "db_user", apitypes.NewStringCredentialValue("admin")),
+			plaintextCred(t, "/o/p/*/*/*/*", "greeting", apitypes.NewReferenceCredentialValue("hi ${db_user}")),
+			plaintextCred(t, "/o/p/prod/*/*/*", "greeting", apitypes.NewReferenceCredentialValue("hey ${db_user}")),
+			plaintextCred(t, "/o/p/dev/*/*/*", "greeting", apitypes.NewReferenceCredentialValue("yo ${db_user}")),
+		}
+
+		err := newReferenceResolver(fetch, false).ResolveAll(creds)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		for i, expected := range map[int]string{2: "hi default", 3: "hey admin", 4: "yo default"} {
+			if v := credValue(t, creds[i]); v != expected {
+				t.Errorf("expected %q, got %q", expected, v)
+			}
+		}
+	})
+
+	t.Run("detects cycles", func(t *testing.T) {
+		creds := []PlaintextCredentialEnvelope{
+			plaintextCred(t, "/o/p/*/*/*/*", "a", apitypes.NewReferenceCredentialValue("${b}")),
+			plaintextCred(t, "/o/p/*/*/*/*", "b", apitypes.NewReferenceCredentialValue("${a}")),
+		}
+
+		err := newReferenceResolver(fetch, true).ResolveAll(creds)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		for _, cred := range creds {
+			if !strings.Contains(cred.Body.Unresolved, "Reference cycle detected") {
+				t.Errorf("expected a cycle to be recorded, got %q", cred.Body.Unresolved)
+			}
+		}
+	})
+
+	t.Run("leaves dangling references unresolved", func(t *testing.T) {
+		creds := []PlaintextCredentialEnvelope{
+			plaintextCred(t, "/o/p/*/*/*/*", "a", apitypes.NewReferenceCredentialValue("${missing}")),
+			plaintextCred(t, "/o/p/*/*/*/*", "b", apitypes.NewReferenceCredentialValue("${c}")),
+			plaintextCred(t, "/o/p/*/*/*/*", "c", apitypes.NewStringCredentialValue("x")),
+		}
+
+		err := newReferenceResolver(fetch, true).ResolveAll(creds)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		expected := "Could not resolve ${missing} in /o/p/*/*/*/*/a: credential not found, or you do not have access to it"
+		if creds[0].Body.Unresolved != expected {
+			t.Errorf("expected %q, got %q", expected, creds[0].Body.Unresolved)
+		}
+		if v := credValue(t, creds[0]); v != "${missing}" {
+			t.Errorf("expected the unresolved value to be kept, got %q", v)
+		}
+
+		if creds[1].Body.Unresolved != "" {
+			t.Errorf("unexpected unresolved reference: %s", creds[1].Body.Unresolved)
+		}
+		if v := credValue(t, creds[1]); v != "x" {
+			t.Errorf("expected %q, got %q", "x", v)
+		}
+	})
+
+	t.Run("reports fetch errors", func(t *testing.T) {
+		creds := []PlaintextCredentialEnvelope{
+			plaintextCred(t, "/o/p/*/*/*/*", "a", apitypes.NewReferenceCredentialValue("${/o/other/*/*/*/*/b}")),
+		}
+
+		err := newReferenceResolver(fetch, true).ResolveAll(creds)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if !strings.Contains(creds[0].Body.Unresolved, "unexpected fetch of /o/other/*/*/*/*") {
+			t.Errorf("expected a fetch error, got %q", creds[0].Body.Unresolved)
+		}
+	})
+}
@@ -2,6 +2,7 @@ package logic
 
 import (
 	"context"
+	"crypto/subtle"
 	"fmt"
 	"log"
 
@@ -106,6 +107,59 @@ func (s *Session) Verify(ctx context.Context, code string) error {
 // into Torus which also allows them to access their stored and encrypted
 // secrets.
 func (s *Session) UpdateProfile(ctx context.Context, newEmail, newName, newPassword string) (envelope.UserInf, error) {
+	_, err := s.currentUser()
+	if err != nil {
+		return nil, err
+	}
+
+	payload := &updateProfile{}
+	if newEmail != "" {
+		payload.Email = newEmail
+	}
+
+	if newName != "" {
+		payload.Name = newName
+	}
+
+	if newPassword != "" {
+		pw, master, keypair, err := s.engine.crypto.ChangePassword(ctx, newPassword)
+		if err != nil {
+			log.Printf("Could not re-encrypt master key: %s", err)
+			return nil, &apitypes.Error{
+				Type: apitypes.InternalServerError,
+				Err:  []string{"Could not re-encrypt master key"},
+			}
+		}
+
+		payload.Password = pw
+		payload.Master = master
+		payload.PublicKey = keypair
+	}
+
+	updatedUser, err := s.engine.client.Users.Update(ctx, payload)
+	if err != nil {
+		log.Printf("Could not update password on server due to err: %s", err)
+		return nil, err
+	}
+
+	s.engine.session.SetIdentity(apitypes.UserSession, updatedUser, updatedUser)
+
+	// The master key is now encrypted with the new password, so it becomes
+	// the session's passphrase. This keeps the user logged in.
+	if newPassword != "" {
+		err = s.engine.session.SetPassphrase([]byte(newPassword))
+		if err != nil {
+			log.Printf("Could not update session passphrase: %s", err)
+			return nil, err
+		}
+	}
+
+	return updatedUser, nil
+}
+
+// currentUser returns the logged in user, ensuring the session belongs to a
+// user whose password may be changed.
+func (s *Session) currentUser() (envelope.UserInf, error) {
 	if s.engine.session.Type() != apitypes.UserSession {
 		return nil, &apitypes.Error{
 			Type: apitypes.BadRequestError,
@@ -134,33 +188,194 @@ func (s *Session) UpdateProfile(ctx context.Context, newEmail, newName, newPassw
 		}
 	}
 
-	payload := &updateProfile{}
-	if newEmail != "" {
-		payload.Email = newEmail
+	return user, nil
+}
+
+// ChangePassword changes the logged in user's password after checking their
+// current password. The user's master key is re-encrypted with the new
+// password, and a new login keypair is derived from it.
+func (s *Session) ChangePassword(ctx context.Context, currentPassword, newPassword string) (envelope.UserInf, error) {
+	if s.engine.session.Type() != apitypes.UserSession {
+		return nil, &apitypes.Error{
+			Type: apitypes.BadRequestError,
+			Err:  []string{"You must be a logged in user to change your password!"},
+		}
 	}
 
-	if newName != "" {
-		payload.Name = newName
+	if newPassword == "" {
+		return nil, &apitypes.Error{
+			Type: apitypes.BadRequestError,
+			Err:  []string{"New password must not be empty."},
+		}
 	}
 
-	if newPassword != "" {
-		pw, master, keypair, err := s.engine.crypto.ChangePassword(ctx, newPassword)
-		if err != nil {
-			log.Printf("Could not re-encrypt master key: %s", err)
+	err := s.checkPassword(currentPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	if currentPassword == newPassword {
+		return nil, &apitypes.Error{
+			Type: apitypes.BadRequestError,
+			Err:  []string{"New password must differ from the current password."},
+		}
+	}
+
+	return s.UpdateProfile(ctx, "", "", newPassword)
+}
+
+// checkPassword returns an error if the given password isn't the session's
+// passphrase, comparing them in constant time.
+func (s *Session) checkPassword(password string) error {
+	if subtle.ConstantTimeCompare(s.engine.session.Passphrase(), []byte(password)) != 1 {
+		return &apitypes.Error{
+			Type: apitypes.BadRequestError,
+			Err:  []string{"Invalid password."},
+		}
+	}
+
+	return nil
+}
+
+// ExportRecoveryKit encrypts the logged in user's master key with a newly
+// generated recovery code, after checking their current password. The code is
+// returned alongside the kit, and is not stored anywhere by the daemon.
+func (s *Session) ExportRecoveryKit(ctx context.Context, password string) (*apitypes.RecoveryKitExport, error) {
+	user, err := s.currentUser()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.checkPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	salt, err := base64.NewFromString(user.Password().Salt)
+	if err != nil {
+		log.Printf("Could not decode password salt: %s", err)
+		return nil, &apitypes.Error{
+			Type: apitypes.InternalServerError,
+			Err:  []string{"Could not create recovery kit"},
+		}
+	}
+
+	kit, code, err := s.engine.crypto.ExportRecoveryKit(ctx, s.engine.session.AuthID(),
+		user.Username(), []byte(password), salt)
+	if err != nil {
+		log.Printf("Could not create recovery kit: %s", err)
+		return nil, &apitypes.Error{
+			Type: apitypes.InternalServerError,
+			Err:  []string{"Could not create recovery kit"},
+		}
+	}
+
+	return &apitypes.RecoveryKitExport{Kit: kit, Code: code}, nil
+}
+
+// ImportRecoveryKit logs in as the user who exported the recovery kit, by
+// signing a login token with the login keypair held in the kit. The user's
+// master key is then restored from the kit, encrypted with the new password,
+// and the user stays logged in.
+//
+// The login keypair is derived from the password the user had when exporting
+// the kit, so a kit can't be used once that password has been changed.
+func (s *Session) ImportRecoveryKit(ctx context.Context, kit *apitypes.RecoveryKit,
+	code, email, newPassword string) (envelope.UserInf, error) {
+
+	if s.engine.session.HasToken() {
+		return nil, &apitypes.Error{
+			Type: apitypes.BadRequestError,
+			Err:  []string{"You must log out before importing a recovery kit."},
+		}
+	}
+
+	if newPassword == "" {
+		return nil, &apitypes.Error{
+			Type: apitypes.BadRequestError,
+			Err:  []string{"New password must not be empty."},
+		}
+	}
+
+	mk, keypair, err := s.engine.crypto.OpenRecoveryKit(ctx, kit, code)
+	if err != nil {
+		return nil, &apitypes.Error{
+			Type: apitypes.BadRequestError,
+			Err:  []string{err.Error()},
+		}
+	}
+	defer mk.Destroy()
+
+	creds := &apitypes.UserLogin{Email: email, Password: newPassword}
+	_, loginToken, err := s.engine.client.Tokens.PostLogin(ctx, creds)
+	if err != nil {
+		return nil, err
+	}
+
+	if loginToken.Body.Mechanism != primitive.EdDSAAuth {
+		return nil, &apitypes.Error{
+			Type: apitypes.BadRequestError,
+			Err:  []string{"This account must log in with its password before a recovery kit can be used."},
+		}
+	}
+
+	tokenString := loginToken.Body.Token
+	sig := keypair.Sign([]byte(tokenString))
+	authToken, err := s.engine.client.Tokens.PostEdDSAAuth(ctx, tokenString, sig)
+	if err != nil {
+		if aerr, ok := err.(*apitypes.Error); ok && aerr.Type == apitypes.UnauthorizedError {
 			return nil, &apitypes.Error{
-				Type: apitypes.InternalServerError,
-				Err:  []string{"Could not re-encrypt master key"},
+				Type: apitypes.UnauthorizedError,
+				Err:  []string{"Could not log in with the recovery kit. It may belong to another account, or your password has changed since it was exported."},
 			}
 		}
+		return nil, err
+	}
 
-		payload.Password = pw
-		payload.Master = master
-		payload.PublicKey = keypair
+	token := authToken.Body.Token
+	self, err := s.engine.client.Self.Get(ctx, token)
+	if err == nil && (self.Type != apitypes.UserSession || *self.Identity.GetID() != *kit.UserID) {
+		err = &apitypes.Error{
+			Type: apitypes.BadRequestError,
+			Err:  []string{"Recovery kit does not belong to this account."},
+		}
+	}
+	if err != nil {
+		s.engine.client.Tokens.Delete(ctx, token)
+		return nil, err
+	}
+
+	pw, master, publicKey, err := crypto.ReencryptMasterKey(ctx, newPassword, mk)
+	if err != nil {
+		s.engine.client.Tokens.Delete(ctx, token)
+		log.Printf("Could not re-encrypt master key: %s", err)
+		return nil, &apitypes.Error{
+			Type: apitypes.InternalServerError,
+			Err:  []string{"Could not re-encrypt master key"},
+		}
+	}
+
+	// The registry is updated with the session's token, so the session is set
+	// first, with the new password as its passphrase.
+	s.engine.db.Set(self.Identity)
+	s.engine.db.Set(self.Auth)
+	err = s.engine.session.Set(self.Type, self.Identity, self.Auth, []byte(newPassword), []byte(token))
+	if err != nil {
+		s.engine.client.Tokens.Delete(ctx, token)
+		return nil, err
+	}
+
+	payload := &updateProfile{
+		Password:  pw,
+		Master:    master,
+		PublicKey: publicKey,
 	}
 
 	updatedUser, err := s.engine.client.Users.Update(ctx, payload)
 	if err != nil {
 		log.Printf("Could not update password on server due to err: %s", err)
+		s.engine.client.Tokens.Delete(ctx, token)
+		s.engine.session.Logout()
 		return nil, err
 	}
 
@@ -0,0 +1,36 @@
+package logic
+
+import (
+	"testing"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+
+	"github.com/manifoldco/torus-cli/daemon/session"
+)
+
+// passphraseSession is a session holding only a passphrase.
+type passphraseSession struct {
+	session.Session
+	passphrase []byte
+}
+
+func (s *passphraseSession) Passphrase() []byte {
+	return s.passphrase
+}
+
+func TestCheckPassword(t *testing.T) {
+	s := &Session{engine: &Engine{
+		session: &passphraseSession{passphrase: []byte("hunter22")},
+	}}
+
+	if err := s.checkPassword("hunter22"); err != nil {
+		t.Errorf("expected the session's password to be accepted, got %s", err)
+	}
+
+	for _, password := range []string{"", "hunter2", "hunter222", "HUNTER22"} {
+		err := s.checkPassword(password)
+		if aerr, ok := err.(*apitypes.Error); !ok || aerr.Type != apitypes.BadRequestError {
+			t.Errorf("expected %q to be rejected, got %v", password, err)
+		}
+	}
+}
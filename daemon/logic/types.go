@@ -1,6 +1,8 @@
 package logic
 
 import (
+	"time"
+
 	"github.com/manifoldco/torus-cli/identity"
 	"github.com/manifoldco/torus-cli/pathexp"
 )
@@ -20,4 +22,32 @@ type PlaintextCredential struct {
 	ProjectID *identity.ID     `json:"project_id"`
 	Value     string           `json:"value"`
 	State     *string          `json:"state"`
+
+	// Reference holds the unresolved value of a reference credential, whose
+	// Value has been resolved.
+	Reference string `json:"reference,omitempty"`
+
+	// Unresolved holds why a reference credential's Value couldn't be
+	// resolved, in which case it is left unresolved.
+	Unresolved string `json:"unresolved,omitempty"`
+}
+
+// PlaintextCredentialVersion is a single version from the history of a
+// Credential. Value is only populated when decryption was requested, the
+// version was not an unset, and it could be decrypted.
+type PlaintextCredentialVersion struct {
+	ID                *identity.ID     `json:"id"`
+	Previous          *identity.ID     `json:"previous"`
+	Name              string           `json:"name"`
+	PathExp           *pathexp.PathExp `json:"pathexp"`
+	CredentialVersion int              `json:"version"`
+	KeyringVersion    int              `json:"keyring_version"`
+
+	// KeyringCreated is the earliest time the version could have been set,
+	// as credentials aren't timestamped themselves.
+	KeyringCreated time.Time `json:"keyring_created_at"`
+
+	SignerID *identity.ID `json:"signer_id"`
+	Unset    bool         `json:"unset"`
+	Value    *string      `json:"value"`
 }
@@ -84,6 +84,27 @@ func extractCredentialValue(pt []byte) (*apitypes.CredentialValue, error) {
 	return cValue, nil
 }
 
+// stampSetAt records t as when the plaintext credential value was set, unless
+// it already records a time, as when only its metadata has changed. Unset
+// values are returned as-is.
+func stampSetAt(value string, t time.Time) (string, error) {
+	cv, err := extractCredentialValue([]byte(value))
+	if err != nil {
+		return "", err
+	}
+
+	if cv.IsUnset() || cv.SetAt() != nil {
+		return value, nil
+	}
+
+	b, err := cv.WithSetAt(&t).MarshalJSON()
+	if err != nil {
+		return "", err
+	}
+
+	return strconv.Unquote(string(b))
+}
+
 // createCredentialGraph generates, signs, and posts a new CredentialGraph
 // to the registry.
 func createCredentialGraph(ctx context.Context, credBody *PlaintextCredential,
@@ -244,28 +265,11 @@ func createKeyringMemberships(ctx context.Context, c *crypto.Engine, client *reg
 	// Get all the keyrings and memberships for the current user. This way we
 	// can decrypt the MEK for each and then create a new KeyringMember for
 	// our wonderful new org member!
-	org, err := client.Orgs.Get(ctx, orgID)
+	graphs, err := orgCredentialGraphs(ctx, client, s, orgID)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	projects, err := client.Projects.List(ctx, org.ID)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	var graphs []registry.CredentialGraph
-	for _, project := range projects {
-		projGraphs, err := client.CredentialGraph.Search(ctx,
-			"/"+org.Body.Name+"/"+project.Body.Name+"/*/*/*/*", s.AuthID(), nil)
-		if err != nil {
-			log.Printf("Error retrieving credential graphs: %s", err)
-			return nil, nil, err
-		}
-
-		graphs = append(graphs, projGraphs...)
-	}
-
 	// Find encryption keys for user
 	targetKeySegment, err := claimTree.FindActive(ownerID, primitive.EncryptionKeyType)
 	if err != nil {
@@ -345,6 +349,36 @@ func createKeyringMemberships(ctx context.Context, c *crypto.Engine, client *reg
 	return v1members, v2members, nil
 }
 
+// orgCredentialGraphs returns every credential graph in the given org that the
+// current user is a member of.
+func orgCredentialGraphs(ctx context.Context, client *registry.Client,
+	s session.Session, orgID *identity.ID) ([]registry.CredentialGraph, error) {
+
+	org, err := client.Orgs.Get(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	projects, err := client.Projects.List(ctx, org.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var graphs []registry.CredentialGraph
+	for _, project := range projects {
+		projGraphs, err := client.CredentialGraph.Search(ctx,
+			"/"+org.Body.Name+"/"+project.Body.Name+"/*/*/*/*", s.AuthID(), nil)
+		if err != nil {
+			log.Printf("Error retrieving credential graphs: %s", err)
+			return nil, err
+		}
+
+		graphs = append(graphs, projGraphs...)
+	}
+
+	return graphs, nil
+}
+
 // fetchKeyPairs fetches and bundles the user's signing and encryption keypairs
 // from the given keypairs struct
 func fetchKeyPairs(k *registry.Keypairs, orgID *identity.ID) (
@@ -0,0 +1,119 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/registry"
+
+	"github.com/manifoldco/torus-cli/daemon/observer"
+)
+
+// VerifyGraph verifies the signatures of the credential graphs, keyrings, and
+// keyring memberships matching the given PathExp, and that they link back to
+// the org through the public keys and claims of its claimtree. The claimtree
+// itself is verified too.
+func (e *Engine) VerifyGraph(ctx context.Context, notifier *observer.Notifier,
+	pe *pathexp.PathExp) (*apitypes.GraphVerifyResult, error) {
+
+	n := notifier.Notifier(4)
+
+	graphs, err := e.client.CredentialGraph.Search(ctx, pe.String(), e.session.AuthID(), nil)
+	if err != nil {
+		log.Printf("error retrieving credential graphs: %s", err)
+		return nil, err
+	}
+
+	n.Notify(observer.Progress, "Credentials retrieved", true)
+
+	org, err := e.client.Orgs.GetByName(ctx, pe.Org.String())
+	if err != nil {
+		log.Printf("error retrieving org: %s", err)
+		return nil, err
+	}
+	if org == nil {
+		return nil, &apitypes.Error{
+			Type: apitypes.NotFoundError,
+			Err:  []string{fmt.Sprintf("Org %s not found", pe.Org)},
+		}
+	}
+
+	sections, err := e.client.Keyring.List(ctx, org.ID, nil)
+	if err != nil {
+		log.Printf("error retrieving keyrings: %s", err)
+		return nil, err
+	}
+
+	n.Notify(observer.Progress, "Keyrings retrieved", true)
+
+	claimtree, err := e.client.ClaimTree.Get(ctx, org.ID, nil)
+	if err != nil {
+		log.Printf("Error fetching claimtree for org[%s]: %s", org.ID, err)
+		return nil, err
+	}
+
+	n.Notify(observer.Progress, "Claims retrieved", true)
+
+	v := newGraphVerifier(claimtree, e.registryKey())
+	v.VerifyClaimTree()
+	v.VerifyCredentialGraphs(graphs...)
+	for _, s := range sections {
+		kpe := s.GetKeyring().PathExp()
+		if kpe != nil && pe.Contains(kpe) {
+			v.VerifyKeyrings(s)
+		}
+	}
+
+	n.Notify(observer.Progress, "Graph verified", true)
+
+	return v.Result(), nil
+}
+
+// verifyCredentialGraphs verifies the given graphs, if verification is
+// enabled via the core.verify_graph preference, returning an error if any of
+// their objects fail. Warnings are only logged.
+func (e *Engine) verifyCredentialGraphs(claimtree *registry.ClaimTree,
+	graphs []registry.CredentialGraph) error {
+
+	if e.config == nil || !e.config.VerifyGraph {
+		return nil
+	}
+
+	v := newGraphVerifier(claimtree, e.registryKey())
+	v.VerifyCredentialGraphs(graphs...)
+
+	var failed []string
+	for _, issue := range v.Result().Issues {
+		msg := fmt.Sprintf("%s %s (%s): %s", issue.Type, issue.ID, issue.Problem, issue.Detail)
+		log.Printf("Graph verification: %s", msg)
+
+		if !issue.Warning {
+			failed = append(failed, msg)
+		}
+	}
+
+	if len(failed) > 0 {
+		return &apitypes.Error{
+			Type: apitypes.InternalServerError,
+			Err: []string{
+				"Credential graph failed verification: " + strings.Join(failed, "; "),
+			},
+		}
+	}
+
+	return nil
+}
+
+// registryKey returns the registry's public key, used to verify objects the
+// registry has signed.
+func (e *Engine) registryKey() []byte {
+	if e.config == nil || e.config.PublicKey == nil {
+		return nil
+	}
+
+	return []byte(e.config.PublicKey.PublicKey)
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"log"
 	"sort"
+	"sync"
+	"time"
 
 	"github.com/manifoldco/go-base64"
 
@@ -43,6 +45,8 @@ func newWorklog(e *Engine) Worklog {
 			apitypes.MissingKeypairsWorklogType: &missingKeypairsHandler{engine: e},
 			apitypes.InviteApproveWorklogType:   &inviteApproveHandler{engine: e},
 			membersType:                         &keyringMembersHandler{engine: e},
+			apitypes.SecretExpiryWorklogType:    &secretExpiryHandler{engine: e},
+			apitypes.SecretAgeWorklogType:       &secretAgeHandler{engine: e},
 		},
 	}
 
@@ -50,15 +54,22 @@ func newWorklog(e *Engine) Worklog {
 }
 
 type worklogTypeHandler interface {
-	list(context.Context, *envelope.Org) ([]apitypes.WorklogItem, error)
+	list(context.Context, *envelope.Org, *apitypes.WorklogOptions) ([]apitypes.WorklogItem, error)
 	resolve(context.Context, *observer.Notifier, *identity.ID,
-		*apitypes.WorklogItem) error
+		*apitypes.WorklogItem, *worklogLookups) error
 	resolveErr() string
 }
 
+// worklogResolveConcurrency is the number of worklog items resolved at once.
+const worklogResolveConcurrency = 8
+
 // List returns the list of all outstanding worklog items for the given org
 func (w *Worklog) List(ctx context.Context, orgID *identity.ID,
-	itemType apitypes.WorklogType) ([]apitypes.WorklogItem, error) {
+	itemType apitypes.WorklogType, opts *apitypes.WorklogOptions) ([]apitypes.WorklogItem, error) {
+
+	if opts == nil {
+		opts = &apitypes.WorklogOptions{}
+	}
 
 	org, err := w.engine.client.Orgs.Get(ctx, orgID)
 	if err != nil {
@@ -71,21 +82,75 @@ func (w *Worklog) List(ctx context.Context, orgID *identity.ID,
 			continue
 		}
 
-		hItems, err := h.list(ctx, org)
+		hItems, err := h.list(ctx, org, opts)
 		if err != nil {
 			return nil, err
 		}
 		items = append(items, hItems...)
 	}
 
+	dismissals, err := w.engine.db.WorklogDismissals(orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	items, stale := filterDismissed(items, dismissals, opts.All, time.Now())
+	if len(stale) > 0 {
+		err = w.engine.db.DeleteWorklogDismissals(orgID, stale...)
+		if err != nil {
+			log.Printf("error removing stale worklog dismissals: %s", err)
+		}
+	}
+
 	return items, nil
 }
 
+// filterDismissed removes items with an active dismissal from items, or
+// annotates them with their dismissal if all is true. It also returns the ids
+// of dismissals which no longer apply, because the item's cause has changed
+// or its snooze has ended.
+//
+// Dismissals for items not in the list are kept, as the item may have been
+// hidden by the list's options.
+func filterDismissed(items []apitypes.WorklogItem,
+	dismissals map[apitypes.WorklogID]apitypes.WorklogDismissal, all bool,
+	now time.Time) ([]apitypes.WorklogItem, []apitypes.WorklogID) {
+
+	var stale []apitypes.WorklogID
+	out := make([]apitypes.WorklogItem, 0, len(items))
+	for _, item := range items {
+		d, ok := dismissals[*item.ID]
+		if !ok {
+			out = append(out, item)
+			continue
+		}
+
+		if !d.Active(item.Fingerprint(), now) {
+			stale = append(stale, *item.ID)
+			out = append(out, item)
+			continue
+		}
+
+		if all {
+			item.Dismissal = &d
+			out = append(out, item)
+		}
+	}
+
+	return out, stale
+}
+
 // Get returns a single worklog item for the given org with the given ident.
 func (w *Worklog) Get(ctx context.Context, orgID *identity.ID,
-	ident *apitypes.WorklogID) (*apitypes.WorklogItem, error) {
+	ident *apitypes.WorklogID, opts *apitypes.WorklogOptions) (*apitypes.WorklogItem, error) {
 
-	items, err := w.List(ctx, orgID, ident.Type())
+	all := apitypes.WorklogOptions{}
+	if opts != nil {
+		all = *opts
+	}
+	all.All = true
+
+	items, err := w.List(ctx, orgID, ident.Type(), &all)
 	if err != nil {
 		return nil, err
 	}
@@ -99,31 +164,201 @@ func (w *Worklog) Get(ctx context.Context, orgID *identity.ID,
 	return nil, nil
 }
 
-// Resolve attempts to resolve the worklog item in the given org with the given
-// ident.
+// Resolve attempts to resolve the worklog items in the given org with the
+// given idents, returning the outcome of each in the same order.
+//
+// Missing keypairs are generated first, as other items may depend on them.
+// The rest are resolved concurrently, sharing lookups of the org's claim tree
+// and the user's keypairs. The outcome of each item is sent to the notifier as
+// it completes, and a failure does not stop other items from being resolved.
 func (w *Worklog) Resolve(ctx context.Context, n *observer.Notifier,
-	orgID *identity.ID, ident *apitypes.WorklogID) error {
+	orgID *identity.ID, idents []apitypes.WorklogID,
+	opts *apitypes.WorklogOptions) ([]apitypes.WorklogResult, error) {
+
+	all := apitypes.WorklogOptions{}
+	if opts != nil {
+		all = *opts
+	}
+	all.All = true
+
+	var itemType apitypes.WorklogType
+	for _, ident := range idents {
+		itemType |= ident.Type()
+	}
 
-	item, err := w.Get(ctx, orgID, ident)
+	items, err := w.List(ctx, orgID, itemType, &all)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if item == nil {
-		return nil
+	byID := make(map[apitypes.WorklogID]*apitypes.WorklogItem, len(items))
+	for i := range items {
+		byID[*items[i].ID] = &items[i]
 	}
 
-	for t, h := range w.handlers {
-		if t&item.Type() == 0 {
+	results := make([]apitypes.WorklogResult, len(idents))
+	var first, rest []int
+	for i, ident := range idents {
+		results[i].ID = &idents[i]
+		if byID[ident] == nil {
+			results[i].State = apitypes.WorklogResultFailure
+			results[i].Message = "worklog item not found"
+			n.Notify(observer.Progress, "Worklog item "+ident.String()+" not found", true)
 			continue
 		}
 
-		return h.resolve(ctx, n, orgID, item)
+		if ident.Type() == apitypes.MissingKeypairsWorklogType {
+			first = append(first, i)
+		} else {
+			rest = append(rest, i)
+		}
+	}
+
+	lookups := &worklogLookups{engine: w.engine, orgID: orgID}
+	resolve := func(i int) {
+		item := byID[idents[i]]
+		results[i] = w.resolveItem(ctx, n, orgID, item, lookups)
+	}
+
+	for _, i := range first {
+		resolve(i)
+	}
+
+	parallel(rest, worklogResolveConcurrency, resolve)
+
+	return results, nil
+}
+
+// parallel calls fn for each of the given indices, with at most limit calls
+// running at once. It returns once all calls have returned.
+func parallel(indices []int, limit int, fn func(int)) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, limit)
+	for _, i := range indices {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// resolveItem resolves a single worklog item, notifying of its outcome.
+func (w *Worklog) resolveItem(ctx context.Context, n *observer.Notifier,
+	orgID *identity.ID, item *apitypes.WorklogItem,
+	lookups *worklogLookups) apitypes.WorklogResult {
+
+	result := apitypes.WorklogResult{ID: item.ID, State: apitypes.WorklogResultSuccess}
+
+	h := w.handler(item.Type())
+	err := h.resolve(ctx, n, orgID, item, lookups)
+	switch err {
+	case nil:
+		n.Notify(observer.Progress, "Resolved "+item.Subject(), true)
+	case errManualResolve:
+		result.State = apitypes.WorklogResultManual
+		n.Notify(observer.Progress, item.Subject()+" must be resolved manually", true)
+	default:
+		log.Printf("%s for worklog item %s: %s", h.resolveErr(), item.ID, err)
+		result.State = apitypes.WorklogResultFailure
+		result.Message = err.Error()
+		n.Notify(observer.Progress, "Could not resolve "+item.Subject(), true)
+	}
+
+	return result
+}
+
+func (w *Worklog) handler(t apitypes.WorklogType) worklogTypeHandler {
+	for ht, h := range w.handlers {
+		if ht&t != 0 {
+			return h
+		}
 	}
 
 	panic("worklog handler not found for type")
 }
 
+// worklogLookups holds the lookups shared between worklog items resolved
+// together. Each is made at most once, when first needed.
+type worklogLookups struct {
+	engine *Engine
+	orgID  *identity.ID
+
+	keypairsOnce sync.Once
+	sigID        *identity.ID
+	encID        *identity.ID
+	kp           *crypto.KeyPairs
+	keypairsErr  error
+
+	claimTreeOnce sync.Once
+	claimTree     *registry.ClaimTree
+	claimTreeErr  error
+}
+
+// KeyPairs returns the ids of the user's signing and encryption public keys,
+// and their keypairs, for the org.
+func (l *worklogLookups) KeyPairs(ctx context.Context) (*identity.ID, *identity.ID, *crypto.KeyPairs, error) {
+	l.keypairsOnce.Do(func() {
+		var keypairs *registry.Keypairs
+		keypairs, l.keypairsErr = l.engine.client.KeyPairs.List(ctx, l.orgID)
+		if l.keypairsErr != nil {
+			return
+		}
+
+		l.sigID, l.encID, l.kp, l.keypairsErr = fetchKeyPairs(keypairs, l.orgID)
+	})
+
+	return l.sigID, l.encID, l.kp, l.keypairsErr
+}
+
+// ClaimTree returns the org's claim tree, for public key lookups.
+func (l *worklogLookups) ClaimTree(ctx context.Context) (*registry.ClaimTree, error) {
+	l.claimTreeOnce.Do(func() {
+		l.claimTree, l.claimTreeErr = l.engine.client.ClaimTree.Get(ctx, l.orgID, nil)
+	})
+
+	return l.claimTree, l.claimTreeErr
+}
+
+// Dismiss snoozes the worklog item in the given org with the given ident until
+// the given time, or dismisses it if until is nil. The item is hidden from the
+// worklog until then, or until its cause changes. It returns nil if the item
+// does not exist.
+func (w *Worklog) Dismiss(ctx context.Context, orgID *identity.ID,
+	ident *apitypes.WorklogID, until *time.Time, reason string,
+	opts *apitypes.WorklogOptions) (*apitypes.WorklogItem, error) {
+
+	item, err := w.Get(ctx, orgID, ident, opts)
+	if err != nil || item == nil {
+		return nil, err
+	}
+
+	item.Dismissal = &apitypes.WorklogDismissal{
+		Until:       until,
+		Reason:      reason,
+		Created:     time.Now().UTC(),
+		Fingerprint: item.Fingerprint(),
+	}
+
+	err = w.engine.db.SetWorklogDismissal(orgID, ident, item.Dismissal)
+	if err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// Restore removes any snooze or dismissal of the worklog item in the given
+// org with the given ident, returning it to the worklog.
+func (w *Worklog) Restore(orgID *identity.ID, ident *apitypes.WorklogID) error {
+	return w.engine.db.DeleteWorklogDismissals(orgID, *ident)
+}
+
 type secretRotateHandler struct {
 	engine *Engine
 }
@@ -134,7 +369,7 @@ func (secretRotateHandler) resolveErr() string {
 	return "Error rotating secret"
 }
 
-func (h *secretRotateHandler) list(ctx context.Context, org *envelope.Org) ([]apitypes.WorklogItem, error) {
+func (h *secretRotateHandler) list(ctx context.Context, org *envelope.Org, opts *apitypes.WorklogOptions) ([]apitypes.WorklogItem, error) {
 	projects, err := h.engine.client.Projects.List(ctx, org.ID)
 	if err != nil {
 		return nil, err
@@ -155,33 +390,70 @@ func (h *secretRotateHandler) list(ctx context.Context, org *envelope.Org) ([]ap
 		}
 	}
 
-	needRotation, err := cgs.NeedRotation()
+	needRotation, err := cgs.NeedRotation(opts.MachineRevocations)
 	if err != nil {
 		return nil, err
 	}
 
+	userType := (&primitive.User{}).Type()
+	var machines *machineNames
+
 	var items []apitypes.WorklogItem
 	for _, reason := range needRotation {
 		var ids []identity.ID
+		var machineClaims []primitive.KeyringMemberClaim
 		claimsByOwner := make(map[identity.ID]primitive.KeyringMemberClaim, len(reason.Reasons))
 		for _, r := range reason.Reasons {
+			if r.OwnerID.Type() != userType {
+				machineClaims = append(machineClaims, r)
+				continue
+			}
+
 			ids = append(ids, *r.OwnerID)
 			claimsByOwner[*r.OwnerID] = r
 		}
 
-		users, err := h.engine.client.Profiles.ListByID(ctx, ids)
-		if err != nil {
-			return nil, err
+		var reasons []apitypes.SecretRotateWorklogReason
+		if len(ids) > 0 {
+			users, err := h.engine.client.Profiles.ListByID(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, user := range users {
+				reasons = append(reasons, apitypes.SecretRotateWorklogReason{
+					Username: user.Body.Username,
+					Type:     claimsByOwner[*user.ID].Reason.Type,
+				})
+			}
 		}
 
-		var reasons []apitypes.SecretRotateWorklogReason
-		for _, user := range users {
+		if len(machineClaims) > 0 && machines == nil {
+			machines, err = newMachineNames(ctx, h.engine, org.ID)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, c := range machineClaims {
 			reasons = append(reasons, apitypes.SecretRotateWorklogReason{
-				Username: user.Body.Username,
-				Type:     claimsByOwner[*user.ID].Reason.Type,
+				Machine: machines.Find(ctx, &c),
+				Type:    c.Reason.Type,
 			})
 		}
 
+		// Keep the reasons in a consistent order, so the item's fingerprint
+		// only changes when the reasons do.
+		sort.Slice(reasons, func(i, j int) bool {
+			if reasons[i].Username != reasons[j].Username {
+				return reasons[i].Username < reasons[j].Username
+			}
+			if reasons[i].Machine != reasons[j].Machine {
+				return reasons[i].Machine < reasons[j].Machine
+			}
+			return reasons[i].Type < reasons[j].Type
+		})
+
 		cred := reason.Credential
 		item := apitypes.WorklogItem{
 			Details: &apitypes.SecretRotateWorklogDetails{
@@ -199,10 +471,72 @@ func (h *secretRotateHandler) list(ctx context.Context, org *envelope.Org) ([]ap
 }
 
 func (h *secretRotateHandler) resolve(ctx context.Context, n *observer.Notifier,
-	orgID *identity.ID, item *apitypes.WorklogItem) error {
+	orgID *identity.ID, item *apitypes.WorklogItem, lookups *worklogLookups) error {
 	return errManualResolve
 }
 
+// machineNames finds the names of the machines whose tokens' access to
+// keyrings has been revoked.
+type machineNames struct {
+	engine   *Engine
+	byID     map[identity.ID]string
+	byTokens map[identity.ID]string
+}
+
+func newMachineNames(ctx context.Context, e *Engine, orgID *identity.ID) (*machineNames, error) {
+	segments, err := e.client.Machines.List(ctx, orgID, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &machineNames{
+		engine:   e,
+		byID:     make(map[identity.ID]string),
+		byTokens: make(map[identity.ID]string),
+	}
+	for _, segment := range segments {
+		m.add(&segment)
+	}
+
+	return m, nil
+}
+
+func (m *machineNames) add(segment *apitypes.MachineSegment) {
+	name := segment.Machine.Body.Name
+	m.byID[*segment.Machine.ID] = name
+	for _, token := range segment.Tokens {
+		m.byTokens[*token.Token.ID] = name
+	}
+}
+
+// Find returns the name of the machine whose token's access was revoked by the
+// claim. The token's ID is returned if the machine can not be found.
+func (m *machineNames) Find(ctx context.Context, claim *primitive.KeyringMemberClaim) string {
+	if name, ok := m.byTokens[*claim.OwnerID]; ok {
+		return name
+	}
+
+	// Destroyed machines may not be listed, so look them up directly.
+	if claim.Reason == nil {
+		return claim.OwnerID.String()
+	}
+
+	if p, ok := claim.Reason.Params.(*primitive.MachineDestroyRevocationParams); ok && p.MachineID != nil {
+		if name, ok := m.byID[*p.MachineID]; ok {
+			return name
+		}
+
+		segment, err := m.engine.client.Machines.Get(ctx, p.MachineID)
+		if err == nil && segment.Machine != nil {
+			m.add(segment)
+			return segment.Machine.Body.Name
+		}
+		log.Printf("Could not find machine %s: %s", p.MachineID, err)
+	}
+
+	return claim.OwnerID.String()
+}
+
 type missingKeypairsHandler struct {
 	engine *Engine
 }
@@ -211,7 +545,7 @@ func (missingKeypairsHandler) resolveErr() string {
 	return "Error generating keypairs"
 }
 
-func (h *missingKeypairsHandler) list(ctx context.Context, org *envelope.Org) ([]apitypes.WorklogItem, error) {
+func (h *missingKeypairsHandler) list(ctx context.Context, org *envelope.Org, opts *apitypes.WorklogOptions) ([]apitypes.WorklogItem, error) {
 	keypairs, err := h.engine.client.KeyPairs.List(ctx, org.ID)
 	if err != nil {
 		return nil, err
@@ -246,7 +580,7 @@ func (h *missingKeypairsHandler) list(ctx context.Context, org *envelope.Org) ([
 }
 
 func (h *missingKeypairsHandler) resolve(ctx context.Context, n *observer.Notifier,
-	orgID *identity.ID, item *apitypes.WorklogItem) error {
+	orgID *identity.ID, item *apitypes.WorklogItem, lookups *worklogLookups) error {
 	return h.engine.GenerateKeypairs(ctx, n, orgID)
 }
 
@@ -258,7 +592,7 @@ func (inviteApproveHandler) resolveErr() string {
 	return "Error approving invite"
 }
 
-func (h *inviteApproveHandler) list(ctx context.Context, org *envelope.Org) ([]apitypes.WorklogItem, error) {
+func (h *inviteApproveHandler) list(ctx context.Context, org *envelope.Org, opts *apitypes.WorklogOptions) ([]apitypes.WorklogItem, error) {
 	invites, err := h.engine.client.OrgInvites.List(ctx, org.ID, []string{"accepted"}, "")
 	if err != nil {
 		// The user can be unauthorized because they don't have access to
@@ -311,7 +645,7 @@ func (h *inviteApproveHandler) list(ctx context.Context, org *envelope.Org) ([]a
 }
 
 func (h *inviteApproveHandler) resolve(ctx context.Context, n *observer.Notifier,
-	orgID *identity.ID, item *apitypes.WorklogItem) error {
+	orgID *identity.ID, item *apitypes.WorklogItem, lookups *worklogLookups) error {
 	_, err := h.engine.ApproveInvite(ctx, n, item.Details.(*apitypes.InviteApproveWorklogDetails).InviteID)
 	return err
 }
@@ -326,7 +660,7 @@ func (keyringMembersHandler) resolveErr() string {
 
 var errUserNotFound = errors.New("user not found")
 
-func (h *keyringMembersHandler) list(ctx context.Context, org *envelope.Org) ([]apitypes.WorklogItem, error) {
+func (h *keyringMembersHandler) list(ctx context.Context, org *envelope.Org, opts *apitypes.WorklogOptions) ([]apitypes.WorklogItem, error) {
 	// Find all of the credential graphs in this org.
 	// We need to get all credential graphs. To do this, we first need to know
 	// their pathexps. Use keyring listing for this.
@@ -467,6 +801,10 @@ func (h *keyringMembersHandler) list(ctx context.Context, org *envelope.Org) ([]
 
 	items := make([]apitypes.WorklogItem, 0, len(missing))
 	for _, k := range keys {
+		d := missing[k].Details.(*apitypes.KeyringMembersWorklogDetails)
+		sort.Slice(d.Keyrings, func(i, j int) bool {
+			return d.Keyrings[i].String() < d.Keyrings[j].String()
+		})
 		items = append(items, missing[k])
 	}
 
@@ -474,21 +812,16 @@ func (h *keyringMembersHandler) list(ctx context.Context, org *envelope.Org) ([]
 }
 
 func (h *keyringMembersHandler) resolve(ctx context.Context, n *observer.Notifier,
-	orgID *identity.ID, item *apitypes.WorklogItem) error {
+	orgID *identity.ID, item *apitypes.WorklogItem, lookups *worklogLookups) error {
 
 	// Preamble. Get the current user's keypairs, and the org's claims for
 	// pubkey lookup.
-	keypairs, err := h.engine.client.KeyPairs.List(ctx, orgID)
-	if err != nil {
-		return err
-	}
-
-	sigID, encID, kp, err := fetchKeyPairs(keypairs, orgID)
+	sigID, encID, kp, err := lookups.KeyPairs(ctx)
 	if err != nil {
 		return err
 	}
 
-	claimTree, err := h.engine.client.ClaimTree.Get(ctx, orgID, nil)
+	claimTree, err := lookups.ClaimTree(ctx)
 	if err != nil {
 		return err
 	}
@@ -596,3 +929,198 @@ func (h *keyringMembersHandler) resolve(ctx context.Context, n *observer.Notifie
 
 	return nil
 }
+
+type secretExpiryHandler struct {
+	engine *Engine
+}
+
+func (secretExpiryHandler) resolveErr() string {
+	// Like rotation, a new value must be set manually.
+	return "Error rotating secret"
+}
+
+func (h *secretExpiryHandler) list(ctx context.Context, org *envelope.Org, opts *apitypes.WorklogOptions) ([]apitypes.WorklogItem, error) {
+	// Expiry dates are kept in the encrypted metadata, so every secret the
+	// user can access must be decrypted to find them. This is only done when
+	// asked for.
+	if opts.ExpiryWindow == 0 {
+		return nil, nil
+	}
+
+	projects, err := h.engine.client.Projects.List(ctx, org.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []apitypes.WorklogItem
+	for _, project := range projects {
+		pe := "/" + org.Body.Name + "/" + project.Body.Name + "/*/*/*/*"
+		creds, err := h.engine.retrieveCredentials(ctx, nil, nil, &pe, nil, false)
+		if err != nil {
+			log.Printf("Skipping expiry check of %s due to error: %s", pe, err)
+			continue
+		}
+
+		items = append(items, expiringCredentials(creds, time.Now().Add(opts.ExpiryWindow))...)
+	}
+
+	return items, nil
+}
+
+func (h *secretExpiryHandler) resolve(ctx context.Context, n *observer.Notifier,
+	orgID *identity.ID, item *apitypes.WorklogItem, lookups *worklogLookups) error {
+	return errManualResolve
+}
+
+// expiringCredentials returns worklog items for the credentials that expire
+// before the given deadline. Credentials that could not be decrypted are
+// skipped.
+func expiringCredentials(creds []PlaintextCredentialEnvelope, deadline time.Time) []apitypes.WorklogItem {
+	var items []apitypes.WorklogItem
+	for _, cred := range creds {
+		cv, err := extractCredentialValue([]byte(cred.Body.Value))
+		if err != nil {
+			continue
+		}
+
+		m := cv.Metadata()
+		if m == nil || m.ExpiresAt == nil || m.ExpiresAt.After(deadline) {
+			continue
+		}
+
+		item := apitypes.WorklogItem{
+			Details: &apitypes.SecretExpiryWorklogDetails{
+				PathExp:   cred.Body.PathExp,
+				Name:      cred.Body.Name,
+				ExpiresAt: *m.ExpiresAt,
+			},
+		}
+		item.CreateID(apitypes.SecretExpiryWorklogType)
+
+		items = append(items, item)
+	}
+
+	return items
+}
+
+type secretAgeHandler struct {
+	engine *Engine
+}
+
+func (secretAgeHandler) resolveErr() string {
+	// Like rotation, this is resolved by setting a new value.
+	return "Error rotating secret"
+}
+
+func (h *secretAgeHandler) list(ctx context.Context, org *envelope.Org, opts *apitypes.WorklogOptions) ([]apitypes.WorklogItem, error) {
+	if len(opts.SecretAge) == 0 {
+		return nil, nil
+	}
+
+	projects, err := h.engine.client.Projects.List(ctx, org.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	var items []apitypes.WorklogItem
+	for _, project := range projects {
+		pe := "/" + org.Body.Name + "/" + project.Body.Name + "/*/*/*/*"
+
+		// Values set before set times were recorded are aged from the
+		// creation of the keyring holding them, the earliest they could
+		// have been set.
+		graphs, err := h.engine.client.CredentialGraph.Search(ctx, pe,
+			h.engine.session.AuthID(), nil)
+		if err != nil {
+			log.Printf("Skipping age check of %s due to error: %s", pe, err)
+			continue
+		}
+
+		cgs := newCredentialGraphSet()
+		err = cgs.Add(graphs...)
+		if err != nil {
+			return nil, err
+		}
+
+		pruned, err := cgs.Prune()
+		if err != nil {
+			return nil, err
+		}
+
+		keyringCreated := make(map[string]time.Time)
+		for _, graph := range pruned {
+			for _, cred := range graph.GetCredentials() {
+				keyringCreated[cred.PathExp().String()+"/"+cred.Name()] = graph.GetKeyring().Created()
+			}
+		}
+
+		creds, err := h.engine.retrieveCredentials(ctx, nil, nil, &pe, nil, false)
+		if err != nil {
+			log.Printf("Skipping age check of %s due to error: %s", pe, err)
+			continue
+		}
+
+		items = append(items, staleCredentials(creds, keyringCreated, opts.SecretAge, now)...)
+	}
+
+	return items, nil
+}
+
+func (h *secretAgeHandler) resolve(ctx context.Context, n *observer.Notifier,
+	orgID *identity.ID, item *apitypes.WorklogItem, lookups *worklogLookups) error {
+	return errManualResolve
+}
+
+// staleCredentials returns worklog items for the credentials older than the
+// maximum age the policy gives them. keyringCreated holds the creation time of
+// the keyring holding each credential, by path, for those without a set time.
+func staleCredentials(creds []PlaintextCredentialEnvelope, keyringCreated map[string]time.Time,
+	policy apitypes.SecretAgePolicy, now time.Time) []apitypes.WorklogItem {
+
+	var items []apitypes.WorklogItem
+	for _, cred := range creds {
+		maxAge, ok := policy.MaxAge(cred.Body.PathExp)
+		if !ok {
+			continue
+		}
+
+		cv, err := extractCredentialValue([]byte(cred.Body.Value))
+		if err != nil {
+			continue
+		}
+
+		details := &apitypes.SecretAgeWorklogDetails{
+			PathExp: cred.Body.PathExp,
+			Name:    cred.Body.Name,
+			MaxAge:  maxAge,
+		}
+
+		if at := cv.SetAt(); at != nil {
+			details.SetAt = *at
+		} else {
+			created, ok := keyringCreated[cred.Body.PathExp.String()+"/"+cred.Body.Name]
+			if !ok {
+				continue
+			}
+			details.SetAt = created
+			details.Approximate = true
+		}
+
+		if now.Sub(details.SetAt) <= maxAge {
+			continue
+		}
+
+		if m := cv.Metadata(); m != nil {
+			details.Link = m.Link
+		}
+
+		item := apitypes.WorklogItem{Details: details}
+		item.CreateID(apitypes.SecretAgeWorklogType)
+
+		items = append(items, item)
+	}
+
+	return items
+}
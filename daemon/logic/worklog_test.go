@@ -0,0 +1,195 @@
+package logic
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+)
+
+func TestExpiringCredentials(t *testing.T) {
+	now := time.Now()
+	expiring := func(d time.Duration) *apitypes.CredentialValue {
+		at := now.Add(d)
+		return apitypes.NewStringCredentialValue("v").WithMetadata(&apitypes.CredentialMetadata{ExpiresAt: &at})
+	}
+
+	pe := "/o/p/e/s/*/*"
+	creds := []PlaintextCredentialEnvelope{
+		plaintextCred(t, pe, "expired", expiring(-48*time.Hour)),
+		plaintextCred(t, pe, "soon", expiring(24*time.Hour)),
+		plaintextCred(t, pe, "later", expiring(90*24*time.Hour)),
+		plaintextCred(t, pe, "never", apitypes.NewStringCredentialValue("v")),
+	}
+
+	items := expiringCredentials(creds, now.Add(30*24*time.Hour))
+	if len(items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(items))
+	}
+
+	for i, name := range []string{"expired", "soon"} {
+		d := items[i].Details.(*apitypes.SecretExpiryWorklogDetails)
+		if d.Name != name {
+			t.Errorf("expected item %d to be %s, got %s", i, name, d.Name)
+		}
+		if items[i].Type() != apitypes.SecretExpiryWorklogType {
+			t.Errorf("expected item %d to have expiry type, got %s", i, items[i].Type())
+		}
+	}
+}
+
+func TestSecretExpiryHandlerOptIn(t *testing.T) {
+	// Without an expiry window, no secrets are fetched or decrypted; the
+	// handler's engine would be used if they were.
+	h := &secretExpiryHandler{}
+	items, err := h.list(context.Background(), nil, &apitypes.WorklogOptions{})
+	if err != nil || items != nil {
+		t.Errorf("expected no items and no error, got %v, %v", items, err)
+	}
+}
+
+func TestStaleCredentials(t *testing.T) {
+	now := time.Now()
+	setAt := func(d time.Duration) *apitypes.CredentialValue {
+		at := now.Add(-d)
+		return apitypes.NewStringCredentialValue("v").WithSetAt(&at)
+	}
+
+	policy, err := apitypes.ParseSecretAgePolicy("/o/p/prod=90")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	day := 24 * time.Hour
+	creds := []PlaintextCredentialEnvelope{
+		plaintextCred(t, "/o/p/prod/s/*/*", "old", setAt(100*day)),
+		plaintextCred(t, "/o/p/prod/s/*/*", "new", setAt(10*day)),
+		plaintextCred(t, "/o/p/prod/s/*/*", "legacy", apitypes.NewStringCredentialValue("v")),
+		plaintextCred(t, "/o/p/dev/s/*/*", "unmatched", setAt(500*day)),
+	}
+
+	keyringCreated := map[string]time.Time{
+		"/o/p/prod/s/*/*/legacy": now.Add(-200 * day),
+	}
+
+	items := staleCredentials(creds, keyringCreated, policy, now)
+	if len(items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(items))
+	}
+
+	old := items[0].Details.(*apitypes.SecretAgeWorklogDetails)
+	if old.Name != "old" || old.Approximate || old.AgeDays(now) != 100 {
+		t.Errorf("unexpected details for old secret: %+v", old)
+	}
+
+	legacy := items[1].Details.(*apitypes.SecretAgeWorklogDetails)
+	if legacy.Name != "legacy" || !legacy.Approximate || legacy.AgeDays(now) != 200 {
+		t.Errorf("unexpected details for legacy secret: %+v", legacy)
+	}
+}
+
+func TestStampSetAt(t *testing.T) {
+	now := time.Now().UTC()
+	cred := plaintextCred(t, "/o/p/e/s/*/*", "a", apitypes.NewStringCredentialValue("v"))
+
+	value, err := stampSetAt(cred.Body.Value, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cv, err := extractCredentialValue([]byte(value))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cv.SetAt() == nil || !cv.SetAt().Equal(now) {
+		t.Fatalf("expected set time %s, got %v", now, cv.SetAt())
+	}
+
+	// An existing set time is kept
+	restamped, err := stampSetAt(value, now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restamped != value {
+		t.Errorf("expected value to be unchanged, got %s", restamped)
+	}
+}
+
+func TestFilterDismissed(t *testing.T) {
+	now := time.Now()
+	pe := "/o/p/e/s/*/*"
+	expiring := apitypes.NewStringCredentialValue("v").WithMetadata(&apitypes.CredentialMetadata{ExpiresAt: &now})
+	items := expiringCredentials([]PlaintextCredentialEnvelope{
+		plaintextCred(t, pe, "open", expiring),
+		plaintextCred(t, pe, "dismissed", expiring),
+		plaintextCred(t, pe, "changed", expiring),
+		plaintextCred(t, pe, "woken", expiring),
+	}, now.Add(time.Hour))
+
+	past := now.Add(-time.Hour)
+	dismissals := map[apitypes.WorklogID]apitypes.WorklogDismissal{
+		*items[1].ID: {Fingerprint: items[1].Fingerprint(), Reason: "not used"},
+		*items[2].ID: {Fingerprint: "old"},
+		*items[3].ID: {Fingerprint: items[3].Fingerprint(), Until: &past},
+	}
+
+	out, stale := filterDismissed(items, dismissals, false, now)
+	if len(out) != 3 {
+		t.Fatalf("expected 3 items, got %d", len(out))
+	}
+	for _, item := range out {
+		if *item.ID == *items[1].ID {
+			t.Error("expected dismissed item to be filtered")
+		}
+	}
+	if len(stale) != 2 || stale[0] != *items[2].ID || stale[1] != *items[3].ID {
+		t.Errorf("expected changed and woken dismissals to be stale, got %v", stale)
+	}
+
+	out, _ = filterDismissed(items, dismissals, true, now)
+	if len(out) != 4 {
+		t.Fatalf("expected 4 items, got %d", len(out))
+	}
+	if out[1].Dismissal == nil || out[1].Dismissal.Reason != "not used" {
+		t.Error("expected dismissed item to include its dismissal")
+	}
+	if out[0].Dismissal != nil || out[2].Dismissal != nil {
+		t.Error("expected other items to have no dismissal")
+	}
+}
+
+func TestParallel(t *testing.T) {
+	indices := make([]int, 50)
+	for i := range indices {
+		indices[i] = i
+	}
+
+	var mu sync.Mutex
+	running, peak := 0, 0
+	seen := make(map[int]bool)
+
+	parallel(indices, 4, func(i int) {
+		mu.Lock()
+		running++
+		if running > peak {
+			peak = running
+		}
+		seen[i] = true
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+	})
+
+	if len(seen) != len(indices) {
+		t.Errorf("expected %d calls, got %d", len(indices), len(seen))
+	}
+	if peak > 4 {
+		t.Errorf("expected at most 4 concurrent calls, got %d", peak)
+	}
+}
@@ -109,8 +109,13 @@ func (t *transaction) start() {
 	}()
 }
 
-// Notifier creates a child notifier to this Notifier
+// Notifier creates a child notifier to this Notifier. A nil Notifier has nil
+// children, which discard notifications.
 func (n *Notifier) Notifier(total uint) *Notifier {
+	if n == nil {
+		return nil
+	}
+
 	notifier := &Notifier{
 		total:          total,
 		current:        0,
@@ -128,6 +133,10 @@ func (n *Notifier) Notifier(total uint) *Notifier {
 // Notify publishes an event to all SSE observers. This function panics when it
 // is called more often than it is supposed to have been called.
 func (n *Notifier) Notify(eventType EventType, message string, increment bool) {
+	if n == nil {
+		return
+	}
+
 	notif := &notification{
 		Type:      eventType,
 		Message:   message,
@@ -7,10 +7,14 @@ import (
 	"errors"
 	"log"
 	"net/http"
+	"strconv"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/pathexp"
 
 	"github.com/manifoldco/torus-cli/daemon/logic"
 	"github.com/manifoldco/torus-cli/daemon/observer"
-	"github.com/manifoldco/torus-cli/identity"
 )
 
 func credentialsGetRoute(engine *logic.Engine, o *observer.Observer) http.HandlerFunc {
@@ -112,3 +116,71 @@ func credentialsPostRoute(engine *logic.Engine, o *observer.Observer) http.Handl
 		}
 	}
 }
+
+func credentialsHistoryRoute(engine *logic.Engine, o *observer.Observer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		q := r.URL.Query()
+
+		name := q.Get("name")
+		rawPathExp := q.Get("pathexp")
+		decrypt := q.Get("decrypt") == "true"
+
+		var limit int
+		if rawLimit := q.Get("limit"); rawLimit != "" {
+			var err error
+			limit, err = strconv.Atoi(rawLimit)
+			if err != nil || limit < 1 {
+				log.Printf("Error constructing request: invalid limit %q", rawLimit)
+				encodeResponseErr(w, &apitypes.Error{
+					Type: apitypes.BadRequestError,
+					Err:  []string{"The limit must be a positive number"},
+				})
+				return
+			}
+		}
+
+		if name == "" || rawPathExp == "" {
+			log.Printf("Error constructing request: missing name or pathexp")
+			encodeResponseErr(w, &apitypes.Error{
+				Type: apitypes.BadRequestError,
+				Err:  []string{"A name and pathexp are required"},
+			})
+			return
+		}
+
+		pe, err := pathexp.Parse(rawPathExp)
+		if err != nil {
+			log.Printf("Error parsing pathexp: %s", err)
+			encodeResponseErr(w, &apitypes.Error{
+				Type: apitypes.BadRequestError,
+				Err:  []string{err.Error()},
+			})
+			return
+		}
+
+		n, err := o.Notifier(ctx, 1)
+		if err != nil {
+			log.Printf("Error creating parent Notifier: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+
+		versions, err := engine.CredentialHistory(ctx, n, pe, name, decrypt, limit)
+		if err != nil {
+			// Rely on logs inside engine for debugging
+			encodeResponseErr(w, err)
+			return
+		}
+
+		n.Notify(observer.Finished, "Completed Operation", true)
+
+		enc := json.NewEncoder(w)
+		err = enc.Encode(versions)
+		if err != nil {
+			log.Printf("error encoding credential history: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package routes
+
+// This file contains routes related to verifying credential graphs
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/pathexp"
+
+	"github.com/manifoldco/torus-cli/daemon/logic"
+	"github.com/manifoldco/torus-cli/daemon/observer"
+)
+
+func graphVerifyRoute(engine *logic.Engine, o *observer.Observer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		rawPathExp := r.URL.Query().Get("pathexp")
+		if rawPathExp == "" {
+			encodeResponseErr(w, &apitypes.Error{
+				Type: apitypes.BadRequestError,
+				Err:  []string{"A pathexp is required"},
+			})
+			return
+		}
+
+		pe, err := pathexp.Parse(rawPathExp)
+		if err != nil {
+			log.Printf("Error parsing pathexp: %s", err)
+			encodeResponseErr(w, &apitypes.Error{
+				Type: apitypes.BadRequestError,
+				Err:  []string{err.Error()},
+			})
+			return
+		}
+
+		n, err := o.Notifier(ctx, 1)
+		if err != nil {
+			log.Printf("Error creating Notifier: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+
+		result, err := engine.VerifyGraph(ctx, n, pe)
+		if err != nil {
+			log.Printf("error verifying credential graph: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+
+		n.Notify(observer.Finished, "Completed Operation", true)
+
+		enc := json.NewEncoder(w)
+		err = enc.Encode(result)
+		if err != nil {
+			log.Printf("error encoding graph verification: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+	}
+}
@@ -95,3 +95,43 @@ func keypairsRevokeRoute(engine *logic.Engine, o *observer.Observer) http.Handle
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
+
+func keypairsRotateRoute(engine *logic.Engine, o *observer.Observer) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		dec := json.NewDecoder(r.Body)
+		rotReq := keyPairRequest{}
+		err := dec.Decode(&rotReq)
+		if err != nil {
+			encodeResponseErr(w, err)
+			return
+		}
+
+		if rotReq.OrgID == nil {
+			encodeResponseErr(w, &apitypes.Error{
+				Type: apitypes.BadRequestError,
+				Err:  []string{"missing or invalid OrgID provided"},
+			})
+			return
+		}
+
+		n, err := o.Notifier(ctx, 1)
+		if err != nil {
+			log.Printf("Error creating Notifier: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+
+		err = engine.RotateKeypairs(ctx, n, rotReq.OrgID)
+		if err != nil {
+			// Rely on engine for debug logging
+			encodeResponseErr(w, err)
+			return
+		}
+
+		n.Notify(observer.Progress, "Keypairs rotated", true)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
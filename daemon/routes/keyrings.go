@@ -0,0 +1,64 @@
+package routes
+
+// This file contains routes related to keyrings
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+
+	"github.com/manifoldco/torus-cli/daemon/logic"
+	"github.com/manifoldco/torus-cli/daemon/observer"
+)
+
+func keyringsRekeyRoute(engine *logic.Engine, o *observer.Observer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		dec := json.NewDecoder(r.Body)
+		req := apitypes.KeyringRekeyRequest{}
+		err := dec.Decode(&req)
+		if err != nil {
+			encodeResponseErr(w, err)
+			return
+		}
+
+		if req.PathExp == nil {
+			encodeResponseErr(w, &apitypes.Error{
+				Type: apitypes.BadRequestError,
+				Err:  []string{"missing or invalid pathexp provided"},
+			})
+			return
+		}
+
+		n, err := o.Notifier(ctx, 1)
+		if err != nil {
+			log.Printf("Error creating Notifier: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+
+		rekeyed, err := engine.RekeyKeyrings(ctx, n, req.PathExp, req.Force)
+		if err != nil {
+			log.Printf("error rekeying keyrings: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+
+		n.Notify(observer.Progress, "Keyrings rekeyed", true)
+
+		if rekeyed == nil {
+			rekeyed = []apitypes.RekeyedKeyring{}
+		}
+
+		enc := json.NewEncoder(w)
+		err = enc.Encode(rekeyed)
+		if err != nil {
+			log.Printf("error encoding rekeyed keyrings: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+	}
+}
@@ -33,21 +33,32 @@ func NewRouteMux(c *config.Config, s session.Session, db *db.DB,
 	mux.GetFunc("/session", sessionRoute(s))
 	mux.GetFunc("/self", selfRoute(s))
 	mux.PatchFunc("/self", updateSelfRoute(client, s, lEngine))
+	mux.PutFunc("/self/password", updatePasswordRoute(s, lEngine))
+	mux.PostFunc("/self/recovery-kit/export", recoveryKitExportRoute(s, lEngine))
+	mux.PostFunc("/self/recovery-kit/import", recoveryKitImportRoute(lEngine))
 
 	mux.PostFunc("/machines", machinesCreateRoute(client, s, lEngine, o))
 
 	mux.PostFunc("/keypairs/generate", keypairsGenerateRoute(lEngine, o))
 	mux.PostFunc("/keypairs/revoke", keypairsRevokeRoute(lEngine, o))
+	mux.PostFunc("/keypairs/rotate", keypairsRotateRoute(lEngine, o))
+
+	mux.PostFunc("/keyrings/rekey", keyringsRekeyRoute(lEngine, o))
+
+	mux.GetFunc("/graph/verify", graphVerifyRoute(lEngine, o))
 
 	mux.GetFunc("/credentials", credentialsGetRoute(lEngine, o))
 	mux.PostFunc("/credentials", credentialsPostRoute(lEngine, o))
+	mux.GetFunc("/credentials/history", credentialsHistoryRoute(lEngine, o))
 
 	mux.PostFunc("/org-invites/:id/approve",
 		orgInvitesApproveRoute(lEngine, o))
 
 	mux.GetFunc("/worklog", worklogListRoute(lEngine, o))
+	mux.PostFunc("/worklog", worklogResolveRoute(lEngine, o))
 	mux.GetFunc("/worklog/:id", worklogGetRoute(lEngine, o))
-	mux.PostFunc("/worklog/:id", worklogResolveRoute(lEngine, o))
+	mux.PutFunc("/worklog/:id/dismissal", worklogDismissRoute(lEngine, o))
+	mux.DeleteFunc("/worklog/:id/dismissal", worklogRestoreRoute(lEngine, o))
 
 	mux.GetFunc("/version", func(w http.ResponseWriter, r *http.Request) {
 		enc := json.NewEncoder(w)
@@ -172,6 +172,116 @@ func updateSelfRoute(client *registry.Client, s session.Session, e *logic.Engine
 	}
 }
 
+func updatePasswordRoute(s session.Session, e *logic.Engine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c := r.Context()
+		dec := json.NewDecoder(r.Body)
+
+		if err := checkLoggedIn(s); err != nil {
+			encodeResponseErr(w, err)
+			return
+		}
+
+		req := apitypes.PasswordUpdate{}
+		err := dec.Decode(&req)
+		if err != nil {
+			encodeResponseErr(w, err)
+			return
+		}
+		if req.CurrentPassword == "" || req.NewPassword == "" {
+			encodeResponseErr(w, &apitypes.Error{
+				Type: apitypes.BadRequestError,
+				Err:  []string{"missing password fields"},
+			})
+			return
+		}
+
+		result, err := e.Session.ChangePassword(c, req.CurrentPassword, req.NewPassword)
+		if err != nil {
+			encodeResponseErr(w, err)
+			return
+		}
+
+		enc := json.NewEncoder(w)
+		err = enc.Encode(result)
+		if err != nil {
+			encodeResponseErr(w, err)
+		}
+	}
+}
+
+func recoveryKitExportRoute(s session.Session, e *logic.Engine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c := r.Context()
+		dec := json.NewDecoder(r.Body)
+
+		if err := checkLoggedIn(s); err != nil {
+			encodeResponseErr(w, err)
+			return
+		}
+
+		req := apitypes.RecoveryKitExportRequest{}
+		err := dec.Decode(&req)
+		if err != nil {
+			encodeResponseErr(w, err)
+			return
+		}
+		if req.Password == "" {
+			encodeResponseErr(w, &apitypes.Error{
+				Type: apitypes.BadRequestError,
+				Err:  []string{"missing password"},
+			})
+			return
+		}
+
+		result, err := e.Session.ExportRecoveryKit(c, req.Password)
+		if err != nil {
+			encodeResponseErr(w, err)
+			return
+		}
+
+		enc := json.NewEncoder(w)
+		err = enc.Encode(result)
+		if err != nil {
+			encodeResponseErr(w, err)
+		}
+	}
+}
+
+func recoveryKitImportRoute(e *logic.Engine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c := r.Context()
+		dec := json.NewDecoder(r.Body)
+
+		req := apitypes.RecoveryKitImport{}
+		err := dec.Decode(&req)
+		if err != nil {
+			encodeResponseErr(w, err)
+			return
+		}
+		if req.Kit == nil || req.Code == "" || req.Email == "" || req.NewPassword == "" {
+			encodeResponseErr(w, &apitypes.Error{
+				Type: apitypes.BadRequestError,
+				Err:  []string{"missing recovery kit fields"},
+			})
+			return
+		}
+
+		result, err := e.Session.ImportRecoveryKit(c, req.Kit, req.Code, req.Email, req.NewPassword)
+		if err != nil {
+			log.Printf("Could not import recovery kit: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+
+		enc := json.NewEncoder(w)
+		err = enc.Encode(result)
+		if err != nil {
+			encodeResponseErr(w, err)
+		}
+	}
+}
+
 func selfRoute(s session.Session) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		enc := json.NewEncoder(w)
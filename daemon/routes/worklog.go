@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/go-zoo/bone"
 
@@ -23,7 +24,13 @@ func worklogListRoute(engine *logic.Engine, o *observer.Observer) http.HandlerFu
 			return
 		}
 
-		items, err := engine.Worklog.List(ctx, &orgID, apitypes.AnyWorklogType)
+		opts, err := worklogOptions(r)
+		if err != nil {
+			encodeResponseErr(w, err)
+			return
+		}
+
+		items, err := engine.Worklog.List(ctx, &orgID, apitypes.AnyWorklogType, opts)
 		if err != nil {
 			log.Printf("error getting worklog list: %s", err)
 			encodeResponseErr(w, err)
@@ -55,7 +62,13 @@ func worklogGetRoute(engine *logic.Engine, o *observer.Observer) http.HandlerFun
 			return
 		}
 
-		item, err := engine.Worklog.Get(ctx, &orgID, &ident)
+		opts, err := worklogOptions(r)
+		if err != nil {
+			encodeResponseErr(w, err)
+			return
+		}
+
+		item, err := engine.Worklog.Get(ctx, &orgID, &ident, opts)
 		if err != nil {
 			log.Printf("error getting worklog item: %s", err)
 			encodeResponseErr(w, err)
@@ -86,24 +99,147 @@ func worklogResolveRoute(engine *logic.Engine, o *observer.Observer) http.Handle
 			return
 		}
 
-		ident, err := apitypes.DecodeWorklogIDFromString(bone.GetValue(r, "id"))
+		opts, err := worklogOptions(r)
+		if err != nil {
+			encodeResponseErr(w, err)
+			return
+		}
+
+		dec := json.NewDecoder(r.Body)
+		req := apitypes.WorklogResolveRequest{}
+		err = dec.Decode(&req)
 		if err != nil {
 			encodeResponseErr(w, err)
 			return
 		}
 
-		n, err := o.Notifier(ctx, 1)
+		n, err := o.Notifier(ctx, uint(len(req.IDs)))
 		if err != nil {
 			log.Printf("Error creating Notifier: %s", err)
 			encodeResponseErr(w, err)
 			return
 		}
 
-		err = engine.Worklog.Resolve(ctx, n, &orgID, &ident)
+		results, err := engine.Worklog.Resolve(ctx, n, &orgID, req.IDs, opts)
+		if err != nil {
+			log.Printf("error resolving worklog items: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+
+		enc := json.NewEncoder(w)
+		err = enc.Encode(results)
 		if err != nil {
-			log.Printf("error resolving worklog item: %s", err)
+			log.Printf("error encoding worklog resolve resp: %s", err)
 			encodeResponseErr(w, err)
 			return
 		}
 	}
 }
+
+func worklogDismissRoute(engine *logic.Engine, o *observer.Observer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		orgID, err := identity.DecodeFromString(r.URL.Query().Get("org_id"))
+		if err != nil {
+			encodeResponseErr(w, err)
+			return
+		}
+
+		ident, err := apitypes.DecodeWorklogIDFromString(bone.GetValue(r, "id"))
+		if err != nil {
+			encodeResponseErr(w, err)
+			return
+		}
+
+		opts, err := worklogOptions(r)
+		if err != nil {
+			encodeResponseErr(w, err)
+			return
+		}
+
+		dec := json.NewDecoder(r.Body)
+		req := apitypes.WorklogDismissRequest{}
+		err = dec.Decode(&req)
+		if err != nil {
+			encodeResponseErr(w, err)
+			return
+		}
+
+		item, err := engine.Worklog.Dismiss(ctx, &orgID, &ident, req.Until, req.Reason, opts)
+		if err != nil {
+			log.Printf("error dismissing worklog item: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+
+		if item == nil {
+			encodeResponseErr(w, notFoundError)
+			return
+		}
+
+		enc := json.NewEncoder(w)
+		err = enc.Encode(item)
+		if err != nil {
+			log.Printf("error encoding worklog dismiss resp: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+	}
+}
+
+func worklogRestoreRoute(engine *logic.Engine, o *observer.Observer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		orgID, err := identity.DecodeFromString(r.URL.Query().Get("org_id"))
+		if err != nil {
+			encodeResponseErr(w, err)
+			return
+		}
+
+		ident, err := apitypes.DecodeWorklogIDFromString(bone.GetValue(r, "id"))
+		if err != nil {
+			encodeResponseErr(w, err)
+			return
+		}
+
+		err = engine.Worklog.Restore(&orgID, &ident)
+		if err != nil {
+			log.Printf("error restoring worklog item: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+	}
+}
+
+// worklogOptions returns the WorklogOptions given in the request's query
+// string.
+func worklogOptions(r *http.Request) (*apitypes.WorklogOptions, error) {
+	opts := &apitypes.WorklogOptions{}
+
+	if raw := r.URL.Query().Get("expiry_window"); raw != "" {
+		window, err := time.ParseDuration(raw)
+		if err != nil || window < 0 {
+			return nil, &apitypes.Error{
+				Type: apitypes.BadRequestError,
+				Err:  []string{"Invalid expiry_window: " + raw},
+			}
+		}
+		opts.ExpiryWindow = window
+	}
+
+	if raw := r.URL.Query().Get("max_secret_age"); raw != "" {
+		policy, err := apitypes.ParseSecretAgePolicy(raw)
+		if err != nil {
+			return nil, &apitypes.Error{
+				Type: apitypes.BadRequestError,
+				Err:  []string{err.Error()},
+			}
+		}
+		opts.SecretAge = policy
+	}
+
+	opts.MachineRevocations = r.URL.Query().Get("machine_revocations") == "true"
+	opts.All = r.URL.Query().Get("all") == "true"
+
+	return opts, nil
+}
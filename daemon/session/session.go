@@ -35,6 +35,7 @@ type Session interface {
 	Type() apitypes.SessionType
 	Set(apitypes.SessionType, envelope.Envelope, envelope.Envelope, []byte, []byte) error
 	SetIdentity(apitypes.SessionType, envelope.Envelope, envelope.Envelope) error
+	SetPassphrase([]byte) error
 	ID() *identity.ID
 	AuthID() *identity.ID
 	Token() []byte
@@ -204,6 +205,31 @@ func (s *session) SetIdentity(sessionType apitypes.SessionType, identity, auth e
 	return nil
 }
 
+// SetPassphrase replaces the passphrase stored in this session, such as after
+// the user has changed their password.
+func (s *session) SetPassphrase(passphrase []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.Type() == apitypes.NotLoggedIn {
+		return createNotLoggedInError()
+	}
+
+	if len(passphrase) == 0 {
+		return errors.New("Passphrase must not be empty")
+	}
+
+	secret, err := s.guard.Secret(passphrase)
+	if err != nil {
+		return err
+	}
+
+	s.passphrase.Destroy()
+	s.passphrase = secret
+
+	return nil
+}
+
 func createNotLoggedInError() error {
 	return &apitypes.Error{
 		Type: apitypes.UnauthorizedError,
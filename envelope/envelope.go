@@ -4,6 +4,7 @@ package envelope
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/manifoldco/go-base64"
 
@@ -162,6 +163,7 @@ type KeyringInf interface {
 	Envelope
 	PathExp() *pathexp.PathExp
 	OrgID() *identity.ID
+	Created() time.Time
 	GetVersion() uint8 // Return the schema version of the keyring
 }
 
@@ -181,6 +183,11 @@ func (k *KeyringV1) PathExp() *pathexp.PathExp {
 	return k.Body.PathExp
 }
 
+// Created returns the time at which this Keyring was created.
+func (k *KeyringV1) Created() time.Time {
+	return k.Body.Created
+}
+
 // GetVersion returns the schema version of this Keyring.
 func (k *Keyring) GetVersion() uint8 {
 	return k.Version
@@ -197,6 +204,11 @@ func (k *Keyring) PathExp() *pathexp.PathExp {
 	return k.Body.PathExp
 }
 
+// Created returns the time at which this Keyring was created.
+func (k *Keyring) Created() time.Time {
+	return k.Body.Created
+}
+
 // CredentialInf is the common interface for all Credential schema versions.
 type CredentialInf interface {
 	Envelope
@@ -214,6 +226,8 @@ type CredentialInf interface {
 
 	OrgID() *identity.ID
 	ProjectID() *identity.ID
+
+	SigningKeyID() *identity.ID
 }
 
 // GetVersion returns the schema version of this Credential.
@@ -269,6 +283,11 @@ func (c *CredentialV1) ProjectID() *identity.ID {
 	return c.Body.ProjectID
 }
 
+// SigningKeyID returns the ID of the public key that signed this Credential.
+func (c *CredentialV1) SigningKeyID() *identity.ID {
+	return c.Signature.PublicKeyID
+}
+
 // GetVersion returns the schema version of this Credential.
 func (c *Credential) GetVersion() uint8 {
 	return c.Version
@@ -320,3 +339,8 @@ func (c *Credential) OrgID() *identity.ID {
 func (c *Credential) ProjectID() *identity.ID {
 	return c.Body.ProjectID
 }
+
+// SigningKeyID returns the ID of the public key that signed this Credential.
+func (c *Credential) SigningKeyID() *identity.ID {
+	return c.Signature.PublicKeyID
+}
@@ -1,7 +1,7 @@
 /*
 Package pathexp provides a representation of path expressions; locations of
 secrets within the org/project/environment/service/identity/instance hierarchy
-supporting globs and alternation.
+supporting globs, alternation, and negation.
 
 Paths being parsed support <double-glob> but will be converted to <full-glob>
 in the resulting pathexp
@@ -16,13 +16,21 @@ Grammar:
 	<identity>    ::= <multiple>
 	<instance>    ::= <multiple>
 
-	<multiple>         ::= <alternation> | <glob-or-literal> | <full-glob>
+	<multiple>         ::= <negation> | <alternation> | <glob-or-literal> | <full-glob>
+	<negation>         ::= "!" <alternation> | "!" <glob-or-literal>
 	<alternation>      ::= "[" <alternation-body> "]"
 	<alternation-body> ::= <glob-or-literal> | <glob-or-literal> "|" <alternation-body>
-	<glob-or-literal>  ::= <glob> | <literal>
+	<glob-or-literal>  ::= <glob> | <pattern> | <literal>
 	<glob>             ::= <literal> "*"
+	<pattern>          ::= [<literal>] <pattern-body> ["*"]
+	<pattern-body>     ::= "*" [a-z0-9\-\_]+ | "*" [a-z0-9\-\_]+ <pattern-body>
 	<literal>          ::= [a-z0-9][a-z0-9\-\_]{0,63}
 	<fullglob>         ::= "*"
+
+A <glob> matches values starting with its literal, while a <pattern> is a
+suffix or infix glob, such as "*-worker" or "api-*-worker". A <negation>
+matches every value not matched by its alternation or glob, so
+"![prod|prod-eu]" matches every environment except prod and prod-eu.
 */
 package pathexp
 
@@ -40,6 +48,7 @@ var (
 	globRe         = regexp.MustCompile(`^(` + slugstr + `)(\*?)$`)
 	fullglobOrGlob = regexp.MustCompile(`(^\*$)|(?:^(` + slugstr + `)(\*?)$)`)
 	doubleGlob     = regexp.MustCompile(`^\*\*$`)
+	patternRe      = regexp.MustCompile(`^(?:[a-z\d][-_a-z\d]*)?(?:\*[-_a-z\d]+)+\*?$`)
 )
 
 const (
@@ -69,8 +78,10 @@ type segment interface {
 
 type literal string
 type glob string
+type pattern string
 type fullglob struct{}
 type alternation []segment
+type negation struct{ segment }
 
 func (l literal) String() string { return string(l) }
 func (l literal) Contains(subject string) bool {
@@ -94,6 +105,35 @@ func GlobContains(value, subject string) bool {
 	return gl.Contains(subject)
 }
 
+func (p pattern) String() string { return string(p) }
+func (p pattern) Contains(subject string) bool {
+	// Alternations and negations are never matched, as for globs.
+	if strings.HasPrefix(subject, "[") || strings.HasPrefix(subject, "!") {
+		return false
+	}
+
+	parts := strings.Split(string(p), "*")
+	first, last := parts[0], parts[len(parts)-1]
+	if len(subject) < len(first)+len(last) ||
+		!strings.HasPrefix(subject, first) || !strings.HasSuffix(subject, last) {
+		return false
+	}
+
+	subject = subject[len(first) : len(subject)-len(last)]
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(subject, part)
+		if idx == -1 {
+			return false
+		}
+		subject = subject[idx+len(part):]
+	}
+
+	return true
+}
+func (p pattern) Components() []string {
+	return []string{string(p)}
+}
+
 func (f fullglob) String() string { return "*" }
 func (f fullglob) Contains(subject string) bool {
 	return true
@@ -132,6 +172,89 @@ func (a alternation) Components() []string {
 	return out
 }
 
+func (n negation) String() string { return "!" + n.segment.String() }
+
+// Contains returns whether the subject is disjoint with the negated segment.
+// Globs and patterns are only known to be disjoint by their fixed prefixes
+// and suffixes, so a subject that can't be shown to be disjoint is not
+// contained.
+func (n negation) Contains(subject string) bool {
+	if strings.HasPrefix(subject, "!") {
+		other, err := parseSegment(subject[1:])
+		if err != nil {
+			return false
+		}
+
+		// !a contains !b when b contains everything a does.
+		for _, seg := range alternatives(n.segment) {
+			if !other.Contains(seg.String()) {
+				return false
+			}
+		}
+		return true
+	}
+
+	other, err := parseSegment(subject)
+	if err != nil {
+		return false
+	}
+
+	for _, a := range alternatives(n.segment) {
+		for _, b := range alternatives(other) {
+			if !disjoint(a, b) {
+				return false
+			}
+		}
+	}
+	return true
+}
+func (n negation) Components() []string {
+	return []string{n.String()}
+}
+
+// alternatives returns the segments of an alternation, or the segment itself.
+func alternatives(s segment) []segment {
+	if a, ok := s.(alternation); ok {
+		return a
+	}
+	return []segment{s}
+}
+
+// disjoint returns whether no value is matched by both of the literal, glob,
+// or pattern segments.
+func disjoint(a, b segment) bool {
+	if _, ok := a.(fullglob); ok {
+		return false
+	}
+	if _, ok := b.(fullglob); ok {
+		return false
+	}
+	if l, ok := a.(literal); ok {
+		return !b.Contains(string(l))
+	}
+	if l, ok := b.(literal); ok {
+		return !a.Contains(string(l))
+	}
+
+	ap, as := affixes(a)
+	bp, bs := affixes(b)
+	return !(strings.HasPrefix(ap, bp) || strings.HasPrefix(bp, ap)) ||
+		!(strings.HasSuffix(as, bs) || strings.HasSuffix(bs, as))
+}
+
+// affixes returns the fixed prefix and suffix of a glob or pattern.
+func affixes(s segment) (string, string) {
+	switch st := s.(type) {
+	case glob:
+		return string(st), ""
+	case pattern:
+		parts := strings.Split(string(st), "*")
+		return parts[0], parts[len(parts)-1]
+	default:
+		panic("Bad type for segment!")
+	}
+}
+
 // compareSegmentType ranks the segments by their type specificity
 func compareSegmentType(a, b segment) int {
 	segs := []segment{a, b}
@@ -139,10 +262,14 @@ func compareSegmentType(a, b segment) int {
 	for i, seg := range segs {
 		switch seg.(type) {
 		case literal:
-			ranks[i] = 3
+			ranks[i] = 5
 		case glob:
-			ranks[i] = 2
+			ranks[i] = 4
+		case pattern:
+			ranks[i] = 3
 		case alternation:
+			ranks[i] = 2
+		case negation:
 			ranks[i] = 1
 		case fullglob:
 			ranks[i] = 0
@@ -173,6 +300,11 @@ func segmentsEqual(a, b segment) bool {
 			return at == bg
 		}
 		return false
+	case pattern:
+		if bp, ok := b.(pattern); ok {
+			return at == bp
+		}
+		return false
 	case alternation:
 		if ba, ok := b.(alternation); ok {
 			if len(at) != len(ba) {
@@ -193,6 +325,11 @@ func segmentsEqual(a, b segment) bool {
 		}
 		return false
 
+	case negation:
+		if bn, ok := b.(negation); ok {
+			return segmentsEqual(at.segment, bn.segment)
+		}
+		return false
 	case fullglob:
 		_, ok := b.(fullglob)
 		return ok
@@ -455,6 +592,14 @@ func parseMultiple(name string, parts []string, mustBeComplete bool) (segment, e
 	case 0:
 		return nil, errors.New("Empty segment alternation for " + name + ".")
 	case 1:
+		if strings.HasPrefix(parts[0], "!") {
+			return parseNegation(name, parts[0][1:])
+		}
+
+		if validPattern(parts[0]) {
+			return pattern(parts[0]), nil
+		}
+
 		matches := fullglobOrGlob.FindAllStringSubmatch(parts[0], -1)
 		if len(matches) != 1 {
 			if mustBeComplete {
@@ -475,6 +620,11 @@ func parseMultiple(name string, parts []string, mustBeComplete bool) (segment, e
 	default:
 		var res alternation
 		for _, part := range parts {
+			if validPattern(part) {
+				res = append(res, pattern(part))
+				continue
+			}
+
 			matches := globRe.FindAllStringSubmatch(part, -1)
 			if len(matches) != 1 {
 				return nil, errors.New("Invalid " + name + ".")
@@ -493,6 +643,44 @@ func parseMultiple(name string, parts []string, mustBeComplete bool) (segment, e
 	}
 }
 
+// parseNegation parses the segment following a "!". Unlike other segments,
+// an invalid negation is never treated as a full glob.
+func parseNegation(name, raw string) (segment, error) {
+	parts, err := Split(name, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	seg, err := parseMultiple(name, parts, true)
+	if err != nil {
+		return nil, err
+	}
+
+	switch seg.(type) {
+	case fullglob, negation:
+		return nil, errors.New("Invalid negation for " + name + ".")
+	}
+
+	return negation{seg}, nil
+}
+
+// parseSegment parses a single segment, as returned by its String method.
+func parseSegment(raw string) (segment, error) {
+	parts, err := Split("segment", raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseMultiple("segment", parts, true)
+}
+
+// validPattern returns whether the subject is a suffix or infix glob. Prefix
+// globs are matched as a glob instead.
+func validPattern(subject string) bool {
+	return patternRe.MatchString(subject) &&
+		len(strings.Replace(subject, "*", "", -1)) <= 64
+}
+
 // Equal returns a bool indicating if the two PathExps are equivalent.
 func (pe *PathExp) Equal(other *PathExp) bool {
 
@@ -523,7 +711,9 @@ func (pe *PathExp) Equal(other *PathExp) bool {
 // Segment specificity is, from most to least specific:
 //	- <literal>
 //  - <glob>
+//  - <pattern>
 //  - <alternation>
+//  - <negation>
 //  - <fullglob>
 //
 // It is assumed that the provided PathExps are not disjoint.
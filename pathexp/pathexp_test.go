@@ -45,7 +45,9 @@ func TestParse(t *testing.T) {
 	)
 
 	// Check globs and alternation for everything else. they should be valid
-	multiple := []string{"*", "thing*", "a*", "[a|bc]", "[a|bc|d]", "[a*|c]"}
+	multiple := []string{"*", "thing*", "a*", "[a|bc]", "[a|bc|d]", "[a*|c]",
+		"*-worker", "api-*-worker", "*work*", "a*b*c", "[*-worker|api]",
+		"!prod", "!prod*", "!*-eu", "![prod|prod-eu]"}
 	prefix := "/org/project"
 	parts = []string{"env", "service", "user", "instance"}
 	for i := 0; i < len(parts); i++ {
@@ -90,6 +92,26 @@ func TestParse(t *testing.T) {
 		valid: false,
 	})
 
+	// Suffix and infix globs must still only contain slug characters
+	testCases = append(testCases,
+		tc{path: "/o/p/e/s/u/-*", valid: false},
+		tc{path: "/o/p/e/s/u/*-", valid: true},
+		tc{path: "/o/p/e/s/u/*A", valid: false},
+		tc{path: "/o/p/e/s/u/a**b", valid: false},
+		tc{path: "/o/p/e/s/u/*" + strings.Repeat("a", 80), valid: false},
+	)
+
+	// Negation must be of a glob, literal, or alternation
+	testCases = append(testCases,
+		tc{path: "/o/p/e/s/u/!", valid: false},
+		tc{path: "/o/p/e/s/u/!*", valid: false},
+		tc{path: "/o/p/e/s/u/!!i", valid: false},
+		tc{path: "/o/p/e/s/u/![i]", valid: false},
+		tc{path: "/o/p/e/s/u/[!i|j]", valid: false},
+		tc{path: "/!o/p/e/s/u/i", valid: false},
+		tc{path: "/o/!p/e/s/u/i", valid: false},
+	)
+
 	// An empty segment is invalid
 	testCases = append(testCases, tc{
 		path:  "/org/project/env/service//instance",
@@ -114,6 +136,10 @@ func TestParse(t *testing.T) {
 		"/org/project/env/*/user/instance",
 		"/org/project/env/[abc|def]/user/instance",
 		"/org/project/env/[abc|def|thing-*]/user/instance",
+		"/org/project/env/*-worker/user/instance",
+		"/org/project/env/api-*-worker*/user/instance",
+		"/org/project/![prod|prod-eu]/service/user/instance",
+		"/org/project/!dev-*/[*-worker|api]/user/instance",
 	}
 
 	for _, path := range paths {
@@ -157,6 +183,13 @@ func TestPathExpCompareSpecificity(t *testing.T) {
 		{a: "/o/p/e/[svc-*|boo]/u/i", b: "/o/p/e/sv*/u/i", res: -1},
 		{a: "/o/p/e/[svc-*|boo]/u/i", b: "/o/p/e/*/u/i", res: 1},
 		{a: "/o/p/e/s/u/i", b: "/o/p/e/s/u*/i", res: 1},
+
+		// pattern and negation cases
+		{a: "/o/p/e/svc-*/u/i", b: "/o/p/e/*-worker/u/i", res: 1},
+		{a: "/o/p/e/*-worker/u/i", b: "/o/p/e/[boo|sv*]/u/i", res: 1},
+		{a: "/o/p/e/*-worker/u/i", b: "/o/p/e/api-*-worker/u/i", res: 0},
+		{a: "/o/p/![prod|qa]/s/u/i", b: "/o/p/[dev|staging]/s/u/i", res: -1},
+		{a: "/o/p/!prod/s/u/i", b: "/o/p/*/s/u/i", res: 1},
 	}
 
 	for _, test := range testCases {
@@ -200,6 +233,16 @@ func TestEqual(t *testing.T) {
 		{a: "/o/p/e/s/u/i", b: "/o/p/e/[s|b-*]/u/i", equal: false},
 		{a: "/o/p/e/[c|d|e]/u/i", b: "/o/p/e/[s|b-*]/u/i", equal: false},
 		{a: "/o/p/e/[c|d|e]/u/i", b: "/o/p/e/[c|e|f]/u/i", equal: false},
+
+		{a: "/o/p/e/*-s/u/i", b: "/o/p/e/*-s/u/i", equal: true},
+		{a: "/o/p/!e/s/u/i", b: "/o/p/!e/s/u/i", equal: true},
+		{a: "/o/p/![e|f]/s/u/i", b: "/o/p/![f|e]/s/u/i", equal: true},
+
+		{a: "/o/p/e/*-s/u/i", b: "/o/p/e/*s/u/i", equal: false},
+		{a: "/o/p/e/s-*/u/i", b: "/o/p/e/*s-/u/i", equal: false},
+		{a: "/o/p/!e/s/u/i", b: "/o/p/e/s/u/i", equal: false},
+		{a: "/o/p/!e/s/u/i", b: "/o/p/!e*/s/u/i", equal: false},
+		{a: "/o/p/![e|f]/s/u/i", b: "/o/p/[e|f]/s/u/i", equal: false},
 	}
 
 	for _, test := range testCases {
@@ -312,6 +355,44 @@ func TestSegmentsContains(t *testing.T) {
 	if !pe.Identities.Contains("development") {
 		t.Errorf("FullGlob contains failed to match any value")
 	}
+
+	path = "/org/project/![prod|prod-eu]/*-worker/identity/api-*-v*"
+	pe, err = Parse(path)
+	if err != nil {
+		t.Errorf("Parsing of %s failed", path)
+	}
+
+	for _, env := range []string{"prod", "prod-eu"} {
+		if pe.Envs.Contains(env) {
+			t.Errorf("Negation contains matched negated value %s", env)
+		}
+	}
+
+	for _, env := range []string{"prod-us", "staging", "dev-matt"} {
+		if !pe.Envs.Contains(env) {
+			t.Errorf("Negation contains failed to match value %s", env)
+		}
+	}
+
+	for _, svc := range []string{"-worker", "api-worker", "a-worker-worker"} {
+		if !pe.Services.Contains(svc) {
+			t.Errorf("Suffix glob contains failed to match value %s", svc)
+		}
+	}
+
+	for _, svc := range []string{"worker", "api-worker-1"} {
+		if pe.Services.Contains(svc) {
+			t.Errorf("Suffix glob contains matched value %s", svc)
+		}
+	}
+
+	if !pe.Instances.Contains("api-1-v2") || !pe.Instances.Contains("api-1-v") {
+		t.Errorf("Infix glob contains failed to match value")
+	}
+
+	if pe.Instances.Contains("api-1") || pe.Instances.Contains("web-v2") {
+		t.Errorf("Infix glob contains matched value")
+	}
 }
 
 func TestExpContains(t *testing.T) {
@@ -336,6 +417,33 @@ func TestExpContains(t *testing.T) {
 		{"/o/p/e/s/u/i", "/o/p/e/[s|b]/u/i", false},
 		{"/o/p/e/[c|d|e]/u/i", "/o/p/e/s/u/i", false},
 		{"/o/p/e/[c|d|e]/u/i", "/o/p/e/[c|d|e]/u/i", false},
+
+		{"/o/p/e/*-worker/u/i", "/o/p/e/api-worker/u/i", true},
+		{"/o/p/e/*-worker/u/i", "/o/p/e/api-*-worker/u/i", true},
+		{"/o/p/e/*worker/u/i", "/o/p/e/*-worker/u/i", true},
+		{"/o/p/e/a*/u/i", "/o/p/e/a*-worker/u/i", true},
+		{"/o/p/*/*/u/i", "/o/p/!e/*-worker/u/i", true},
+		{"/o/p/!prod/s/u/i", "/o/p/staging/s/u/i", true},
+		{"/o/p/!prod/s/u/i", "/o/p/!prod/s/u/i", true},
+		{"/o/p/!prod/s/u/i", "/o/p/![prod|qa]/s/u/i", true},
+		{"/o/p/!prod*/s/u/i", "/o/p/!prod-eu/s/u/i", false},
+		{"/o/p/!prod-eu/s/u/i", "/o/p/!prod*/s/u/i", true},
+		{"/o/p/![prod|qa]/s/u/i", "/o/p/[dev|staging]/s/u/i", true},
+		{"/o/p/![prod|qa]/s/u/i", "/o/p/dev-*/s/u/i", true},
+		{"/o/p/!*-eu/s/u/i", "/o/p/*-us/s/u/i", true},
+		{"/o/p/!*-eu/s/u/i", "/o/p/prod-*/s/u/i", false},
+
+		{"/o/p/e/*-worker/u/i", "/o/p/e/worker/u/i", false},
+		{"/o/p/e/*-worker/u/i", "/o/p/e/api-*/u/i", false},
+		{"/o/p/e/*-worker/u/i", "/o/p/e/*/u/i", false},
+		{"/o/p/e/*-worker/u/i", "/o/p/e/[a-worker|b-worker]/u/i", false},
+		{"/o/p/e/*d*/u/i", "/o/p/e/![prod|qa]/u/i", false},
+		{"/o/p/!prod/s/u/i", "/o/p/prod/s/u/i", false},
+		{"/o/p/!prod/s/u/i", "/o/p/*/s/u/i", false},
+		{"/o/p/!prod/s/u/i", "/o/p/p*/s/u/i", false},
+		{"/o/p/!prod/s/u/i", "/o/p/[prod|qa]/s/u/i", false},
+		{"/o/p/![prod|qa]/s/u/i", "/o/p/!prod/s/u/i", false},
+		{"/o/p/e/s/u/i", "/o/p/!e/s/u/i", false},
 	}
 
 	for _, test := range testCases {
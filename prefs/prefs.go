@@ -22,6 +22,7 @@ const (
 type Preferences struct {
 	Core     Core     `ini:"core"`
 	Defaults Defaults `ini:"defaults"`
+	Worklog  Worklog  `ini:"worklog"`
 }
 
 // CountFields returns the number of defined fields on sub-field struct
@@ -55,6 +56,7 @@ type Core struct {
 	Vim                bool   `ini:"vim"`
 	EnableCheckUpdates bool   `ini:"check_updates"`
 	EnableColors       bool   `ini:"colors"`
+	VerifyGraph        bool   `ini:"verify_graph"`
 }
 
 // Defaults contains default values for use in command argument flags
@@ -65,6 +67,13 @@ type Defaults struct {
 	Service      string `ini:"service,omitempty"`
 }
 
+// Worklog contains options for finding worklog items
+type Worklog struct {
+	ExpiryWindow       string `ini:"expiry_window,omitempty"`
+	MaxSecretAge       string `ini:"max_secret_age,omitempty"`
+	MachineRevocations string `ini:"machine_revocations,omitempty"`
+}
+
 // SetValue for ini key on preferences struct
 func (prefs Preferences) SetValue(key string, value string) (Preferences, error) {
 	parts := strings.Split(key, ".")
@@ -29,6 +29,9 @@ var InviteCode StringPrompt
 // VerificationCode asks the user to provide a verification code
 var VerificationCode StringPrompt
 
+// RecoveryCode asks the user to provide an account recovery code
+var RecoveryCode StringPrompt
+
 // FullName asks the user to provide a full name
 var FullName StringPrompt
 
@@ -173,6 +176,7 @@ func init() {
 	Email = stringPrompt("Email", validate.Email)
 	InviteCode = stringPrompt("Invite Code", validate.InviteCode)
 	VerificationCode = stringPrompt("Verification Code", validate.VerificationCode)
+	RecoveryCode = stringPrompt("Recovery Code", validate.RecoveryCode)
 	FullName = stringPrompt("Full Name", validate.Name)
 	Username = stringPrompt("Username", validate.SlugValidator("Usernames"))
 	OrgName = stringPrompt("Org Name", validate.SlugValidator("Org names"))
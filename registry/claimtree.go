@@ -63,11 +63,13 @@ func (ct *ClaimTree) Find(id *identity.ID, mustActive bool) (*apitypes.PublicKey
 }
 
 // FindActive returns the PublicKeySegment for a non-revoked Public Key for
-// the given owner id.
+// the given owner id. If the owner is rotating their keys, the most recently
+// created active key is returned.
 //
 // If an active key cannot be found an error is returned
 func (ct *ClaimTree) FindActive(ownerID *identity.ID, t primitive.KeyType) (*apitypes.PublicKeySegment, error) {
-	for _, pks := range ct.PublicKeys {
+	var found *apitypes.PublicKeySegment
+	for i, pks := range ct.PublicKeys {
 		if *pks.PublicKey.Body.OwnerID != *ownerID {
 			continue
 		}
@@ -80,10 +82,17 @@ func (ct *ClaimTree) FindActive(ownerID *identity.ID, t primitive.KeyType) (*api
 			continue
 		}
 
-		return &pks, nil
+		if found == nil || pks.PublicKey.Body.Created.After(found.PublicKey.Body.Created) {
+			found = &ct.PublicKeys[i]
+		}
+	}
+
+	if found == nil {
+		return nil, ErrMissingKeyForOwner
 	}
 
-	return nil, ErrMissingKeyForOwner
+	pks := *found
+	return &pks, nil
 }
 
 // List returns a list of all claimtrees for a given orgID. If no orgID is
@@ -82,6 +82,9 @@ func (kp *Keypairs) Get(publicKeyID *identity.ID) (*ClaimedKeyPair, error) {
 // Select returns a keypair for the given type inside the specified
 // organization. If a valid key (non-revoked) cannot be found an error is
 // returned.
+//
+// If more than one valid key exists, as while keypairs are being rotated, the
+// most recently created is returned.
 func (kp *Keypairs) Select(orgID *identity.ID, t primitive.KeyType) (*ClaimedKeyPair, error) {
 	if orgID == nil {
 		return nil, errors.New("Invalid OrgID Provided to select keypairs")
@@ -92,13 +95,23 @@ func (kp *Keypairs) Select(orgID *identity.ID, t primitive.KeyType) (*ClaimedKey
 		return nil, ErrMissingKeysForOrg
 	}
 
-	for _, k := range possible {
-		if k.PublicKey.Body.KeyType == t && !k.Revoked() {
-			return &k, nil
+	var found *ClaimedKeyPair
+	for i, k := range possible {
+		if k.PublicKey.Body.KeyType != t || k.Revoked() {
+			continue
+		}
+
+		if found == nil || k.PublicKey.Body.Created.After(found.PublicKey.Body.Created) {
+			found = &possible[i]
 		}
 	}
 
-	return nil, ErrMissingValidKeypair
+	if found == nil {
+		return nil, ErrMissingValidKeypair
+	}
+
+	ckp := *found
+	return &ckp, nil
 }
 
 // All returns all keypairs including those which have been revoked.
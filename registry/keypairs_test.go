@@ -2,6 +2,7 @@ package registry
 
 import (
 	"testing"
+	"time"
 
 	gm "github.com/onsi/gomega"
 
@@ -119,6 +120,27 @@ func TestKeypairs(t *testing.T) {
 		gm.Expect(found.PublicKey.ID).To(gm.Equal(ckpB.PublicKey.ID))
 	})
 
+	t.Run("selects the newest valid keypair", func(t *testing.T) {
+		gm.RegisterTestingT(t)
+
+		orgID, err := identity.NewMutable(&primitive.Org{})
+		gm.Expect(err).To(gm.BeNil())
+
+		kp := NewKeypairs()
+
+		ckpA := createCKP(&orgID, primitive.EncryptionKeyType, false)
+		ckpB := createCKP(&orgID, primitive.EncryptionKeyType, false)
+		ckpB.PublicKey.Body.Created = time.Now().UTC()
+		ckpC := createCKP(&orgID, primitive.EncryptionKeyType, false)
+
+		err = kp.Add(ckpA, ckpB, ckpC)
+		gm.Expect(err).To(gm.BeNil())
+
+		found, err := kp.Select(&orgID, primitive.EncryptionKeyType)
+		gm.Expect(err).To(gm.BeNil())
+		gm.Expect(found.PublicKey.ID).To(gm.Equal(ckpB.PublicKey.ID))
+	})
+
 	t.Run("will not select revoked keypairs", func(t *testing.T) {
 		gm.RegisterTestingT(t)
 
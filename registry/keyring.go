@@ -27,6 +27,7 @@ type KeyringSection interface {
 	GetKeyring() envelope.KeyringInf
 	KeyringVersion() int
 	FindMember(*identity.ID) (*primitive.KeyringMember, *primitive.MEKShare, error)
+	FindMemberByPublicKeyID(*identity.ID) (*primitive.KeyringMember, *primitive.MEKShare, error)
 	FindMEKByKeyID(*identity.ID) (*primitive.MEKShare, error)
 	HasRevocations() bool
 	GetClaims() []envelope.KeyringMemberClaim
@@ -50,24 +51,43 @@ func (k *KeyringSectionV1) KeyringVersion() int {
 }
 
 // FindMember returns the membership and mekshare for the given user id.
+// If the user has more than one membership, as after rotating their keypairs,
+// the most recently created is returned.
 // The data is returned in V2 format.
 func (k *KeyringSectionV1) FindMember(id *identity.ID) (*primitive.KeyringMember, *primitive.MEKShare, error) {
-	var krm *primitive.KeyringMember
-	var mekshare *primitive.MEKShare
-	for _, m := range k.Members {
-		if *m.Body.OwnerID == *id {
-			krm, mekshare = convertV1KRM(&m)
-			break
+	var found *envelope.KeyringMemberV1
+	for i, m := range k.Members {
+		if *m.Body.OwnerID != *id {
+			continue
+		}
+
+		if found == nil || m.Body.Created.After(found.Body.Created) {
+			found = &k.Members[i]
 		}
 	}
 
-	if krm == nil {
+	if found == nil {
 		return nil, nil, ErrMemberNotFound
 	}
 
+	krm, mekshare := convertV1KRM(found)
 	return krm, mekshare, nil
 }
 
+// FindMemberByPublicKeyID returns the membership and mekshare encrypted for
+// the given public key id.
+// The data is returned in V2 format.
+func (k *KeyringSectionV1) FindMemberByPublicKeyID(id *identity.ID) (*primitive.KeyringMember, *primitive.MEKShare, error) {
+	for _, m := range k.Members {
+		if *m.Body.PublicKeyID == *id {
+			krm, mekshare := convertV1KRM(&m)
+			return krm, mekshare, nil
+		}
+	}
+
+	return nil, nil, ErrMemberNotFound
+}
+
 // FindMEKByKeyID returns the MEKShare for the given encrypting key id.
 //
 // The data is returned in the V2 format.
@@ -133,38 +153,61 @@ func (k *KeyringSectionV2) KeyringVersion() int {
 // FindMember returns the membership and mekshare for the given user id.
 //
 // An owner (user/machine token) may have multiple memberships, one per
-// encryption key. There will only be one unrevoked membership.
-// Either this unrevoked membership will be returned, or the result will error
-// with ErrMemberNotFound.
+// encryption key. Usually there will only be one unrevoked membership, but
+// after an owner rotates their keypairs, the membership for their old key may
+// remain unrevoked; the most recently created unrevoked membership is
+// returned. If none exist, the result will error with ErrMemberNotFound.
 func (k *KeyringSectionV2) FindMember(id *identity.ID) (*primitive.KeyringMember, *primitive.MEKShare, error) {
-	var krm *primitive.KeyringMember
-	var mekshare *primitive.MEKShare
+	var found *KeyringMember
 
-outerLoop:
-	for _, m := range k.Members {
-		if *m.Member.Body.OwnerID == *id {
-			// We've found the right owner. Now see if this membership is
-			// unrevoked.
-			// A revocation is always terminal for a claim chain, so if there's
-			// any revocations for this membership, we know it is invalid.
-			if krmIsRevoked(m, k.Claims) {
-				continue outerLoop
-			}
+	for i, m := range k.Members {
+		if *m.Member.Body.OwnerID != *id {
+			continue
+		}
 
-			krm = m.Member.Body
-			// We never get the MEKShare for another user returned.
-			if m.MEKShare != nil {
-				mekshare = m.MEKShare.Body
-			}
-			break
+		// We've found the right owner. Now see if this membership is
+		// unrevoked.
+		// A revocation is always terminal for a claim chain, so if there's
+		// any revocations for this membership, we know it is invalid.
+		if krmIsRevoked(m, k.Claims) {
+			continue
+		}
+
+		if found == nil || m.Member.Body.Created.After(found.Member.Body.Created) {
+			found = &k.Members[i]
 		}
 	}
 
-	if krm == nil {
+	if found == nil {
 		return nil, nil, ErrMemberNotFound
 	}
 
-	return krm, mekshare, nil
+	return memberBodies(found)
+}
+
+// FindMemberByPublicKeyID returns the unrevoked membership and mekshare
+// encrypted for the given public key id.
+func (k *KeyringSectionV2) FindMemberByPublicKeyID(id *identity.ID) (*primitive.KeyringMember, *primitive.MEKShare, error) {
+	for i, m := range k.Members {
+		if *m.Member.Body.PublicKeyID != *id || krmIsRevoked(m, k.Claims) {
+			continue
+		}
+
+		return memberBodies(&k.Members[i])
+	}
+
+	return nil, nil, ErrMemberNotFound
+}
+
+func memberBodies(m *KeyringMember) (*primitive.KeyringMember, *primitive.MEKShare, error) {
+	var mekshare *primitive.MEKShare
+
+	// We never get the MEKShare for another user returned.
+	if m.MEKShare != nil {
+		mekshare = m.MEKShare.Body
+	}
+
+	return m.Member.Body, mekshare, nil
 }
 
 // FindMEKByKeyID returns the mekshare for the given encrypting key id.
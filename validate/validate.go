@@ -12,6 +12,7 @@ const slugPattern = "^[a-z][a-z0-9\\-\\_]{0,63}$"
 const namePattern = "^[a-zA-Z\\s,\\.'\\-pL]{3,64}$"
 const inviteCodePattern = "(?i)^[0-9a-ht-zjkmnpqr]{10}$"
 const verifyCodePattern = "(?i)^[0-9a-ht-zjkmnpqr]{9}$"
+const recoveryCodePattern = "(?i)^[a-z2-7]{4}(-?[a-z2-7]{4}){7}$"
 
 const slugErrorPattern = "%s must be between 1 and 64 characters in length and only contain alphabetical letters, numbers, hyphens, and underscores."
 const nameErrorPattern = "%s must be between 3 and 64 characters in length and only contain letters, commas, periods, apostraphes, and hyphens."
@@ -124,6 +125,15 @@ func VerificationCode(input string) error {
 	return NewValidationError("Please enter a valid verification code. Make sure to copy the entire code from the email!")
 }
 
+// RecoveryCode validates whether the input meets the recovery code requirements
+func RecoveryCode(input string) error {
+	if govalidator.StringMatches(input, recoveryCodePattern) {
+		return nil
+	}
+
+	return NewValidationError("Please enter a valid recovery code. Make sure to enter the entire code you were given when exporting your recovery kit!")
+}
+
 // Description validates whether the input meets the descriptin requirements
 func Description(input, fieldName string) error {
 	if len(input) <= 500 {